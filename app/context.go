@@ -15,3 +15,4 @@ var TunTapService iface.TunTapInterface
 var WailsApp iface.WailsInterface
 
 var SerialService iface.SerialInterface
+var ControlService iface.ControlInterface
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"vilan/app"
+	"vilan/common"
+	"vilan/daemon"
+)
+
+// 不依赖Wails界面的构建,用于没有桌面环境的Linux服务器及路由设备
+// go build -o vilan-peer ./cmd/vilan-peer && ./vilan-peer daemon
+func main() {
+	common.ParseFlags()
+	fmt.Println("当前程序版本:", app.Version)
+	debug.SetMemoryLimit(1024 * 1024 * 50)
+	if flag.Arg(0) != "daemon" {
+		fmt.Println("用法:", os.Args[0], "[-d=true] daemon")
+		os.Exit(2)
+	}
+	if err := daemon.Run(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"vilan/control"
+	"vilan/model"
+)
+
+// Client 通过本地控制接口访问正在运行的终端程序
+type Client struct {
+	http    *http.Client
+	baseUrl string
+	token   string
+}
+
+// addr 为空时按配置文件中的control_addr确定, configPath 为终端程序的配置目录
+func NewClient(addr string, configPath string) (*Client, error) {
+	if len(addr) == 0 {
+		addr = loadControlAddr(configPath)
+	}
+	c := &Client{http: &http.Client{Timeout: 10 * time.Second}}
+	if strings.HasPrefix(addr, "unix://") {
+		path := strings.TrimPrefix(addr, "unix://")
+		if _, err := os.Stat(path); err == nil {
+			c.baseUrl = "http://localhost"
+			c.http.Transport = &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", path)
+				},
+			}
+			return c, nil
+		}
+		// unix socket 监听失败时终端程序改用本地回环端口
+		addr = control.DefaultTcpAddr
+	}
+	token, err := os.ReadFile(configPath + control.TokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("访问令牌读取失败(%s),请确认终端程序已运行且有权限读取该文件", err.Error())
+	}
+	c.token = strings.TrimSpace(string(token))
+	c.baseUrl = "http://" + addr
+	return c, nil
+}
+
+func loadControlAddr(configPath string) string {
+	conf := &model.AppConfig{}
+	if content, err := os.ReadFile(configPath + "config_app.json"); err == nil {
+		_ = json.Unmarshal(content, conf)
+	}
+	if len(conf.ControlAddr) > 0 {
+		return conf.ControlAddr
+	}
+	return control.DefaultAddr()
+}
+
+func (c *Client) Get(path string, out interface{}) error {
+	return c.do(http.MethodGet, path, nil, out)
+}
+
+func (c *Client) Post(path string, in interface{}, out interface{}) error {
+	return c.do(http.MethodPost, path, in, out)
+}
+
+func (c *Client) Delete(path string, out interface{}) error {
+	return c.do(http.MethodDelete, path, nil, out)
+}
+
+func (c *Client) do(method string, path string, in interface{}, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		content, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(content)
+	}
+	req, err := http.NewRequest(method, c.baseUrl+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if len(c.token) > 0 {
+		req.Header.Set(control.TokenHeader, c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("本地控制接口连接失败,请确认终端程序已运行: %s", err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		result := &model.Result{}
+		if e := json.NewDecoder(resp.Body).Decode(result); e != nil || len(result.Message) == 0 {
+			return errors.New(resp.Status)
+		}
+		return errors.New(result.Message)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
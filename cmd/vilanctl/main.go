@@ -0,0 +1,512 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"vilan/common"
+	"vilan/control"
+	"vilan/model"
+	"vilan/protocol"
+)
+
+// 命令行工具,通过本地控制接口操作正在运行的终端程序
+// go build -o vilanctl ./cmd/vilanctl && ./vilanctl peers
+const usage = `用法: vilanctl <命令> [参数] [选项]
+
+命令:
+  status                                   终端状态及流量
+  peers [--json] [--refresh]               组内终端列表
+  route add <终端>                         添加到目标终端内网的路由,可添加多个
+  route del <终端>                         删除到目标终端内网的路由
+  route list [--json]                      本程序添加的路由
+  links <终端> [--json]                    目标终端的网络连接
+  traffic [--json]                         各终端按路径的流量及速率
+  serial open <终端> <远程串口> <本地串口> [用户串口]
+  serial close <终端> <远程串口>
+  serial list <终端>                       远程串口列表
+  logs [--follow] [--clear]                运行日志
+
+<终端> 可以是名称、虚拟IP、MAC地址
+
+选项:
+  --addr    本地控制接口地址,默认读取配置文件中的control_addr
+  --config  终端程序配置目录,默认为程序所在目录下的config
+`
+
+var logTypeNames = map[common.LogType]string{
+	common.Error: "Error",
+	common.Warn:  "Warn",
+	common.Info:  "Info",
+	common.Debug: "Debug",
+}
+
+type command struct {
+	flags   *flag.FlagSet
+	addr    *string
+	config  *string
+	jsonOut *bool
+	args    []string
+}
+
+func main() {
+	args := os.Args[1:]
+	if len(args) == 0 {
+		fmt.Print(usage)
+		os.Exit(2)
+	}
+	var err error
+	switch args[0] {
+	case "status":
+		err = runStatus(newCommand(args[0], args[1:]))
+	case "peers":
+		err = runPeers(args[1:])
+	case "route":
+		if len(args) < 2 {
+			err = errors.New("用法: vilanctl route add|del|list <终端>")
+			break
+		}
+		switch args[1] {
+		case "add":
+			err = runRouteAdd(newCommand("route add", args[2:]))
+		case "del":
+			err = runRouteDel(newCommand("route del", args[2:]))
+		case "list":
+			err = runRouteList(newCommand("route list", args[2:]))
+		default:
+			err = errors.New("用法: vilanctl route add|del|list <终端>")
+		}
+	case "links":
+		err = runLinks(newCommand(args[0], args[1:]))
+	case "traffic":
+		err = runTraffic(newCommand(args[0], args[1:]))
+	case "serial":
+		if len(args) < 2 {
+			err = errors.New("用法: vilanctl serial open|close|list <终端> ...")
+			break
+		}
+		err = runSerial(args[1], newCommand("serial "+args[1], args[2:]))
+	case "logs":
+		err = runLogs(args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		err = errors.New("未知命令:" + args[0])
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func newFlagSet(name string) *command {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	return &command{
+		flags:   fs,
+		addr:    fs.String("addr", "", "本地控制接口地址"),
+		config:  fs.String("config", common.GetConfigPath(), "终端程序配置目录"),
+		jsonOut: fs.Bool("json", false, "以JSON格式输出"),
+	}
+}
+
+func newCommand(name string, args []string) *command {
+	cmd := newFlagSet(name)
+	cmd.parse(args)
+	return cmd
+}
+
+// 允许选项与位置参数混合书写
+func (c *command) parse(args []string) {
+	for {
+		_ = c.flags.Parse(args)
+		args = c.flags.Args()
+		if len(args) == 0 {
+			return
+		}
+		c.args = append(c.args, args[0])
+		args = args[1:]
+	}
+}
+
+func (c *command) client() (*Client, error) {
+	configPath := *c.config
+	if len(configPath) > 0 && !strings.HasSuffix(configPath, string(os.PathSeparator)) {
+		configPath += string(os.PathSeparator)
+	}
+	return NewClient(*c.addr, configPath)
+}
+
+func (c *command) arg(i int, name string) (string, error) {
+	if i >= len(c.args) {
+		return "", errors.New("缺少参数:" + name)
+	}
+	return c.args[i], nil
+}
+
+func printJson(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func runStatus(cmd *command) error {
+	client, err := cmd.client()
+	if err != nil {
+		return err
+	}
+	info := &control.StateInfo{}
+	if err = client.Get("/api/state", info); err != nil {
+		return err
+	}
+	if *cmd.jsonOut {
+		return printJson(info)
+	}
+	fmt.Println("终端状态:", info.StateName)
+	fmt.Println("程序版本:", info.Version)
+	fmt.Println("虚拟网卡:", info.TapName)
+	if info.PathMtu > 0 {
+		fmt.Println("MTU:", info.Mtu, "(服务端路径MTU", info.PathMtu, ")")
+	} else {
+		fmt.Println("MTU:", info.Mtu)
+	}
+	fmt.Println("NAT类型:", info.NatType)
+	fmt.Println("服务地址:", info.Server)
+	fmt.Println("传输方式:", info.Transport)
+	if stats := info.Stats; stats != nil {
+		fmt.Println("发送/接收:", model.SizeFormat(stats.TransSend+stats.P2PSend), "/", model.SizeFormat(stats.TransReceive+stats.P2PReceive))
+		fmt.Println("P2P:", model.SizeFormat(stats.P2PSend), "/", model.SizeFormat(stats.P2PReceive))
+		fmt.Println("转发:", model.SizeFormat(stats.TransSend), "/", model.SizeFormat(stats.TransReceive))
+		fmt.Println("认证失败丢弃:", stats.AuthFail)
+		fmt.Println("重放丢弃:", stats.ReplayDrop)
+		fmt.Println("服务端延迟:", stats.ServerRtt, "ms")
+		if ratio := model.CompressRatio(stats.CompressRaw, stats.CompressSize); ratio != "" {
+			fmt.Println("压缩比:", ratio, "(", model.SizeFormat(stats.CompressRaw), "/", model.SizeFormat(stats.CompressSize), ")")
+		}
+	}
+	return nil
+}
+
+func runPeers(args []string) error {
+	cmd := newFlagSet("peers")
+	refresh := cmd.flags.Bool("refresh", false, "先向服务端请求刷新终端列表")
+	cmd.parse(args)
+	client, err := cmd.client()
+	if err != nil {
+		return err
+	}
+	peers := make([]*model.PeerInfo, 0)
+	if *refresh {
+		if err = client.Get("/api/peers?refresh=true", &peers); err != nil {
+			return err
+		}
+		time.Sleep(time.Second) // 等待服务端应答
+	}
+	if err = client.Get("/api/peers", &peers); err != nil {
+		return err
+	}
+	if *cmd.jsonOut {
+		return printJson(peers)
+	}
+	table := NewTable("名称", "虚拟IP", "MAC", "状态", "连接", "延迟/抖动/丢包", "NAT", "路由", "总流量(发/收)", "P2P", "转发", "速率(发/收)", "设备")
+	for _, p := range peers {
+		state, connect, quality, routed := "离线", "-", "-", ""
+		if p.Online {
+			state, connect = "在线", "转发"
+			if p.ConnectType == 1 {
+				connect = "P2P"
+			} else if p.ConnectType == 2 {
+				connect = "中转"
+			}
+			if p.Rtt > 0 || p.Loss > 0 {
+				quality = fmt.Sprintf("%dms/%dms/%d%%", p.Rtt, p.Jitter, p.Loss)
+			}
+		}
+		if p.Routed {
+			routed = "*"
+		}
+		table.Append(p.PeerName, p.NetAddr, peerMacStr(p.PeerMac), state, connect, quality, p.NatType, routed, p.TotalRxTx, p.P2PRxTx, p.TransRxTx, p.RateTxRx, p.DevType)
+	}
+	table.Print(os.Stdout)
+	return nil
+}
+
+func peerMacStr(macStr string) string {
+	mac, err := strconv.ParseUint(macStr, 10, 64)
+	if err != nil {
+		return macStr
+	}
+	return common.Uint64ToMacStr(mac)
+}
+
+// 按名称、虚拟IP、MAC查找终端
+func findPeer(client *Client, key string) (*model.PeerInfo, error) {
+	peers := make([]*model.PeerInfo, 0)
+	if err := client.Get("/api/peers", &peers); err != nil {
+		return nil, err
+	}
+	mac, e := common.MacStrToUint64(key)
+	for _, p := range peers {
+		if p.PeerMac == key || p.PeerName == key || strings.Split(p.NetAddr, "/")[0] == key ||
+			(e == nil && p.PeerMac == strconv.FormatUint(mac, 10)) {
+			return p, nil
+		}
+	}
+	return nil, errors.New("未找到终端:" + key)
+}
+
+func runRouteAdd(cmd *command) error {
+	key, err := cmd.arg(0, "<终端>")
+	if err != nil {
+		return err
+	}
+	client, err := cmd.client()
+	if err != nil {
+		return err
+	}
+	peer, err := findPeer(client, key)
+	if err != nil {
+		return err
+	}
+	result := make(map[string]interface{})
+	if err = client.Post("/api/routes", &control.PeerRequest{PeerMac: peer.PeerMac}, &result); err != nil {
+		return err
+	}
+	if result["result"] != true {
+		return errors.New("路由添加失败,详见终端程序日志")
+	}
+	fmt.Println("已添加到终端", peer.PeerName, "的路由")
+	return nil
+}
+
+func runRouteDel(cmd *command) error {
+	key, err := cmd.arg(0, "<终端>")
+	if err != nil {
+		return err
+	}
+	client, err := cmd.client()
+	if err != nil {
+		return err
+	}
+	peer, err := findPeer(client, key)
+	if err != nil {
+		return err
+	}
+	result := make(map[string]interface{})
+	if err = client.Delete("/api/routes?peer_mac="+url.QueryEscape(peer.PeerMac), &result); err != nil {
+		return err
+	}
+	if result["result"] != true {
+		return errors.New("路由删除失败,详见终端程序日志")
+	}
+	fmt.Println("已删除到终端", peer.PeerName, "的路由")
+	return nil
+}
+
+func runRouteList(cmd *command) error {
+	client, err := cmd.client()
+	if err != nil {
+		return err
+	}
+	routes := make([]*model.TapRoute, 0)
+	if err = client.Get("/api/routes", &routes); err != nil {
+		return err
+	}
+	if *cmd.jsonOut {
+		return printJson(routes)
+	}
+	table := NewTable("目标网段", "子网掩码", "网关", "网卡")
+	for _, r := range routes {
+		table.Append(r.Dst, r.Mask, r.Gw, r.Dev)
+	}
+	table.Print(os.Stdout)
+	return nil
+}
+
+func runLinks(cmd *command) error {
+	key, err := cmd.arg(0, "<终端>")
+	if err != nil {
+		return err
+	}
+	client, err := cmd.client()
+	if err != nil {
+		return err
+	}
+	peer, err := findPeer(client, key)
+	if err != nil {
+		return err
+	}
+	links := make([]*protocol.LinkInfo, 0)
+	if err = client.Get("/api/links?peer_mac="+url.QueryEscape(peer.PeerMac), &links); err != nil {
+		return err
+	}
+	if *cmd.jsonOut {
+		return printJson(links)
+	}
+	table := NewTable("地址", "接收", "发送")
+	for _, l := range links {
+		table.Append(common.Uint32toIpV4(l.Addr), model.SizeFormat(l.Rx), model.SizeFormat(l.Tx))
+	}
+	table.Print(os.Stdout)
+	return nil
+}
+
+// 速率为最近10秒的平均值,按经服务端转发的速率从大到小排列
+func runTraffic(cmd *command) error {
+	client, err := cmd.client()
+	if err != nil {
+		return err
+	}
+	list := make([]*model.PeerTraffic, 0)
+	if err = client.Get("/api/traffic", &list); err != nil {
+		return err
+	}
+	if *cmd.jsonOut {
+		return printJson(list)
+	}
+	peers := make([]*model.PeerInfo, 0)
+	_ = client.Get("/api/peers", &peers)
+	names := make(map[string]string)
+	for _, p := range peers {
+		names[p.PeerMac] = p.PeerName
+	}
+	rate := func(c *model.TrafficCounter) string {
+		if c == nil || c.TxBytes+c.RxBytes == 0 {
+			return "-"
+		}
+		return model.RateFormat(c.TxRate) + " / " + model.RateFormat(c.RxRate)
+	}
+	ratio := func(t *model.PeerTraffic) string {
+		if r := model.CompressRatio(t.ZipRaw, t.ZipSize); r != "" {
+			return r
+		}
+		return "-"
+	}
+	table := NewTable("名称", "MAC", "转发速率(发/收)", "P2P速率", "中转速率", "总流量(发/收)", "报文数(发/收)", "压缩比", "认证失败", "重放丢弃", "解压失败", "未协商丢弃")
+	for _, t := range list {
+		var tx, rx, txPackets, rxPackets uint64
+		for _, c := range []*model.TrafficCounter{t.Server, t.P2P, t.Relay} {
+			if c != nil {
+				tx, rx = tx+c.TxBytes, rx+c.RxBytes
+				txPackets, rxPackets = txPackets+c.TxPackets, rxPackets+c.RxPackets
+			}
+		}
+		table.Append(names[t.PeerMac], peerMacStr(t.PeerMac), rate(t.Server), rate(t.P2P), rate(t.Relay),
+			model.SizeFormat(tx)+" / "+model.SizeFormat(rx), fmt.Sprintf("%d / %d", txPackets, rxPackets), ratio(t),
+			strconv.FormatUint(t.AuthFail, 10), strconv.FormatUint(t.ReplayDrop, 10), strconv.FormatUint(t.Decompress, 10), strconv.FormatUint(t.NoSession, 10))
+	}
+	table.Print(os.Stdout)
+	return nil
+}
+
+func runSerial(action string, cmd *command) error {
+	key, err := cmd.arg(0, "<终端>")
+	if err != nil {
+		return err
+	}
+	client, err := cmd.client()
+	if err != nil {
+		return err
+	}
+	peer, err := findPeer(client, key)
+	if err != nil {
+		return err
+	}
+	req := &control.SerialRequest{PeerMac: peer.PeerMac}
+	result := make(map[string]interface{})
+	switch action {
+	case "open":
+		if req.Remote, err = cmd.arg(1, "<远程串口>"); err != nil {
+			return err
+		}
+		if req.Local, err = cmd.arg(2, "<本地串口>"); err != nil {
+			return err
+		}
+		req.User, _ = cmd.arg(3, "[用户串口]")
+		err = client.Post("/api/serial/open", req, &result)
+	case "close":
+		if req.Name, err = cmd.arg(1, "<远程串口>"); err != nil {
+			return err
+		}
+		err = client.Post("/api/serial/close", req, &result)
+	case "list":
+		err = client.Get("/api/serial/remote?peer_mac="+url.QueryEscape(peer.PeerMac), &result)
+		if err == nil && *cmd.jsonOut {
+			return printJson(result)
+		}
+	default:
+		return errors.New("未知命令: serial " + action)
+	}
+	if err != nil {
+		return err
+	}
+	if result["result"] != true {
+		if tip, ok := result["tip"].(string); ok {
+			return errors.New(tip)
+		}
+		return errors.New("操作失败")
+	}
+	if action == "list" {
+		list, _ := result["list"].([]interface{})
+		for _, item := range list {
+			if name, ok := item.(string); ok {
+				fmt.Println(name)
+			} else {
+				_ = printJson(item)
+			}
+		}
+		return nil
+	}
+	if tip, ok := result["tip"].(string); ok {
+		fmt.Println(tip)
+	}
+	return nil
+}
+
+type logEvent struct {
+	Id      uint64         `json:"id"`
+	LogType common.LogType `json:"logType"`
+	Time    string         `json:"time"`
+	Content string         `json:"content"`
+}
+
+func runLogs(args []string) error {
+	cmd := newFlagSet("logs")
+	follow := cmd.flags.Bool("follow", false, "持续输出新的日志")
+	clean := cmd.flags.Bool("clear", false, "清除日志记录")
+	cmd.parse(args)
+	client, err := cmd.client()
+	if err != nil {
+		return err
+	}
+	if *clean {
+		return client.Delete("/api/logs", nil)
+	}
+	var since uint64
+	for {
+		events := make([]*logEvent, 0)
+		if err = client.Get("/api/logs?since="+strconv.FormatUint(since, 10), &events); err != nil {
+			return err
+		}
+		// 新的在前,倒序输出
+		for i := len(events) - 1; i >= 0; i-- {
+			evt := events[i]
+			if *cmd.jsonOut {
+				_ = json.NewEncoder(os.Stdout).Encode(evt)
+			} else {
+				fmt.Printf("%s [%s] %s\n", evt.Time, logTypeNames[evt.LogType], evt.Content)
+			}
+			since = evt.Id
+		}
+		if !*follow {
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// 简单的文本表格,按终端显示宽度对齐(中文占两列)
+type Table struct {
+	header []string
+	rows   [][]string
+}
+
+func NewTable(header ...string) *Table {
+	return &Table{header: header}
+}
+
+func (t *Table) Append(row ...string) {
+	t.rows = append(t.rows, row)
+}
+
+func (t *Table) Print(w io.Writer) {
+	widths := make([]int, len(t.header))
+	for _, row := range append([][]string{t.header}, t.rows...) {
+		for i := range row {
+			if i < len(widths) && displayWidth(row[i]) > widths[i] {
+				widths[i] = displayWidth(row[i])
+			}
+		}
+	}
+	for _, row := range append([][]string{t.header}, t.rows...) {
+		line := strings.Builder{}
+		for i := range row {
+			if i >= len(widths) {
+				break
+			}
+			line.WriteString(row[i])
+			if i < len(row)-1 {
+				line.WriteString(strings.Repeat(" ", widths[i]-displayWidth(row[i])+2))
+			}
+		}
+		_, _ = fmt.Fprintln(w, line.String())
+	}
+}
+
+func displayWidth(s string) int {
+	width := 0
+	for len(s) > 0 {
+		r, size := utf8.DecodeRuneInString(s)
+		s = s[size:]
+		if r >= 0x1100 && (r <= 0x115F || (r >= 0x2E80 && r <= 0xA4CF) || (r >= 0xAC00 && r <= 0xD7A3) ||
+			(r >= 0xF900 && r <= 0xFAFF) || (r >= 0xFE30 && r <= 0xFE4F) || (r >= 0xFF00 && r <= 0xFF60) ||
+			(r >= 0xFFE0 && r <= 0xFFE6)) {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
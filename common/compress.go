@@ -0,0 +1,121 @@
+package common
+
+import (
+	"errors"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"strings"
+	"sync"
+)
+
+// 数据帧压缩,LZ4速度快,zstd压缩率高;按块压缩,不保留字典,每个报文可独立解压;
+// zstd编解码器创建失败时不使用zstd,也不告知对端可解压zstd
+
+const (
+	CompressNone uint32 = 0
+	CompressLz4  uint32 = 1
+	CompressZstd uint32 = 2
+)
+
+var (
+	ErrIncompressible = errors.New("数据不可压缩")
+	ErrDecompress     = errors.New("数据解压失败")
+)
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+)
+
+func initZstd() {
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1),
+		zstd.WithLowerEncoderMem(true), zstd.WithZeroFrames(true))
+	if err != nil {
+		return
+	}
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1), zstd.WithDecoderLowmem(true),
+		zstd.WithDecoderMaxMemory(64*1024))
+	if err != nil {
+		_ = encoder.Close()
+		return
+	}
+	zstdEncoder, zstdDecoder = encoder, decoder
+}
+
+func zstdReady() bool {
+	zstdOnce.Do(initZstd)
+	return zstdEncoder != nil && zstdDecoder != nil
+}
+
+// 本端可解压的算法(按位 1<<算法)
+func CompressCaps() uint32 {
+	caps := uint32(1 << CompressLz4)
+	if zstdReady() {
+		caps |= 1 << CompressZstd
+	}
+	return caps
+}
+
+// 配置的压缩算法名称 lz4 zstd,为空或none时不压缩
+func ParseCompress(name string) (uint32, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return CompressNone, nil
+	case "lz4":
+		return CompressLz4, nil
+	case "zstd":
+		if !zstdReady() {
+			return CompressNone, errors.New("zstd初始化失败")
+		}
+		return CompressZstd, nil
+	}
+	return CompressNone, errors.New("不支持的压缩算法:" + name)
+}
+
+// 压缩到dst,压缩后不小于原数据时返回 ErrIncompressible
+func Compress(alg uint32, src []byte, dst []byte) (int, error) {
+	switch alg {
+	case CompressLz4:
+		n, err := lz4.CompressBlock(src, dst, nil)
+		if err != nil || n == 0 || n >= len(src) {
+			return 0, ErrIncompressible
+		}
+		return n, nil
+	case CompressZstd:
+		if !zstdReady() {
+			return 0, ErrIncompressible
+		}
+		out := zstdEncoder.EncodeAll(src, dst[:0])
+		if len(out) >= len(src) || len(out) > len(dst) {
+			return 0, ErrIncompressible
+		}
+		return len(out), nil
+	}
+	return 0, errors.New("不支持的压缩算法")
+}
+
+// 解压到dst,超出dst长度时返回 ErrDecompress
+func Decompress(alg uint32, src []byte, dst []byte) (int, error) {
+	switch alg {
+	case CompressLz4:
+		n, err := lz4.UncompressBlock(src, dst)
+		if err != nil {
+			return 0, ErrDecompress
+		}
+		return n, nil
+	case CompressZstd:
+		if !zstdReady() {
+			return 0, ErrDecompress
+		}
+		out, err := zstdDecoder.DecodeAll(src, dst[:0])
+		if err != nil || len(out) > len(dst) {
+			return 0, ErrDecompress
+		}
+		if len(out) > 0 && &out[0] != &dst[0] { // 扩容后不在dst中
+			return 0, ErrDecompress
+		}
+		return len(out), nil
+	}
+	return 0, ErrDecompress
+}
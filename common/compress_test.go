@@ -0,0 +1,88 @@
+package common
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestCompress(t *testing.T) {
+	text := bytes.Repeat([]byte("temperature=21.5;humidity=40;"), 40)
+	random := make([]byte, 1024)
+	_, _ = rand.Read(random)
+	var cases = []struct {
+		name string
+		alg  uint32
+		data []byte
+		err  error
+	}{
+		{name: "lz4 text", alg: CompressLz4, data: text},
+		{name: "zstd text", alg: CompressZstd, data: text},
+		{name: "lz4 random", alg: CompressLz4, data: random, err: ErrIncompressible},
+		{name: "zstd random", alg: CompressZstd, data: random, err: ErrIncompressible},
+	}
+	for _, c := range cases {
+		zipped := make([]byte, len(c.data))
+		n, err := Compress(c.alg, c.data, zipped)
+		if err != c.err {
+			t.Fatalf("%s: %v != %v", c.name, err, c.err)
+		}
+		if err != nil {
+			continue
+		}
+		if n >= len(c.data) {
+			t.Fatalf("%s: %d not smaller than %d", c.name, n, len(c.data))
+		}
+		out := make([]byte, len(c.data))
+		m, err := Decompress(c.alg, zipped[:n], out)
+		if err != nil || !bytes.Equal(out[:m], c.data) {
+			t.Fatalf("%s: round trip %v", c.name, err)
+		}
+		// 解压结果超出dst时失败,不扩容
+		if _, err = Decompress(c.alg, zipped[:n], make([]byte, len(c.data)-1)); err != ErrDecompress {
+			t.Fatalf("%s: short dst %v", c.name, err)
+		}
+	}
+	if _, err := Compress(CompressNone, text, make([]byte, len(text))); err == nil {
+		t.Fatal("none compressed")
+	}
+}
+
+func TestDecompressInvalid(t *testing.T) {
+	var cases = []struct {
+		name string
+		alg  uint32
+		data []byte
+	}{
+		{name: "lz4 garbage", alg: CompressLz4, data: []byte{0xFF, 0xFF, 0xFF, 0xFF}},
+		{name: "zstd garbage", alg: CompressZstd, data: []byte{0x28, 0xB5, 0x2F, 0xFD, 0xFF}},
+		{name: "unknown", alg: 3, data: []byte{1}},
+	}
+	for _, c := range cases {
+		if _, err := Decompress(c.alg, c.data, make([]byte, 2048)); err != ErrDecompress {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+	}
+}
+
+func TestParseCompress(t *testing.T) {
+	var cases = []struct {
+		name string
+		alg  uint32
+		ok   bool
+	}{
+		{name: "", alg: CompressNone, ok: true},
+		{name: "None", alg: CompressNone, ok: true},
+		{name: "LZ4", alg: CompressLz4, ok: true},
+		{name: "zstd", alg: CompressZstd, ok: true},
+		{name: "gzip", alg: CompressNone},
+	}
+	for _, c := range cases {
+		if alg, err := ParseCompress(c.name); alg != c.alg || (err == nil) != c.ok {
+			t.Fatalf("%s: %d %v", c.name, alg, err)
+		}
+	}
+	if caps := CompressCaps(); caps != 1<<CompressLz4|1<<CompressZstd {
+		t.Fatalf("caps %b", caps)
+	}
+}
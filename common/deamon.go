@@ -7,8 +7,9 @@ import (
 	"os/exec"
 )
 
-// 在main前自动执行
-func init() {
+// 在main开始时调用,-d=true时以后台进程重新启动
+// 不放在init中,避免引用本包的测试程序解析到未定义的参数
+func ParseFlags() {
 	goDaemon := flag.Bool("d", false, "run app as a daemon with -d=true.")
 	flag.Parse()
 
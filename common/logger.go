@@ -63,7 +63,7 @@ func (l *Logger) Debug(msg ...interface{}) {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 	if l.save {
-		l.saveToFile(Debug, msg)
+		l.saveToFile(Debug, msg...)
 	}
 	pc, file, line, _ := runtime.Caller(1) // 0 -3 四级调用层级
 	pcName := runtime.FuncForPC(pc).Name() // 获取方法名称
@@ -72,7 +72,7 @@ func (l *Logger) Debug(msg ...interface{}) {
 	temp = strings.Split(pcName, ".")
 	method := temp[len(temp)-1]
 	t := time.Now().Format("15:04:05.000")
-	m := fmt.Sprint(msg)
+	m := strings.TrimSuffix(fmt.Sprintln(msg...), "\n")
 	fmt.Println("Debug—>", t, "[", file, ",", method, ",", line, ",]", "—>", m)
 	if l.onLog != nil {
 		l.onLog(Debug, t, m)
@@ -88,7 +88,7 @@ func (l *Logger) Info(msg ...interface{}) {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 	if l.save {
-		l.saveToFile(Info, msg)
+		l.saveToFile(Info, msg...)
 	}
 
 	pc, file, line, _ := runtime.Caller(1) // 0 -3 四级调用层级
@@ -98,7 +98,7 @@ func (l *Logger) Info(msg ...interface{}) {
 	temp = strings.Split(pcName, ".")
 	method := temp[len(temp)-1]
 	t := time.Now().Format("15:04:05.000")
-	m := fmt.Sprint(msg)
+	m := strings.TrimSuffix(fmt.Sprintln(msg...), "\n")
 	fmt.Println("Info—>", t, "[", file, ",", method, ",", line, ",]", "—>", m)
 	//fmt.Println("Info——>", time.Now().Format("15:04:05.000"), "—>", message)
 	if l.onLog != nil {
@@ -115,7 +115,7 @@ func (l *Logger) Warn(msg ...interface{}) {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 	if l.save {
-		l.saveToFile(Warn, msg)
+		l.saveToFile(Warn, msg...)
 	}
 	pc, file, line, _ := runtime.Caller(1) // 0 -3 四级调用层级
 	pcName := runtime.FuncForPC(pc).Name() // 获取方法名称
@@ -124,7 +124,7 @@ func (l *Logger) Warn(msg ...interface{}) {
 	temp = strings.Split(pcName, ".")
 	method := temp[len(temp)-1]
 	t := time.Now().Format("15:04:05.000")
-	m := fmt.Sprint(msg)
+	m := strings.TrimSuffix(fmt.Sprintln(msg...), "\n")
 	fmt.Println("Warn—>", t, "[", file, ",", method, ",", line, ",]", "—>", m)
 	if l.onLog != nil {
 		l.onLog(Warn, t, m)
@@ -140,7 +140,7 @@ func (l *Logger) Error(msg ...interface{}) {
 	l.mutex.Lock()
 	defer l.mutex.Unlock()
 	if l.save {
-		l.saveToFile(Error, msg)
+		l.saveToFile(Error, msg...)
 	}
 	pc, file, line, _ := runtime.Caller(1) // 0 -3 四级调用层级
 	pcName := runtime.FuncForPC(pc).Name() // 获取方法名称
@@ -149,7 +149,7 @@ func (l *Logger) Error(msg ...interface{}) {
 	temp = strings.Split(pcName, ".")
 	method := temp[len(temp)-1]
 	t := time.Now().Format("15:04:05.000")
-	m := fmt.Sprint(msg)
+	m := strings.TrimSuffix(fmt.Sprintln(msg...), "\n")
 	fmt.Println("Error—>", t, "[", file, ",", method, ",", line, ",]", "—>", m)
 	//fmt.Println("Error——>", time.Now().Format("15:04:05.000"), "—>", message)
 	if l.onLog != nil {
@@ -198,6 +198,6 @@ func (l *Logger) saveToFile(logType LogType, msg ...interface{}) {
 		case Error:
 			l.logger.SetPrefix(fmt.Sprintf("Error—>%s [%s,%s,%d]—>", time.Now().Format("15:04:05.000"), file, method, line))
 		}
-		l.logger.Println(msg)
+		l.logger.Println(msg...)
 	}
 }
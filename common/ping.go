@@ -119,7 +119,11 @@ func Ping(address string, timeoutMs int) bool {
 }
 
 func Pinger(address string, timeoutMs int) error {
-	c, err := net.Dial("ip4:icmp", address)
+	network, typ := "ip4:icmp", icmpv4EchoRequest
+	if ip := net.ParseIP(address); ip != nil && ip.To4() == nil {
+		network, typ = "ip6:ipv6-icmp", icmpv6EchoRequest // ICMPv6校验和由系统计算
+	}
+	c, err := net.Dial(network, address)
 	if err != nil {
 		return err
 	}
@@ -128,7 +132,6 @@ func Pinger(address string, timeoutMs int) error {
 		_ = c.Close()
 	}()
 
-	typ := icmpv4EchoRequest
 	//xid, xseq := os.Getpid()&0xffff, 1
 	wb, err := (&icmpMessage{
 		Type: typ, Code: 0,
@@ -150,7 +153,9 @@ func Pinger(address string, timeoutMs int) error {
 		if _, err = c.Read(rb); err != nil {
 			return err
 		}
-		rb = ipv4Payload(rb)
+		if typ == icmpv4EchoRequest {
+			rb = ipv4Payload(rb)
+		}
 		if m, err = parseICMPMessage(rb); err != nil {
 			return err
 		}
@@ -10,14 +10,30 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
 	"os"
 )
 
+var (
+	ErrAuthFailed = errors.New("报文认证失败")  // AEAD校验未通过,报文被篡改或密码不一致
+	ErrDecrypt    = errors.New("报文解密失败")  // 长度或补码错误
+	ErrNoSession  = errors.New("会话密钥未协商") // 启用会话密钥时,协商完成前不发送单播报文
+	ErrNoCrypt    = errors.New("未启用加密")   // 加密方式为不加密,报文按明文传输
+)
+
 // 加密解密接口
 type Crypt interface { // 密码内置
 	Encode(src []byte, dst []byte) (int, error)
 	Decode(src []byte, dst []byte) (int, error)
 }
+
+// 认证加密接口,附加数据不加密但参与认证,用于绑定报文头中的序号等字段
+type AuthCrypt interface {
+	Crypt
+	EncodeAd(src []byte, dst []byte, ad []byte) (int, error)
+	DecodeAd(src []byte, dst []byte, ad []byte) (int, error)
+}
 type AesCrypt struct {
 	keyByte   []byte
 	block     cipher.Block
@@ -65,13 +81,16 @@ func (a *AesCrypt) Encode(src []byte, dst []byte) (int, error) {
 }
 
 func (a *AesCrypt) Decode(src []byte, dst []byte) (int, error) {
-	defer func() {
-		recover()
-	}()
 	srcSize := len(src)
+	if srcSize == 0 || srcSize%a.blockSize != 0 || len(dst) < srcSize {
+		return 0, ErrDecrypt
+	}
 	blockMode := cipher.NewCBCDecrypter(a.block, a.keyByte)
 	blockMode.CryptBlocks(dst, src)
 	unPadding := int(dst[srcSize-1])
+	if unPadding == 0 || unPadding > a.blockSize {
+		return 0, ErrDecrypt
+	}
 	return srcSize - unPadding, nil
 }
 
@@ -119,8 +138,8 @@ func (d *DesCrypt) Encode(src []byte, dst []byte) (int, error) {
 
 func (d *DesCrypt) Decode(src []byte, dst []byte) (int, error) {
 	dataLen := len(src)
-	if dataLen%d.blockSize != 0 {
-		return 0, errors.New("crypto/cipher: input not full blocks")
+	if dataLen == 0 || dataLen%d.blockSize != 0 || len(dst) < dataLen {
+		return 0, ErrDecrypt
 	}
 	offset := 0
 	for len(src) > 0 {
@@ -129,9 +148,78 @@ func (d *DesCrypt) Decode(src []byte, dst []byte) (int, error) {
 		offset += d.blockSize
 	}
 	unPadding := int(dst[offset-1]) // 明文减码
+	if unPadding == 0 || unPadding > d.blockSize {
+		return 0, ErrDecrypt
+	}
 	return offset - unPadding, nil
 }
 
+// AeadCrypt 认证加密,每个报文使用随机nonce,输出为 nonce+密文+认证标签
+type AeadCrypt struct {
+	aead cipher.AEAD
+}
+
+// 由传输密码派生32字节密钥,salt使用组名,使相同密码在不同组中得到不同密钥;
+// scrypt参数(N=32768,r=8,p=1)约占32MB内存,仅在启动时计算一次,增加离线猜测密码的代价
+func DeriveKey(password string, salt string) ([]byte, error) {
+	return scrypt.Key([]byte(password), []byte("vilan:"+salt), 1<<15, 8, 1, 32)
+}
+
+// 使用32字节密钥创建AES-GCM加密,密钥由 DeriveKey 派生或为协商得到的会话密钥
+func NewAesGcmCryptKey(key []byte) *AeadCrypt {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil
+	}
+	return &AeadCrypt{aead: aead}
+}
+
+// 使用32字节密钥创建ChaCha20-Poly1305加密,适用于没有AES硬件加速的设备
+func NewChaChaCryptKey(key []byte) *AeadCrypt {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil
+	}
+	return &AeadCrypt{aead: aead}
+}
+
+func (a *AeadCrypt) Encode(src []byte, dst []byte) (int, error) {
+	return a.EncodeAd(src, dst, nil)
+}
+
+func (a *AeadCrypt) Decode(src []byte, dst []byte) (int, error) {
+	return a.DecodeAd(src, dst, nil)
+}
+
+func (a *AeadCrypt) EncodeAd(src []byte, dst []byte, ad []byte) (int, error) {
+	nonceSize := a.aead.NonceSize()
+	size := nonceSize + len(src) + a.aead.Overhead()
+	if len(dst) < size {
+		return 0, errors.New("dst buf too small")
+	}
+	if _, err := rand.Read(dst[:nonceSize]); err != nil {
+		return 0, err
+	}
+	a.aead.Seal(dst[nonceSize:nonceSize], dst[:nonceSize], src, ad)
+	return size, nil
+}
+
+func (a *AeadCrypt) DecodeAd(src []byte, dst []byte, ad []byte) (int, error) {
+	nonceSize := a.aead.NonceSize()
+	if len(src) < nonceSize+a.aead.Overhead() || len(dst) < len(src)-nonceSize-a.aead.Overhead() {
+		return 0, ErrAuthFailed
+	}
+	out, err := a.aead.Open(dst[:0], src[:nonceSize], src[nonceSize:], ad)
+	if err != nil {
+		return 0, ErrAuthFailed
+	}
+	return len(out), nil
+}
+
 type RsaCrypt struct {
 	privateKeyBytes  []byte
 	publicKeyBytes   []byte
@@ -0,0 +1,75 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAeadCrypt(t *testing.T) {
+	key := make([]byte, 32)
+	var cases = []struct {
+		name  string
+		crypt *AeadCrypt
+	}{
+		{name: "aes-gcm", crypt: NewAesGcmCryptKey(key)},
+		{name: "chacha20-poly1305", crypt: NewChaChaCryptKey(key)},
+	}
+	plain := []byte("vilan aead round trip")
+	ad := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			sealed := make([]byte, 128)
+			n, err := c.crypt.EncodeAd(plain, sealed, ad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sealed = sealed[:n]
+			out := make([]byte, 128)
+			m, err := c.crypt.DecodeAd(sealed, out, ad)
+			if err != nil || !bytes.Equal(out[:m], plain) {
+				t.Fatalf("round trip: %v %q", err, out[:m])
+			}
+
+			var tampers = []struct {
+				name string
+				data func() []byte
+				ad   []byte
+			}{
+				{name: "nonce", data: func() []byte { return flip(sealed, 0) }, ad: ad},
+				{name: "ciphertext", data: func() []byte { return flip(sealed, len(sealed)/2) }, ad: ad},
+				{name: "tag", data: func() []byte { return flip(sealed, len(sealed)-1) }, ad: ad},
+				{name: "ad", data: func() []byte { return sealed }, ad: []byte{1, 2, 3, 4, 5, 6, 7, 9}},
+				{name: "no ad", data: func() []byte { return sealed }, ad: nil},
+				{name: "truncated", data: func() []byte { return sealed[:len(sealed)-1] }, ad: ad},
+				{name: "short", data: func() []byte { return sealed[:8] }, ad: ad},
+			}
+			for _, tc := range tampers {
+				if _, err := c.crypt.DecodeAd(tc.data(), out, tc.ad); err != ErrAuthFailed {
+					t.Fatalf("%s: %v != %v", tc.name, err, ErrAuthFailed)
+				}
+			}
+			if _, err := c.crypt.EncodeAd(plain, make([]byte, len(plain)), ad); err == nil {
+				t.Fatal("dst too small accepted")
+			}
+		})
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	a, err := DeriveKey("password", "group")
+	if err != nil || len(a) != 32 {
+		t.Fatalf("%v %d", err, len(a))
+	}
+	b, _ := DeriveKey("password", "group")
+	c, _ := DeriveKey("password", "other")
+	if !bytes.Equal(a, b) || bytes.Equal(a, c) {
+		t.Fatal("key must depend on password and group only")
+	}
+}
+
+func flip(data []byte, i int) []byte {
+	out := append([]byte(nil), data...)
+	out[i] ^= 0x01
+	return out
+}
@@ -0,0 +1,31 @@
+//go:build linux
+// +build linux
+
+package common
+
+import (
+	"golang.org/x/sys/unix"
+	"net"
+)
+
+// UDP套接字发送时设置不分片,且不受内核缓存的路径MTU限制,用于探测路径MTU;on为false时恢复系统默认
+func SetDontFragment(conn *net.UDPConn, on bool) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	mode, mode6 := unix.IP_PMTUDISC_PROBE, unix.IPV6_PMTUDISC_PROBE
+	if !on {
+		mode, mode6 = unix.IP_PMTUDISC_WANT, unix.IPV6_PMTUDISC_WANT
+	}
+	var opErr error
+	err = raw.Control(func(fd uintptr) {
+		opErr = unix.SetsockoptInt(int(fd), unix.IPPROTO_IP, unix.IP_MTU_DISCOVER, mode)
+		// IPv6套接字同时设置
+		_ = unix.SetsockoptInt(int(fd), unix.IPPROTO_IPV6, unix.IPV6_MTU_DISCOVER, mode6)
+	})
+	if err != nil {
+		return err
+	}
+	return opErr
+}
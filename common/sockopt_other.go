@@ -0,0 +1,13 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package common
+
+import (
+	"errors"
+	"net"
+)
+
+func SetDontFragment(_ *net.UDPConn, _ bool) error {
+	return errors.New("当前系统不支持设置不分片")
+}
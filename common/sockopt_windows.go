@@ -0,0 +1,35 @@
+//go:build windows
+// +build windows
+
+package common
+
+import (
+	"golang.org/x/sys/windows"
+	"net"
+)
+
+const (
+	ipDontFragment = 14 // IP_DONTFRAGMENT
+	ipv6DontFrag   = 14 // IPV6_DONTFRAG
+)
+
+// UDP套接字发送时设置不分片,用于探测路径MTU;on为false时恢复允许分片
+func SetDontFragment(conn *net.UDPConn, on bool) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	value := 0
+	if on {
+		value = 1
+	}
+	var opErr error
+	err = raw.Control(func(fd uintptr) {
+		opErr = windows.SetsockoptInt(windows.Handle(fd), windows.IPPROTO_IP, ipDontFragment, value)
+		_ = windows.SetsockoptInt(windows.Handle(fd), windows.IPPROTO_IPV6, ipv6DontFrag, value)
+	})
+	if err != nil {
+		return err
+	}
+	return opErr
+}
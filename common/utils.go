@@ -4,72 +4,20 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
-	"golang.org/x/sys/windows"
 	"net"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
 	"sync"
-	"syscall"
 	"time"
 	"unsafe"
 )
 
 var osType = runtime.GOOS
 
-// 根据网卡接口 Index 判断网卡类型 0 unknown 1 RJ45 2 WIFI 3 GPRS
-func GetIfType(ifIndex int) (uint32, error) {
-	aas, err := adapterAddresses()
-	if err != nil {
-		return 0, err
-	}
-	for _, aa := range aas {
-		index := aa.IfIndex
-		if ifIndex == int(index) {
-			switch aa.IfType {
-			case windows.IF_TYPE_ETHERNET_CSMACD:
-				return 1, nil
-			case windows.IF_TYPE_IEEE80211:
-				return 2, nil
-			case windows.IF_TYPE_PPP:
-				return 3, nil
-			case windows.IF_TYPE_ATM:
-				return 3, nil
-			default:
-				return 0, nil
-			}
-		}
-	}
-	return 0, nil
-}
-func adapterAddresses() ([]*windows.IpAdapterAddresses, error) {
-	var b []byte
-	l := uint32(15000) // recommended initial size
-	for {
-		b = make([]byte, l)
-		err := windows.GetAdaptersAddresses(syscall.AF_UNSPEC, windows.GAA_FLAG_INCLUDE_PREFIX, 0, (*windows.IpAdapterAddresses)(unsafe.Pointer(&b[0])), &l)
-		if err == nil {
-			if l == 0 {
-				return nil, nil
-			}
-			break
-		}
-		if err.(syscall.Errno) != syscall.ERROR_BUFFER_OVERFLOW {
-			return nil, os.NewSyscallError("getadaptersaddresses", err)
-		}
-		if l <= uint32(len(b)) {
-			return nil, os.NewSyscallError("getadaptersaddresses", err)
-		}
-	}
-	var aas []*windows.IpAdapterAddresses
-	for aa := (*windows.IpAdapterAddresses)(unsafe.Pointer(&b[0])); aa != nil; aa = aa.Next {
-		aas = append(aas, aa)
-	}
-	return aas, nil
-}
-
 func GetLocalIP(ipAddr string) (ip *net.IPNet) {
 	faces, err := net.Interfaces()
 	if err != nil {
@@ -141,6 +89,46 @@ func ExtractAddrFromStr(addr string) (sockIp, port uint32, err error) {
 	}
 	return
 }
+
+// 拆分地址字符串中的IP及端口,支持IPv4及[IPv6]:port格式
+func SplitAddr(addr string) (net.IP, uint32, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, 0, err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil, 0, errors.New("cannot extract ip")
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, 0, errors.New("cannot extract port")
+	}
+	return ip, uint32(port), nil
+}
+
+var hostnamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9\-]{0,61}[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9\-]{0,61}[a-zA-Z0-9])?)*$`)
+
+// IP地址或域名
+func IsHost(host string) bool {
+	return net.ParseIP(host) != nil || (len(host) <= 253 && hostnamePattern.MatchString(host))
+}
+
+// 本机的公网IPv6地址(不含链路本地及ULA地址),用作P2P直连候选地址
+func GetGlobalIpv6() []net.IP {
+	ips := make([]net.IP, 0)
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ips
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() == nil && ipNet.IP.IsGlobalUnicast() && !ipNet.IP.IsPrivate() {
+			ips = append(ips, ipNet.IP)
+		}
+	}
+	return ips
+}
+
 func IsLittleEndian() bool {
 	var i int32 = 0x01020304
 	u := unsafe.Pointer(&i)
@@ -166,6 +154,25 @@ func IpV4toUint32(ip string) (uint32, error) {
 	return IP, nil
 }
 
+// 解析CIDR格式网段(如192.168.1.0/24),返回网络地址及掩码长度
+func ParseCidr(cidr string) (netAddr uint32, bitLen uint32, err error) {
+	temp := strings.Split(strings.TrimSpace(cidr), "/")
+	if len(temp) != 2 {
+		return 0, 0, errors.New("invalid cidr string")
+	}
+	ip4 := net.ParseIP(temp[0]).To4() // IpV4toUint32 不检查各段范围
+	if ip4 == nil || strings.Contains(temp[0], ":") {
+		return 0, 0, errors.New("invalid cidr ip")
+	}
+	ip := binary.BigEndian.Uint32(ip4)
+	n, err := strconv.ParseUint(temp[1], 10, 8)
+	if err != nil || n == 0 || n > 32 {
+		return 0, 0, errors.New("invalid cidr mask")
+	}
+	bitLen = uint32(n)
+	return ip & (uint32(0xFFFFFFFF) << (32 - bitLen)), bitLen, nil
+}
+
 func Uint32toIpV4(ip uint32) string {
 	return fmt.Sprintf("%d.%d.%d.%d", ip>>24, (ip>>16)&0xFF, (ip>>8)&0xFF, ip&0xFF)
 }
@@ -200,16 +207,16 @@ func MacStrToUint64(macStr string) (uint64, error) {
 var MacBroadcast uint64 = 0xFFFFFFFFFFFF //[]byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
 var MacMulticast uint64 = 0x01005E       //[]byte{0x01, 0x00, 0x5E}
 
-// 单播
+// 单播,首字节最低位(组地址位)为0;广播及IPv4(01:00:5E)、IPv6(33:33)组播该位为1
 func IsUniCast(mac uint64) bool {
-	return mac != MacBroadcast && mac != MacMulticast
+	return mac&0x01 == 0
 }
 
 func IsBroadcastMac(mac uint64) bool {
 	return mac == MacBroadcast
 }
 func IsMulticastMac(mac uint64) bool {
-	return mac == MacMulticast
+	return !IsUniCast(mac) && mac != MacBroadcast
 }
 
 func GetProjectPath() string {
@@ -243,17 +250,7 @@ func GetLogPath() string {
 }
 
 func GetSystemVersion() uint32 {
-	if osType == "windows" {
-		version, e := syscall.GetVersion()
-		if e != nil {
-			version = 7
-		} else {
-			version = uint32(byte(version) + uint8(version>>8))
-		}
-		return version
-	} else {
-		return 10
-	}
+	return systemVersion()
 }
 func ClearMap(m *sync.Map) {
 	if m == nil {
@@ -0,0 +1,38 @@
+//go:build !windows
+// +build !windows
+
+package common
+
+import (
+	"net"
+	"os"
+	"strings"
+)
+
+// 根据网卡接口 Index 判断网卡类型 0 unknown 1 RJ45 2 WIFI 3 GPRS
+func GetIfType(ifIndex int) (uint32, error) {
+	iFace, err := net.InterfaceByIndex(ifIndex)
+	if err != nil {
+		return 0, err
+	}
+	sysPath := "/sys/class/net/" + iFace.Name
+	if _, err := os.Stat(sysPath + "/wireless"); err == nil {
+		return 2, nil
+	}
+	content, err := os.ReadFile(sysPath + "/type")
+	if err != nil {
+		return 0, nil
+	}
+	switch strings.TrimSpace(string(content)) {
+	case "1": // ARPHRD_ETHER
+		return 1, nil
+	case "512": // ARPHRD_PPP
+		return 3, nil
+	default:
+		return 0, nil
+	}
+}
+
+func systemVersion() uint32 {
+	return 10
+}
@@ -0,0 +1,36 @@
+package common
+
+import "testing"
+
+func TestParseCidr(t *testing.T) {
+	var cases = []struct {
+		cidr   string
+		addr   uint32
+		bitLen uint32
+		ok     bool
+	}{
+		{cidr: "192.168.10.0/24", addr: 0xC0A80A00, bitLen: 24, ok: true},
+		{cidr: " 192.168.10.77/24 ", addr: 0xC0A80A00, bitLen: 24, ok: true}, // 主机位清零
+		{cidr: "10.1.2.3/32", addr: 0x0A010203, bitLen: 32, ok: true},
+		{cidr: "10.0.0.0/1", addr: 0, bitLen: 1, ok: true},
+		{cidr: "10.0.0.0/0"},
+		{cidr: "10.0.0.0/33"},
+		{cidr: "10.0.0.0/-1"},
+		{cidr: "10.0.0.0"},
+		{cidr: "10.0.0/8"},
+		{cidr: "300.0.0.0/8"},
+		{cidr: "::ffff:10.0.0.0/8"},
+		{cidr: "fd00::/64"},
+		{cidr: "/24"},
+		{cidr: ""},
+	}
+	for _, c := range cases {
+		addr, bitLen, err := ParseCidr(c.cidr)
+		if (err == nil) != c.ok {
+			t.Fatalf("%q: %v", c.cidr, err)
+		}
+		if c.ok && (addr != c.addr || bitLen != c.bitLen) {
+			t.Fatalf("%q: %08X/%d != %08X/%d", c.cidr, addr, bitLen, c.addr, c.bitLen)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+//go:build windows
+// +build windows
+
+package common
+
+import (
+	"golang.org/x/sys/windows"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// 根据网卡接口 Index 判断网卡类型 0 unknown 1 RJ45 2 WIFI 3 GPRS
+func GetIfType(ifIndex int) (uint32, error) {
+	aas, err := adapterAddresses()
+	if err != nil {
+		return 0, err
+	}
+	for _, aa := range aas {
+		index := aa.IfIndex
+		if ifIndex == int(index) {
+			switch aa.IfType {
+			case windows.IF_TYPE_ETHERNET_CSMACD:
+				return 1, nil
+			case windows.IF_TYPE_IEEE80211:
+				return 2, nil
+			case windows.IF_TYPE_PPP:
+				return 3, nil
+			case windows.IF_TYPE_ATM:
+				return 3, nil
+			default:
+				return 0, nil
+			}
+		}
+	}
+	return 0, nil
+}
+func adapterAddresses() ([]*windows.IpAdapterAddresses, error) {
+	var b []byte
+	l := uint32(15000) // recommended initial size
+	for {
+		b = make([]byte, l)
+		err := windows.GetAdaptersAddresses(syscall.AF_UNSPEC, windows.GAA_FLAG_INCLUDE_PREFIX, 0, (*windows.IpAdapterAddresses)(unsafe.Pointer(&b[0])), &l)
+		if err == nil {
+			if l == 0 {
+				return nil, nil
+			}
+			break
+		}
+		if err.(syscall.Errno) != syscall.ERROR_BUFFER_OVERFLOW {
+			return nil, os.NewSyscallError("getadaptersaddresses", err)
+		}
+		if l <= uint32(len(b)) {
+			return nil, os.NewSyscallError("getadaptersaddresses", err)
+		}
+	}
+	var aas []*windows.IpAdapterAddresses
+	for aa := (*windows.IpAdapterAddresses)(unsafe.Pointer(&b[0])); aa != nil; aa = aa.Next {
+		aas = append(aas, aa)
+	}
+	return aas, nil
+}
+
+func systemVersion() uint32 {
+	version, e := syscall.GetVersion()
+	if e != nil {
+		version = 7
+	} else {
+		version = uint32(byte(version) + uint8(version>>8))
+	}
+	return version
+}
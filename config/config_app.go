@@ -66,6 +66,11 @@ func InitAppConfig() (err error) {
 			pwd := string(groupPwd)
 			AppConfig.PeerConfig.GroupPwd = pwd
 		}
+		// 非认证加密方式无法创建会话密钥,保留该项会导致本地服务无法启动
+		if AppConfig.PeerConfig.SessionKey && !AppConfig.PeerConfig.CryptType.Authenticated() {
+			fmt.Println("配置错误:会话密钥仅支持AES-GCM及ChaCha20-Poly1305加密方式,已忽略session_key")
+			AppConfig.PeerConfig.SessionKey = false
+		}
 		if needSave {
 			_ = SaveAppConfig()
 		}
@@ -80,6 +85,7 @@ func InitAppConfig() (err error) {
 		AppConfig.P2pTryCount = 3
 		AppConfig.P2pRetryInterval = 3
 		AppConfig.AllowVisitPort = false
+		AppConfig.EnableControl = true
 		AppConfig.EnableLog = true
 		AppConfig.SaveLog = true
 		AppConfig.LogLevel = common.Info
@@ -137,6 +143,12 @@ func CopyAppConfigTo(conf *model.AppConfig) error {
 	}
 	conf.ServerIp = AppConfig.ServerIp
 	conf.ServerPort = AppConfig.ServerPort
+	conf.Servers = append([]string(nil), AppConfig.Servers...)
+	conf.ServerPickRtt = AppConfig.ServerPickRtt
+	conf.ServerTransport = AppConfig.ServerTransport
+	conf.ServerTcpPort = AppConfig.ServerTcpPort
+	conf.ServerTlsPort = AppConfig.ServerTlsPort
+	conf.TlsSkipVerify = AppConfig.TlsSkipVerify
 	conf.Heartbeat = AppConfig.Heartbeat
 	conf.Offline = AppConfig.Offline
 	conf.P2pTryCount = AppConfig.P2pTryCount
@@ -145,12 +157,36 @@ func CopyAppConfigTo(conf *model.AppConfig) error {
 	conf.PacketNum = AppConfig.PacketNum
 	conf.SaveLog = AppConfig.SaveLog
 	conf.AllowVisitPort = AppConfig.AllowVisitPort
+	conf.Relay = AppConfig.Relay
+	conf.EnableControl = AppConfig.EnableControl
+	conf.ControlAddr = AppConfig.ControlAddr
+	conf.NatProbeAddr = AppConfig.NatProbeAddr
 	conf.EnableLog = AppConfig.EnableLog
 	conf.LogLevel = AppConfig.LogLevel
 	tap := AppConfig.TapConfig
-	conf.TapConfig = &model.TapConfig{Name: tap.Name, HwMac: tap.HwMac, HwMacStr: tap.HwMacStr, IpMode: tap.IpMode, IpAddr: tap.IpAddr, IpMask: tap.IpMask, DevType: tap.DevType}
+	conf.TapConfig = &model.TapConfig{Name: tap.Name, HwMac: tap.HwMac, HwMacStr: tap.HwMacStr, IpMode: tap.IpMode, IpAddr: tap.IpAddr, IpMask: tap.IpMask, DevType: tap.DevType, Ip6Addr: tap.Ip6Addr,
+		Mtu: tap.Mtu}
+	conf.Routes = make([]*model.RouteConfig, 0, len(AppConfig.Routes))
+	for _, r := range AppConfig.Routes {
+		if r != nil {
+			conf.Routes = append(conf.Routes, &model.RouteConfig{PeerMac: r.PeerMac, PeerName: r.PeerName})
+		}
+	}
+	conf.AdvertiseSubnets = append([]string(nil), AppConfig.AdvertiseSubnets...)
+	conf.StaticRoutes = append([]string(nil), AppConfig.StaticRoutes...)
+	conf.ProbeTargets = append([]string(nil), AppConfig.ProbeTargets...)
+	conf.Compress = AppConfig.Compress
+	if b := AppConfig.Broadcast; b != nil {
+		conf.Broadcast = &model.BroadcastConfig{Default: b.Default, P2PFanout: b.P2PFanout, Rules: make([]*model.BroadcastRule, 0, len(b.Rules))}
+		for _, rule := range b.Rules {
+			if rule != nil {
+				r := *rule
+				conf.Broadcast.Rules = append(conf.Broadcast.Rules, &r)
+			}
+		}
+	}
 	pc := AppConfig.PeerConfig
-	conf.PeerConfig = &model.PeerConfig{Name: pc.Name, GroupName: pc.GroupName, GroupPwd: pc.GroupPwd, PeerPwd: pc.PeerPwd, CryptType: pc.CryptType}
+	conf.PeerConfig = &model.PeerConfig{Name: pc.Name, GroupName: pc.GroupName, GroupPwd: pc.GroupPwd, PeerPwd: pc.PeerPwd, CryptType: pc.CryptType, SessionKey: pc.SessionKey}
 	return nil
 }
 
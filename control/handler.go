@@ -0,0 +1,299 @@
+package control
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"vilan/app"
+	"vilan/model"
+	"vilan/protocol"
+	"vilan/sys/serial"
+)
+
+// 终端状态
+type StateInfo struct {
+	State     model.PeerState      `json:"state"`
+	StateName string               `json:"state_name"`
+	Version   string               `json:"version"`
+	TapName   string               `json:"tap_name"`
+	NatType   string               `json:"nat_type"`
+	Transport string               `json:"transport"` // 连接服务端的传输方式
+	Server    string               `json:"server"`    // 当前服务端地址
+	Mtu       int                  `json:"mtu"`       // 虚拟网卡MTU
+	PathMtu   int                  `json:"path_mtu"`  // 探测到的服务端路径MTU,未探测时为0
+	Stats     *protocol.Statistics `json:"stats"`
+}
+
+// 串口相关请求参数
+type SerialRequest struct {
+	PeerMac string         `json:"peer_mac"`
+	Remote  string         `json:"remote"`
+	Local   string         `json:"local"`
+	User    string         `json:"user"`
+	Name    string         `json:"name"`
+	Config  *serial.Config `json:"config"`
+}
+
+type PeerRequest struct {
+	PeerMac string `json:"peer_mac"`
+}
+
+func (c *ControlService) newHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/state", c.handleState)
+	mux.HandleFunc("/api/peers", c.handlePeers)
+	mux.HandleFunc("/api/links", c.handleLinks)
+	mux.HandleFunc("/api/traffic", c.handleTraffic)
+	mux.HandleFunc("/api/routes", c.handleRoutes)
+	mux.HandleFunc("/api/config", c.handleConfig)
+	mux.HandleFunc("/api/peer_config", c.handlePeerConfig)
+	mux.HandleFunc("/api/logs", c.handleLogs)
+	mux.HandleFunc("/api/serial/connect", c.handleSerialConnect)
+	mux.HandleFunc("/api/serial/disconnect", c.handleSerialDisconnect)
+	mux.HandleFunc("/api/serial/open", c.handleSerialOpen)
+	mux.HandleFunc("/api/serial/close", c.handleSerialClose)
+	mux.HandleFunc("/api/serial/config", c.handleSerialConfig)
+	mux.HandleFunc("/api/serial/ports", c.handleSerialPorts)
+	mux.HandleFunc("/api/serial/remote", c.handleSerialRemote)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if e := recover(); e != nil {
+				app.Logger.Error("本地控制接口请求处理出错:", r.URL.Path, e)
+				writeError(w, http.StatusInternalServerError, fmt.Sprint("请求处理出错:", e))
+			}
+		}()
+		if len(c.token) > 0 && subtle.ConstantTimeCompare([]byte(r.Header.Get(TokenHeader)), []byte(c.token)) != 1 {
+			writeError(w, http.StatusUnauthorized, "访问令牌无效")
+			return
+		}
+		if app.RuntimeService == nil {
+			writeError(w, http.StatusServiceUnavailable, "服务未初始化")
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func (c *ControlService) handleState(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	state := app.RuntimeService.PeerState()
+	info := &StateInfo{State: state, StateName: state.String(), Version: app.Version, Stats: app.RuntimeService.GetStats(),
+		NatType: app.RuntimeService.NatType().String(), Transport: app.RuntimeService.ServerTransport(),
+		Server: app.RuntimeService.ServerAddr()}
+	info.PathMtu = app.RuntimeService.ServerPmtu()
+	if app.TunTapService != nil {
+		info.TapName = app.TunTapService.TapName()
+		info.Mtu = app.TunTapService.Mtu()
+	}
+	writeJson(w, info)
+}
+
+func (c *ControlService) handlePeers(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	// refresh 时等同界面的 RequestGroupPeers, 应答到达前列表为空
+	peers := GroupPeers(r.URL.Query().Get("refresh") == "true")
+	if peers == nil {
+		peers = make([]*model.PeerInfo, 0)
+	}
+	writeJson(w, peers)
+}
+
+func (c *ControlService) handleLinks(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	writeJson(w, GetLinkInfos(r.URL.Query().Get("peer_mac")))
+}
+
+func (c *ControlService) handleTraffic(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	writeJson(w, TrafficList())
+}
+
+func (c *ControlService) handleRoutes(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet, http.MethodPost, http.MethodDelete) {
+		return
+	}
+	if r.Method == http.MethodDelete {
+		writeJson(w, map[string]interface{}{"result": UnselectPeer(r.URL.Query().Get("peer_mac"))})
+		return
+	}
+	if r.Method == http.MethodGet {
+		routes, err := GetRoutes()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJson(w, routes)
+		return
+	}
+	req := &PeerRequest{}
+	if !readJson(w, r, req) {
+		return
+	}
+	writeJson(w, map[string]interface{}{"result": SelectPeer(req.PeerMac)})
+}
+
+func (c *ControlService) handleConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJson(w, GetConfig())
+	case http.MethodPost:
+		conf := &model.Config{}
+		if !readJson(w, r, conf) {
+			return
+		}
+		tip := SaveConfig(conf)
+		writeJson(w, map[string]interface{}{"result": tip == TipConfigSaved, "tip": tip})
+	default:
+		allowMethod(w, r, http.MethodGet, http.MethodPost)
+	}
+}
+
+func (c *ControlService) handlePeerConfig(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	peer := &model.PeerInfo{}
+	if !readJson(w, r, peer) {
+		return
+	}
+	writeJson(w, UpdateConfig(peer))
+}
+
+func (c *ControlService) handleLogs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		since, _ := strconv.ParseUint(r.URL.Query().Get("since"), 10, 64)
+		writeJson(w, Logs.List(since))
+	case http.MethodDelete:
+		Logs.Clear()
+		writeJson(w, map[string]interface{}{"result": true})
+	default:
+		allowMethod(w, r, http.MethodGet, http.MethodDelete)
+	}
+}
+
+func (c *ControlService) handleSerialConnect(w http.ResponseWriter, r *http.Request) {
+	if id, ok := readSerialRequest(w, r, nil); ok {
+		writeJson(w, app.SerialService.ConnectRemote(id))
+	}
+}
+
+func (c *ControlService) handleSerialDisconnect(w http.ResponseWriter, r *http.Request) {
+	if id, ok := readSerialRequest(w, r, nil); ok {
+		writeJson(w, app.SerialService.DisConnectRemote(id))
+	}
+}
+
+func (c *ControlService) handleSerialOpen(w http.ResponseWriter, r *http.Request) {
+	req := &SerialRequest{}
+	if id, ok := readSerialRequest(w, r, req); ok {
+		writeJson(w, app.SerialService.OpenPort(id, req.Remote, req.Local, req.User, req.Config))
+	}
+}
+
+func (c *ControlService) handleSerialClose(w http.ResponseWriter, r *http.Request) {
+	req := &SerialRequest{}
+	if id, ok := readSerialRequest(w, r, req); ok {
+		writeJson(w, app.SerialService.ClosePort(id, req.Name))
+	}
+}
+
+func (c *ControlService) handleSerialConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		if id, ok := parsePeerMac(w, r.URL.Query().Get("peer_mac")); ok {
+			writeJson(w, app.SerialService.GetPortConfig(id, r.URL.Query().Get("name")))
+		}
+	case http.MethodPost:
+		req := &SerialRequest{}
+		if id, ok := readSerialRequest(w, r, req); ok {
+			if req.Config == nil {
+				writeError(w, http.StatusBadRequest, "串口参数为空")
+				return
+			}
+			writeJson(w, app.SerialService.SetPortConfig(id, req.Remote, req.Config))
+		}
+	default:
+		allowMethod(w, r, http.MethodGet, http.MethodPost)
+	}
+}
+
+func (c *ControlService) handleSerialPorts(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	if id, ok := parsePeerMac(w, r.URL.Query().Get("peer_mac")); ok {
+		writeJson(w, app.SerialService.GetConnectedPorts(id))
+	}
+}
+
+func (c *ControlService) handleSerialRemote(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	if id, ok := parsePeerMac(w, r.URL.Query().Get("peer_mac")); ok {
+		writeJson(w, app.SerialService.GetRemotePortList(id))
+	}
+}
+
+// POST 请求,读取参数并解析终端MAC
+func readSerialRequest(w http.ResponseWriter, r *http.Request, req *SerialRequest) (uint64, bool) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return 0, false
+	}
+	if req == nil {
+		req = &SerialRequest{}
+	}
+	if !readJson(w, r, req) {
+		return 0, false
+	}
+	return parsePeerMac(w, req.PeerMac)
+}
+
+func parsePeerMac(w http.ResponseWriter, macStr string) (uint64, bool) {
+	mac, err := strconv.ParseUint(macStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "无效的终端MAC:"+macStr)
+		return 0, false
+	}
+	return mac, true
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, m := range methods {
+		if r.Method == m {
+			return true
+		}
+	}
+	writeError(w, http.StatusMethodNotAllowed, "不支持的请求方法:"+r.Method)
+	return false
+}
+
+func readJson(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "请求参数解析失败:"+err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJson(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(&model.Result{Message: message, Code: code})
+}
@@ -0,0 +1,57 @@
+package control
+
+import (
+	"sync"
+	"vilan/common"
+)
+
+// Logs 最近的日志记录,界面与本地控制接口共用
+var Logs = NewLogHistory(100)
+
+type LogHistory struct {
+	mutex  sync.Mutex
+	size   int
+	lastId uint64
+	events []map[string]interface{} // 新的在前
+}
+
+func NewLogHistory(size int) *LogHistory {
+	return &LogHistory{size: size, events: make([]map[string]interface{}, 0)}
+}
+
+func (h *LogHistory) Add(logType common.LogType, time string, content string) map[string]interface{} {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.lastId++
+	evt := map[string]interface{}{"id": h.lastId, "logType": logType, "time": time, "content": content}
+	if len(h.events) > h.size {
+		h.events = h.events[:h.size*9/10]
+	}
+	h.events = append([]map[string]interface{}{evt}, h.events...)
+	return evt
+}
+
+// OnLog 可直接设置为日志组件的回调
+func (h *LogHistory) OnLog(logType common.LogType, time string, content string) {
+	h.Add(logType, time, content)
+}
+
+// List 返回id大于since的记录,新的在前
+func (h *LogHistory) List(since uint64) []map[string]interface{} {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	events := make([]map[string]interface{}, 0, len(h.events))
+	for _, evt := range h.events {
+		if evt["id"].(uint64) <= since {
+			break
+		}
+		events = append(events, evt)
+	}
+	return events
+}
+
+func (h *LogHistory) Clear() {
+	h.mutex.Lock()
+	h.events = make([]map[string]interface{}, 0)
+	h.mutex.Unlock()
+}
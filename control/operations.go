@@ -0,0 +1,301 @@
+package control
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"vilan/app"
+	"vilan/common"
+	"vilan/config"
+	"vilan/model"
+	"vilan/protocol"
+)
+
+// 以下操作由界面绑定方法及本地控制接口共用
+
+// 组内终端列表, request为true时同时向服务端请求刷新(应答后推送)
+func GroupPeers(request bool) []*model.PeerInfo {
+	if app.RuntimeService == nil {
+		return nil
+	}
+	infos := app.RuntimeService.GetGroupPeers(config.AppConfig.PeerConfig.GroupName, request)
+	if infos != nil {
+		peers := make([]*model.PeerInfo, len(infos))
+		for i := range infos {
+			peers[i] = model.ProtoToModel(infos[i])
+			if app.P2pService.IsRelayed(infos[i].PeerMac) {
+				peers[i].ConnectType = 2
+			} else if app.P2pService.IsP2P(infos[i].PeerMac) {
+				peers[i].ConnectType = 1
+			}
+			if q := app.P2pService.PeerQuality(infos[i].PeerMac); q != nil {
+				peers[i].Rtt, peers[i].Jitter, peers[i].Loss = q.Rtt, q.Jitter, q.Loss
+			}
+			peers[i].Mtu = app.P2pService.PeerMtu(infos[i].PeerMac)
+			if t := app.RuntimeService.PeerTraffic(infos[i].PeerMac); t != nil {
+				tx, rx := t.Rate()
+				peers[i].Traffic = t
+				peers[i].RateTxRx = model.RateFormat(tx) + " / " + model.RateFormat(rx)
+			}
+			peers[i].Routed = app.RuntimeService.HasRoute(infos[i].PeerMac)
+		}
+		return sortPeers(peers)
+	}
+	return nil
+}
+
+// 排序 先在线 按IP 小到大
+func sortPeers(peers []*model.PeerInfo) []*model.PeerInfo {
+	if peers == nil || len(peers) == 0 {
+		return peers
+	}
+	onlinePeers := make([]*model.PeerInfo, 0)
+	offlinePeers := make([]*model.PeerInfo, 0)
+	for i := range peers {
+		if peers[i].Online {
+			onlinePeers = append(onlinePeers, peers[i])
+		} else {
+			offlinePeers = append(offlinePeers, peers[i])
+		}
+	}
+
+	num := len(onlinePeers)
+	if num > 0 {
+		for i := 0; i < num-1; i++ {
+			for j := 0; j < num-i-1; j++ {
+				if compareIP(onlinePeers[j].NetAddr, onlinePeers[j+1].NetAddr) {
+					temp := onlinePeers[j]
+					onlinePeers[j] = onlinePeers[j+1]
+					onlinePeers[j+1] = temp
+				}
+			}
+		}
+	}
+	num = len(offlinePeers)
+	if num > 0 {
+		for i := 0; i < num-1; i++ {
+			for j := 0; j < num-i-1; j++ {
+				if compareIP(offlinePeers[j].NetAddr, offlinePeers[j+1].NetAddr) {
+					temp := offlinePeers[j]
+					offlinePeers[j] = offlinePeers[j+1]
+					offlinePeers[j+1] = temp
+				}
+			}
+		}
+	}
+	return append(onlinePeers, offlinePeers...)
+}
+
+func compareIP(ip0, ip1 string) bool {
+	ipStr0 := strings.ReplaceAll(ip0, "/", "")
+	ipStr0 = strings.ReplaceAll(ipStr0, ".", "")
+	ipVal0, err := strconv.ParseUint(ipStr0, 10, 64)
+	if err != nil {
+		return false
+	}
+	ipStr1 := strings.ReplaceAll(ip1, "/", "")
+	ipStr1 = strings.ReplaceAll(ipStr1, ".", "")
+	ipVal1, err := strconv.ParseUint(ipStr1, 10, 64)
+	if err != nil {
+		return false
+	}
+	return ipVal0 > ipVal1
+}
+
+func GetLinkInfos(macStr string) []*protocol.LinkInfo {
+	mac, err := strconv.ParseUint(macStr, 10, 64)
+	if err != nil {
+		app.Logger.Debug("地址转换错误:", macStr, err)
+		return nil
+	}
+	return app.RuntimeService.GetLinkInfos(mac)
+}
+
+// 各终端流量统计,按经服务端转发的速率从大到小排序
+func TrafficList() []*model.PeerTraffic {
+	list := app.RuntimeService.TrafficList()
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i].Server, list[j].Server
+		return a.TxRate+a.RxRate > b.TxRate+b.RxRate
+	})
+	return list
+}
+
+func SelectPeer(macStr string) bool {
+	mac, err := strconv.ParseUint(macStr, 10, 64)
+	if err != nil {
+		return false
+	}
+	return app.RuntimeService.AddRoute(mac)
+}
+
+func UnselectPeer(macStr string) bool {
+	mac, err := strconv.ParseUint(macStr, 10, 64)
+	if err != nil {
+		return false
+	}
+	return app.RuntimeService.RemoveRoute(mac)
+}
+
+// 本程序添加到虚拟网卡上的路由
+func GetRoutes() ([]*model.TapRoute, error) {
+	if app.TunTapService == nil {
+		return nil, errors.New("虚拟网卡服务未启动")
+	}
+	return app.TunTapService.ListRoutes()
+}
+
+func GetConfig() *model.Config {
+	defer func() {
+		if err := recover(); err != nil {
+			app.Logger.Error("配置请求出错:", err)
+		}
+	}()
+	if app.RuntimeService == nil {
+		return nil
+	}
+	mask := uint32(0xFFFFFFFF) << (32 - config.AppConfig.TapConfig.IpMask)
+	conf := &model.Config{
+		ServerIp:   config.AppConfig.ServerIp,
+		ServerPort: config.AppConfig.ServerPort,
+		PeerName:   config.AppConfig.PeerConfig.Name,
+		CryptType:  config.AppConfig.PeerConfig.CryptType,
+		SessionKey: config.AppConfig.PeerConfig.SessionKey,
+		PeerPwd:    config.AppConfig.PeerConfig.PeerPwd,
+		GroupName:  config.AppConfig.PeerConfig.GroupName,
+		GroupPwd:   config.AppConfig.PeerConfig.GroupPwd,
+		HwMac:      common.Uint64ToMacStr(config.AppConfig.TapConfig.HwMac),
+		DevType:    config.AppConfig.TapConfig.DevType,
+		Ip6Addr:    config.AppConfig.TapConfig.Ip6Addr,
+		IpMode:     uint(config.AppConfig.TapConfig.IpMode),
+		IpAddr:     common.Uint32toIpV4(config.AppConfig.TapConfig.IpAddr),
+		IpMask:     common.Uint32toIpV4(mask),
+		EnableLog:  config.AppConfig.EnableLog,
+		LogLevel:   byte(config.AppConfig.LogLevel),
+	}
+	if conf.DevType != model.TUN { // 旧版本配置没有网卡模式
+		conf.DevType = model.TAP
+	}
+	if app.RuntimeService.PeerState() >= model.StateInitOk {
+		conf.TabName = app.TunTapService.TapName()
+	}
+	return conf
+}
+
+// 配置保存成功时SaveConfig的返回值
+const TipConfigSaved = "配置保存成功"
+
+func SaveConfig(conf *model.Config) string {
+	if conf == nil {
+		return "配置信息为空"
+	}
+	if len(conf.PeerName) == 0 {
+		return "终端名称不能为空"
+	}
+	if len(conf.GroupName) == 0 {
+		return "网络组名称不能为空"
+	}
+	//if len(conf.GroupPwd) == 0 {
+	//	return "网络组密码不能为空"
+	//}
+	if conf.CryptType != model.CryptNone && len(conf.PeerPwd) == 0 {
+		return "传输密码不能为空"
+	}
+	if conf.SessionKey && !conf.CryptType.Authenticated() {
+		return "会话密钥仅支持AES-GCM及ChaCha20-Poly1305加密方式"
+	}
+	if !common.IsHost(conf.ServerIp) {
+		return "服务地址格式错误"
+	}
+	if len(conf.Ip6Addr) > 0 {
+		if ip, _, e := net.ParseCIDR(conf.Ip6Addr); e != nil || ip.To4() != nil {
+			return "IPv6地址格式错误"
+		}
+	}
+	ipMode := model.AutoAssign
+	if conf.IpMode == 0x02 {
+		ipMode = model.Static
+	}
+	peerIp, e := common.IpV4toUint32(conf.IpAddr)
+	if e != nil && ipMode != model.AutoAssign {
+		return "分配地址格式错误"
+	}
+	peerMask, e := common.IpV4toUint32(conf.IpMask)
+	if e != nil && ipMode != model.AutoAssign {
+		return "子网掩码格式错误"
+	}
+
+	maskLen := common.MaskBitLen(peerMask)
+	mac, e := common.MacStrToUint64(conf.HwMac)
+	if e != nil {
+		return "MAC地址格式错误"
+	}
+	c := config.NewConfig()
+	if e := config.CopyAppConfigTo(c); e != nil {
+		return "配置载入出错"
+	}
+	c.ServerIp = conf.ServerIp
+	c.ServerPort = conf.ServerPort
+	c.EnableLog = conf.EnableLog
+	c.LogLevel = common.LogType(conf.LogLevel)
+	c.PeerConfig.Name = conf.PeerName
+	c.PeerConfig.CryptType = conf.CryptType
+	c.PeerConfig.SessionKey = conf.SessionKey
+	c.PeerConfig.PeerPwd = conf.PeerPwd
+	c.PeerConfig.GroupName = conf.GroupName
+	c.PeerConfig.GroupPwd = conf.GroupPwd
+	c.TapConfig.HwMac = mac
+	if conf.DevType == model.TUN {
+		c.TapConfig.DevType = model.TUN
+	} else {
+		c.TapConfig.DevType = model.TAP
+	}
+	c.TapConfig.IpMode = ipMode
+	c.TapConfig.IpAddr = peerIp
+	c.TapConfig.IpMask = maskLen
+	c.TapConfig.Ip6Addr = conf.Ip6Addr
+	if err := config.SaveConfig(c); err != nil {
+		return "配置保存失败"
+	} else {
+		needRestart := false
+		if config.AppConfig.TapConfig.HwMac != c.TapConfig.HwMac ||
+			config.AppConfig.PeerConfig.CryptType != c.PeerConfig.CryptType ||
+			config.AppConfig.PeerConfig.SessionKey != c.PeerConfig.SessionKey ||
+			(config.AppConfig.PeerConfig.CryptType != model.CryptNone && config.AppConfig.PeerConfig.PeerPwd != c.PeerConfig.PeerPwd) ||
+			config.AppConfig.TapConfig.IpMode != c.TapConfig.IpMode ||
+			config.AppConfig.TapConfig.DevType != c.TapConfig.DevType ||
+			config.AppConfig.TapConfig.Ip6Addr != c.TapConfig.Ip6Addr {
+			needRestart = true
+		} else if (config.AppConfig.TapConfig.IpAddr != c.TapConfig.IpAddr ||
+			config.AppConfig.TapConfig.IpMask != c.TapConfig.IpMask) && c.TapConfig.IpMode != model.AutoAssign {
+			needRestart = true
+		}
+		if config.AppConfig.ServerIp != c.ServerIp ||
+			config.AppConfig.ServerPort != c.ServerPort ||
+			config.AppConfig.PeerConfig.GroupName != c.PeerConfig.GroupName ||
+			config.AppConfig.PeerConfig.GroupPwd != c.PeerConfig.GroupPwd {
+			needRestart = true
+		}
+		config.AppConfig = c
+		if c.EnableLog {
+			app.Logger.Enable()
+		} else {
+			app.Logger.Disable()
+		}
+		app.Logger.SetLogLevel(c.LogLevel)
+		if needRestart {
+			if ex := app.RuntimeService.Restart(); ex != nil {
+				app.Logger.Error("本地服务重启失败:", ex)
+			} else {
+				app.Logger.Info("本地服务重启成功!")
+			}
+		}
+		return TipConfigSaved
+	}
+}
+
+func UpdateConfig(peer *model.PeerInfo) map[string]interface{} {
+	return app.RuntimeService.UpdateConfig(peer)
+}
@@ -0,0 +1,138 @@
+package control
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"strings"
+	"vilan/app"
+	"vilan/common"
+	"vilan/config"
+	"vilan/netty"
+	"vilan/netty/codec/xhttp"
+	"vilan/netty/transport/tcp"
+	"vilan/netty/transport/unix"
+)
+
+const (
+	DefaultUnixAddr = "unix:///var/run/vilan-peer.sock"
+	DefaultTcpAddr  = "127.0.0.1:48533"
+	TokenFile       = "control.token" // 回环端口方式的访问令牌,位于配置目录
+	TokenHeader     = "X-Vilan-Token"
+)
+
+// ControlService 本地控制接口,提供与界面绑定方法一致的JSON API
+// unix socket 通过文件权限限制访问; 回环端口通过仅属主可读的令牌文件限制访问
+type ControlService struct {
+	bootstrap netty.Bootstrap
+	addr      string
+	token     string
+}
+
+func NewControlService() *ControlService {
+	return &ControlService{}
+}
+
+// 配置为空时的默认地址
+func DefaultAddr() string {
+	if runtime.GOOS == "linux" {
+		return DefaultUnixAddr
+	}
+	return DefaultTcpAddr
+}
+
+func (c *ControlService) Start() error {
+	if c.bootstrap != nil {
+		c.Stop()
+	}
+	addr := config.AppConfig.ControlAddr
+	if len(addr) == 0 {
+		addr = DefaultAddr()
+	}
+	if strings.HasPrefix(addr, "unix://") {
+		err := c.listen(unix.New(), addr)
+		if err == nil {
+			path := strings.TrimPrefix(addr, "unix://")
+			if err = os.Chmod(path, 0660); err == nil {
+				c.addr = addr
+				return nil
+			}
+			c.Stop()
+		}
+		app.Logger.Warn("本地控制接口unix socket监听失败,改用本地回环端口:", err)
+		addr = DefaultTcpAddr
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return errors.New("本地控制接口只允许监听回环地址")
+	}
+	if c.token, err = writeToken(); err != nil {
+		return fmt.Errorf("访问令牌生成失败:%s", err.Error())
+	}
+	if err = c.listen(tcp.New(), addr); err != nil {
+		c.token = ""
+		return err
+	}
+	c.addr = addr
+	return nil
+}
+
+func (c *ControlService) Stop() {
+	if c.bootstrap == nil {
+		return
+	}
+	c.bootstrap.Stop()
+	c.bootstrap = nil
+	if len(c.token) > 0 {
+		_ = os.Remove(common.GetConfigPath() + TokenFile)
+		c.token = ""
+	}
+	c.addr = ""
+}
+
+func (c *ControlService) Addr() string {
+	return c.addr
+}
+
+func (c *ControlService) listen(factory netty.TransportFactory, addr string) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("%v", e)
+			c.bootstrap = nil
+		}
+	}()
+	handler := c.newHandler()
+	c.bootstrap = netty.NewBootstrap()
+	c.bootstrap.ChildInitializer(func(channel netty.Channel) {
+		channel.Pipeline().
+			AddLast(xhttp.ServerCodec()).
+			AddLast(xhttp.Handler(handler))
+	})
+	c.bootstrap.Transport(factory).Listen(addr)
+	return nil
+}
+
+func writeToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+	if err := os.MkdirAll(common.GetConfigPath(), 0755); err != nil {
+		return "", err
+	}
+	path := common.GetConfigPath() + TokenFile
+	_ = os.Remove(path) // 重新创建以确保权限
+	if err := os.WriteFile(path, []byte(token), 0600); err != nil {
+		return "", err
+	}
+	return token, nil
+}
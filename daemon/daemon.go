@@ -0,0 +1,99 @@
+package daemon
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"vilan/app"
+	"vilan/config"
+	"vilan/control"
+	"vilan/model"
+	"vilan/protocol"
+	"vilan/service"
+)
+
+// HeadlessApp 无界面运行时替代WailsApp,将状态、流量及终端变化输出到日志
+type HeadlessApp struct {
+	mutex     sync.Mutex
+	peers     map[uint64]string // mac -> 上次输出的连接状态
+	lastStats protocol.Statistics
+}
+
+func NewHeadlessApp() *HeadlessApp {
+	return &HeadlessApp{peers: make(map[uint64]string)}
+}
+
+// Run 以守护进程方式启动各服务,收到退出信号后关闭
+func Run() error {
+	app.WailsApp = NewHeadlessApp()
+	service.LoadAppConfig()
+	app.Logger.SetFunc(control.Logs.OnLog)
+	app.Logger.Info("当前程序版本:", app.Version, ",以守护进程方式运行")
+
+	if err := service.StartServices(); err != nil {
+		return err
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-quit
+	app.Logger.Info("收到退出信号:", sig)
+	service.StopServices()
+	return nil
+}
+
+func (h *HeadlessApp) UpdateState(state model.PeerState) {
+	app.Logger.Info("终端状态:", state)
+}
+
+// 每3秒推送一次,只在流量变化时输出
+func (h *HeadlessApp) UpdateStats(stats *protocol.Statistics) {
+	if stats == nil {
+		return
+	}
+	h.mutex.Lock()
+	changed := h.lastStats.TransSend != stats.TransSend || h.lastStats.TransReceive != stats.TransReceive ||
+		h.lastStats.P2PSend != stats.P2PSend || h.lastStats.P2PReceive != stats.P2PReceive
+	h.lastStats.TransSend, h.lastStats.TransReceive = stats.TransSend, stats.TransReceive
+	h.lastStats.P2PSend, h.lastStats.P2PReceive = stats.P2PSend, stats.P2PReceive
+	authFail := stats.AuthFail - h.lastStats.AuthFail
+	replayDrop := stats.ReplayDrop - h.lastStats.ReplayDrop
+	h.lastStats.AuthFail, h.lastStats.ReplayDrop = stats.AuthFail, stats.ReplayDrop
+	h.mutex.Unlock()
+	if authFail > 0 {
+		app.Logger.Warn("解密或认证失败丢弃报文:", authFail, ",请确认各终端加密方式及传输密码一致")
+	}
+	if replayDrop > 0 {
+		app.Logger.Warn("重放报文丢弃:", replayDrop)
+	}
+	if !changed {
+		return
+	}
+	app.Logger.Debug("流量统计 发送/接收:", model.SizeFormat(stats.TransSend+stats.P2PSend), "/", model.SizeFormat(stats.TransReceive+stats.P2PReceive),
+		",P2P:", model.SizeFormat(stats.P2PSend), "/", model.SizeFormat(stats.P2PReceive))
+}
+
+func (h *HeadlessApp) UpdatePeers() {
+	if app.RuntimeService == nil {
+		return
+	}
+	infos := app.RuntimeService.GetGroupPeers(config.AppConfig.PeerConfig.GroupName, false)
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for _, info := range infos {
+		state := "离线"
+		if info.Online {
+			state = "在线,转发"
+			if app.P2pService != nil && app.P2pService.IsP2P(info.PeerMac) {
+				state = "在线,P2P"
+			}
+		}
+		if last, ok := h.peers[info.PeerMac]; ok && last == state {
+			continue
+		}
+		h.peers[info.PeerMac] = state
+		peer := model.ProtoToModel(info)
+		app.Logger.Info("终端", peer.PeerName, peer.NetAddr, state)
+	}
+}
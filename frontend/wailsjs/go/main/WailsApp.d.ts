@@ -24,6 +24,8 @@ export function SaveConfig(arg1:any):Promise<string>;
 
 export function SelectPeer(arg1:string):Promise<boolean>;
 
+export function UnselectPeer(arg1:string):Promise<boolean>;
+
 export function UpdateConfig(arg1:any):Promise<{[key: string]: any}>;
 
 export function UpdatePeers():Promise<void>;
@@ -46,6 +46,10 @@ export function SelectPeer(arg1) {
   return window['go']['main']['WailsApp']['SelectPeer'](arg1);
 }
 
+export function UnselectPeer(arg1) {
+  return window['go']['main']['WailsApp']['UnselectPeer'](arg1);
+}
+
 export function UpdateConfig(arg1) {
   return window['go']['main']['WailsApp']['UpdateConfig'](arg1);
 }
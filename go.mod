@@ -5,8 +5,10 @@ go 1.18
 require (
 	github.com/cwchiu/go-winapi v0.0.0-20130629162214-19f502a3f526
 	github.com/golang/protobuf v1.5.2
+	github.com/klauspost/compress v1.17.2
 	github.com/mdlayher/genetlink v1.3.2
 	github.com/mdlayher/netlink v1.7.2
+	github.com/pierrec/lz4/v4 v4.1.21
 	github.com/rodolfoag/gow32 v0.0.0-20160917004320-d95ff468acf8
 	github.com/wailsapp/wails/v2 v2.4.0
 	golang.org/x/crypto v0.1.0
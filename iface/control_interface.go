@@ -0,0 +1,7 @@
+package iface
+
+type ControlInterface interface {
+	Start() error
+	Stop()
+	Addr() string // 实际监听地址
+}
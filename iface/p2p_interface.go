@@ -14,6 +14,13 @@ type P2pInterface interface {
 	ProcessP2PTrigger(msg *protocol.MsgP2PTrigger) error
 	ProcessP2PAck(msgAck *protocol.MsgP2PAck) error
 	IsP2P(dstMac uint64) bool
+	IsRelayed(dstMac uint64) bool
+	RelayForward(msg *protocol.MsgDataFrame) error
+	ProcessRelayPong(srcMac uint64, viaMac uint64, seq uint64)
+	PeerQuality(mac uint64) *model.QualityInfo
+	ProcessMtuPong(mac uint64, size uint32)
+	PeerMtu(mac uint64) int
+	PathMtu(mac uint64) int
 	P2PSuccess(dstMac uint64, sock *model.PeerSockContext) error
 	DeleteFailedP2P(mac uint64)
 	ResetP2PSocks()
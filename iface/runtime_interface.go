@@ -13,8 +13,17 @@ type RuntimeInterface interface {
 	PeerState() model.PeerState
 	SetPeerState(state model.PeerState)
 	PostTunTapData(dstMac uint64, data []byte) error
+	FanOutTunTapData(dstMac uint64, data []byte) error
 	EncryptMsg(msg *protocol.MsgDataFrame, out []byte) (int, error)
 	DecryptMsg(msg *protocol.MsgDataFrame, out []byte) (int, error)
+	CompressMsg(msg *protocol.MsgDataFrame, out []byte) (int, error)
+	DecompressMsg(msg *protocol.MsgDataFrame, out []byte) (int, error)
+	ProcessCompressCaps(mac uint64, caps uint32)
+	CompressCapsSent(mac uint64)
+	StartKeyExchange(mac uint64) error
+	NextSeq() uint64
+	CheckReplay(msg *protocol.MsgDataFrame) bool
+	ProcessKeyExchange(msg *protocol.MsgDataFrame) error
 	SendAuthRequest() error
 	ProcessAuthResponse(ack *protocol.MsgAuthAck) error
 	SendUnAuth() error
@@ -31,9 +40,21 @@ type RuntimeInterface interface {
 	ProcessPeerLinksResponse(response *protocol.MsgPeerLinksResponse) error
 	GetGroupPeers(group string, request bool) []*protocol.PeerInfo
 	FindPeer(mac uint64) *protocol.PeerInfo
+	FindPeerByIp(ip uint32) (uint64, bool)
+	FindMacByIp(ip uint32) (uint64, bool)
+	FindMacByIp6(ip []byte) (uint64, bool)
+	NatType() model.NatType
+	ServerTransport() string
+	ServerAddr() string
+	ServerPmtu() int
 	GetLinkInfos(mac uint64) []*protocol.LinkInfo
 	AddRoute(mac uint64) bool
+	RemoveRoute(mac uint64) bool
+	HasRoute(mac uint64) bool
 	CleanRoutes()
-	SetStats(size uint64, rx bool, p2p bool)
+	SetStats(mac uint64, size uint64, rx bool, path model.StatsPath)
+	SetDropStats(mac uint64, reason model.DropReason)
 	GetStats() *protocol.Statistics
+	PeerTraffic(mac uint64) *model.PeerTraffic
+	TrafficList() []*model.PeerTraffic
 }
@@ -8,7 +8,11 @@ type TunTapInterface interface {
 	Stop() error
 	AddRoute(dst, mask, gw string) error
 	DelRoute(dst, mask, gw string) error
+	ListRoutes() ([]*model.TapRoute, error)
 	WriteData2TunTap(data []byte) (int, error) // 向tun tap 写入数据
 	State() model.TunTapState
 	TapName() string
+	SetMtu(mtu int) error
+	Mtu() int
+	AnnouncePeer(mac uint64, ip uint32) // 终端上线时向本机发送免费ARP
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"embed"
+	"flag"
 	"fmt"
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/logger"
@@ -14,14 +15,23 @@ import (
 	"runtime/debug"
 	"vilan/app"
 	"vilan/common"
+	"vilan/daemon"
 )
 
 //go:embed frontend/dist
 var assets embed.FS
 
 func main() {
+	common.ParseFlags()
 	fmt.Println("当前程序版本:", app.Version)
 	debug.SetMemoryLimit(1024 * 1024 * 50)
+	// vilan-peer daemon 无界面运行
+	if flag.Arg(0) == "daemon" {
+		if err := daemon.Run(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 	version := common.GetSystemVersion()
 
 	// Create an instance of the wailsApp structure
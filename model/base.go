@@ -3,9 +3,25 @@ package model
 const (
 	SizeEthFrame  = 14
 	SizeMaxPacket = 2048
+	SizeMaxFrame  = 9216 // 虚拟网卡最大MTU的以太网帧加隧道封装开销
 	AESKey        = "vilan_hash_key"
+
+	// 隧道封装开销:数据帧编码(含中转封装及长度前缀)最多80字节,加密(随机数及认证标签或块填充)最多32字节
+	SizeTunnelOverhead = 112
+	MtuMin             = 576
+	MtuMax             = 9000 // 超过路径MTU的报文分片传输
+	PathMtuDefault     = 1500 // 未探测时假定的路径MTU
 )
 
+// 路径MTU减去IP及UDP头、隧道封装开销和以太网帧头,得到虚拟网卡可用的MTU
+func TunnelMtu(pathMtu int, ipv6 bool) int {
+	hdr := 20 + 8
+	if ipv6 {
+		hdr = 40 + 8
+	}
+	return pathMtu - hdr - SizeTunnelOverhead - SizeEthFrame
+}
+
 type PeerState int32
 
 const (
@@ -19,13 +35,47 @@ const (
 	StateOk        PeerState = 7
 )
 
+var peerStateNames = []string{"未初始化", "初始化错误", "初始化成功", "网络未连接", "网络已连接", "服务未注册", "服务注册失败", "服务正常运行"}
+
+func (s PeerState) String() string {
+	if s < 0 || int(s) >= len(peerStateNames) {
+		return "未知状态"
+	}
+	return peerStateNames[s]
+}
+
+// 与服务端连接的传输方式
+const (
+	TransportAuto = "auto" // 优先UDP,UDP不通时依次改用TCP、TLS
+	TransportUdp  = "udp"
+	TransportTcp  = "tcp"
+	TransportTls  = "tls"
+)
+
 type CryptType uint32
 
 const (
-	CryptNone CryptType = 0
-	CryptAES  CryptType = 1
-	CryptDES  CryptType = 2
-	CryptRSA  CryptType = 3
+	CryptNone     CryptType = 0
+	CryptAES      CryptType = 1
+	CryptDES      CryptType = 2
+	CryptRSA      CryptType = 3
+	CryptAESGCM   CryptType = 4 // 认证加密
+	CryptChaCha20 CryptType = 5 // 认证加密,无AES硬件加速时更快
+)
+
+// 是否为认证加密方式,会话密钥仅支持认证加密
+func (c CryptType) Authenticated() bool {
+	return c == CryptAESGCM || c == CryptChaCha20
+}
+
+// 报文被丢弃的原因
+type DropReason uint32
+
+const (
+	DropAuthFail   DropReason = 0 // 解密或认证失败
+	DropReplay     DropReason = 1 // 重放或超出防重放窗口
+	DropDecompress DropReason = 2 // 解压失败
+	DropNoSession  DropReason = 3 // 发送时会话密钥未协商完成
 )
 
 type LinkMode uint32
@@ -37,15 +87,45 @@ const (
 	LinkGprs   LinkMode = 3
 )
 
-//type NatType uint32
-//
-//const (
-//	NatUnKnown   NatType = 0
-//	NatFullCone  NatType = 1
-//	NatAddrCone  NatType = 2
-//	NatPortCone  NatType = 3
-//	NatSymmetric NatType = 4
-//)
+type NatType uint32
+
+const (
+	NatUnKnown   NatType = 0
+	NatFullCone  NatType = 1
+	NatAddrCone  NatType = 2 // 地址限制锥形
+	NatPortCone  NatType = 3 // 端口限制锥形
+	NatSymmetric NatType = 4
+	NatOpen      NatType = 5 // 公网地址,没有NAT
+)
+
+var natTypeNames = []string{"未知", "完全锥形", "地址限制锥形", "端口限制锥形", "对称型", "公网"}
+
+func (n NatType) String() string {
+	if int(n) >= len(natTypeNames) {
+		return natTypeNames[NatUnKnown]
+	}
+	return natTypeNames[n]
+}
+
+type PunchMode uint32
+
+const (
+	PunchDirect  PunchMode = 0 // 双方向对端公网地址互发打洞报文
+	PunchPredict PunchMode = 1 // 端口预测,批量打开本地端口或探测对端可能的映射端口
+	PunchNone    PunchMode = 2 // 无法打洞,经服务端或中转终端转发
+)
+
+// 按双方NAT类型选择打洞方式,对称型NAT每个目标地址使用不同映射端口,直接互发无法打通;
+// 双方都是对称型时两端的映射端口都要预测,基本无法打通,不再尝试
+func PunchModeOf(self, other NatType) PunchMode {
+	if self == NatSymmetric && other == NatSymmetric {
+		return PunchNone
+	}
+	if self == NatSymmetric || other == NatSymmetric {
+		return PunchPredict
+	}
+	return PunchDirect
+}
 
 const (
 	RegResOk           = 0
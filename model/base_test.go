@@ -0,0 +1,64 @@
+package model
+
+import "testing"
+
+func TestPunchModeOf(t *testing.T) {
+	var cases = []struct {
+		self, other NatType
+		want        PunchMode
+	}{
+		{self: NatFullCone, other: NatFullCone, want: PunchDirect},
+		{self: NatPortCone, other: NatAddrCone, want: PunchDirect},
+		{self: NatOpen, other: NatPortCone, want: PunchDirect},
+		{self: NatUnKnown, other: NatUnKnown, want: PunchDirect}, // 未探测时按普通打洞
+		{self: NatSymmetric, other: NatFullCone, want: PunchPredict},
+		{self: NatPortCone, other: NatSymmetric, want: PunchPredict},
+		{self: NatSymmetric, other: NatSymmetric, want: PunchNone},
+		{self: NatUnKnown, other: NatSymmetric, want: PunchPredict},
+	}
+	for _, c := range cases {
+		if got := PunchModeOf(c.self, c.other); got != c.want {
+			t.Fatalf("%s/%s: %d != %d", c.self, c.other, got, c.want)
+		}
+		if got := PunchModeOf(c.other, c.self); got != c.want {
+			t.Fatalf("%s/%s: %d != %d", c.other, c.self, got, c.want)
+		}
+	}
+}
+
+func TestNatTypeString(t *testing.T) {
+	var cases = []struct {
+		nat  NatType
+		want string
+	}{
+		{nat: NatUnKnown, want: "未知"},
+		{nat: NatPortCone, want: "端口限制锥形"},
+		{nat: NatSymmetric, want: "对称型"},
+		{nat: NatOpen, want: "公网"},
+		{nat: NatType(99), want: "未知"},
+	}
+	for _, c := range cases {
+		if got := c.nat.String(); got != c.want {
+			t.Fatalf("%d: %s != %s", c.nat, got, c.want)
+		}
+	}
+}
+
+func TestCryptTypeAuthenticated(t *testing.T) {
+	var cases = []struct {
+		crypt CryptType
+		want  bool
+	}{
+		{crypt: CryptNone},
+		{crypt: CryptAES},
+		{crypt: CryptDES},
+		{crypt: CryptRSA},
+		{crypt: CryptAESGCM, want: true},
+		{crypt: CryptChaCha20, want: true},
+	}
+	for _, c := range cases {
+		if got := c.crypt.Authenticated(); got != c.want {
+			t.Fatalf("%d: %v != %v", c.crypt, got, c.want)
+		}
+	}
+}
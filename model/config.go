@@ -10,8 +10,11 @@ type Config struct {
 	GroupPwd       string    `json:"group_pwd"`
 	CryptType      CryptType `json:"crypt_type"`
 	PeerPwd        string    `json:"peer_pwd"`
+	SessionKey     bool      `json:"session_key"`
 	TabName        string    `json:"tab_name"`
 	HwMac          string    `json:"hw_mac"`
+	DevType        DevType   `json:"dev_type"`
+	Ip6Addr        string    `json:"ip6_addr"`
 	IpMode         uint      `json:"ip_mode"`
 	IpAddr         string    `json:"ip_addr"`
 	IpMask         string    `json:"ip_mask"`
@@ -21,11 +24,12 @@ type Config struct {
 }
 
 type PeerConfig struct {
-	GroupName string    `json:"group_name"`
-	GroupPwd  string    `json:"group_pwd"`
-	PeerPwd   string    `json:"peer_pwd"`
-	Name      string    `json:"name"`
-	CryptType CryptType `json:"crypt_type"`
+	GroupName  string    `json:"group_name"`
+	GroupPwd   string    `json:"group_pwd"`
+	PeerPwd    string    `json:"peer_pwd"`
+	Name       string    `json:"name"`
+	CryptType  CryptType `json:"crypt_type"`
+	SessionKey bool      `json:"session_key"` // 终端间协商会话密钥,需使用认证加密方式
 }
 type TapConfig struct {
 	Name      string  `json:"name"`
@@ -36,21 +40,53 @@ type TapConfig struct {
 	IpAddrStr string  `json:"ip_addr"`
 	IpMask    uint32  `json:"ip_mask_len"`
 	DevType   DevType `json:"dev_type"`
+	Ip6Addr   string  `json:"ip6_addr"` // IPv6虚拟地址(如fd00::2/64),为空时不配置
+	Mtu       uint32  `json:"mtu"`      // 虚拟网卡MTU,为0时按探测到的服务端路径MTU自动设置
 }
 
 type AppConfig struct {
-	ServerIp         string         `json:"server_ip"`
-	ServerPort       int32          `json:"server_port"`
-	Heartbeat        uint32         `json:"heartbeat"`
-	Offline          uint32         `json:"offline"`
-	MaxPacketSize    int32          `json:"max_packet_size"`
-	PacketNum        int            `json:"packet_num"`
-	P2pTryCount      uint32         `json:"p2p_try_count"`
-	P2pRetryInterval uint32         `json:"p2p_retry_interval"`
-	AllowVisitPort   bool           `json:"allow_visit_port"`
-	EnableLog        bool           `json:"enable_log"`
-	SaveLog          bool           `json:"save_log"`
-	LogLevel         common.LogType `json:"log_level"`
-	PeerConfig       *PeerConfig    `json:"peer_config"`
-	TapConfig        *TapConfig     `json:"tap_config"`
+	ServerIp         string           `json:"server_ip"`
+	ServerPort       int32            `json:"server_port"`
+	Servers          []string         `json:"servers"`          // 备用服务端地址(host:port,可为域名),当前服务端不可用时依次切换
+	ServerPickRtt    bool             `json:"server_pick_rtt"`  // 启动时选择往返时间最短的服务端
+	ServerTransport  string           `json:"server_transport"` // 连接服务端的传输方式 auto udp tcp tls,为空时同udp
+	ServerTcpPort    int32            `json:"server_tcp_port"`  // 服务端TCP端口,为0时同server_port
+	ServerTlsPort    int32            `json:"server_tls_port"`  // 服务端TLS端口,为0时使用443
+	TlsSkipVerify    bool             `json:"tls_skip_verify"`  // 不校验服务端证书(自签名证书)
+	Heartbeat        uint32           `json:"heartbeat"`
+	Offline          uint32           `json:"offline"`
+	MaxPacketSize    int32            `json:"max_packet_size"`
+	PacketNum        int              `json:"packet_num"`
+	P2pTryCount      uint32           `json:"p2p_try_count"`
+	P2pRetryInterval uint32           `json:"p2p_retry_interval"`
+	AllowVisitPort   bool             `json:"allow_visit_port"`
+	Relay            bool             `json:"relay"`          // 允许其他终端经本终端中转到与本终端P2P直连的终端
+	EnableControl    bool             `json:"enable_control"` // 本地控制接口
+	ControlAddr      string           `json:"control_addr"`   // 为空时Linux使用unix socket,其他使用本地回环端口
+	EnableLog        bool             `json:"enable_log"`
+	SaveLog          bool             `json:"save_log"`
+	LogLevel         common.LogType   `json:"log_level"`
+	PeerConfig       *PeerConfig      `json:"peer_config"`
+	TapConfig        *TapConfig       `json:"tap_config"`
+	Routes           []*RouteConfig   `json:"routes"`            // 到其他终端内网的路由
+	AdvertiseSubnets []string         `json:"advertise_subnets"` // 本终端发布的内网网段(CIDR),随注册及心跳发送
+	StaticRoutes     []string         `json:"static_routes"`     // 需要路由的内网网段(CIDR),发布该网段的终端上线后自动添加
+	NatProbeAddr     string           `json:"nat_probe_addr"`    // NAT类型探测服务地址(ip:port),为空时使用服务端
+	ProbeTargets     []string         `json:"probe_targets"`     // 服务端无心跳应答时探测网络的目标,host:port为TCP连接,仅host为ICMP,为空时不探测
+	Compress         string           `json:"compress"`          // 数据压缩算法 lz4 zstd,为空时不压缩;对端可解压时才压缩
+	Broadcast        *BroadcastConfig `json:"broadcast"`         // 广播及组播的过滤和发送方式,为空时全部经服务端转发
+}
+
+type BroadcastRule struct {
+	Action    string `json:"action"`     // allow drop
+	EtherType string `json:"ether_type"` // 以太网类型 ipv4 ipv6 arp 或数值(如0x88cc),为空时不限
+	Protocol  string `json:"protocol"`   // IP协议 udp tcp icmp igmp icmpv6 或协议号,为空时不限
+	Group     string `json:"group"`      // 目的组播组或广播地址,可为网段(如239.0.0.0/8、ff02::/16),为空时不限
+	Port      uint16 `json:"port"`       // UDP目的端口(如mDNS 5353、SSDP 1900、NetBIOS 137),为0时不限
+}
+
+type BroadcastConfig struct {
+	Default   string           `json:"default"`    // 未匹配任何规则时的处理 allow drop,为空时allow
+	Rules     []*BroadcastRule `json:"rules"`      // 按顺序匹配,第一条匹配的规则生效
+	P2PFanout bool             `json:"p2p_fanout"` // 广播复制为单播发送给有P2P连接或中转路径的终端,其余终端仍经服务端广播
 }
@@ -0,0 +1,122 @@
+package model
+
+import (
+	"sync"
+	"time"
+)
+
+// 链路质量统计:按心跳请求/应答计算平滑往返时间、抖动(RFC 3550)及最近若干次心跳的丢包率
+
+const (
+	qualityWindow  = 20              // 丢包率统计的心跳次数
+	qualityTimeout = 3 * time.Second // 超过该时间没有应答视为丢包
+)
+
+type QualityInfo struct {
+	Rtt    uint32 `json:"rtt"`    // 平滑往返时间(毫秒)
+	Jitter uint32 `json:"jitter"` // 抖动(毫秒)
+	Loss   uint32 `json:"loss"`   // 丢包率(%)
+}
+
+type LinkQuality struct {
+	mutex   sync.Mutex
+	nextId  uint64
+	pending map[uint64]time.Time // 等待应答的心跳
+	results []bool               // 最近的心跳结果,true为收到应答
+	rtt     float64
+	jitter  float64
+	last    float64 // 上次的往返时间样本
+	samples int
+}
+
+func NewLinkQuality() *LinkQuality {
+	return &LinkQuality{pending: make(map[uint64]time.Time)}
+}
+
+// 发送心跳前调用,返回心跳编号
+func (q *LinkQuality) Sent() uint64 {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.expire(time.Now())
+	q.nextId++
+	q.pending[q.nextId] = time.Now()
+	return q.nextId
+}
+
+// 收到心跳应答,编号未知或已超时返回false
+func (q *LinkQuality) Received(id uint64) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	sent, ok := q.pending[id]
+	if !ok {
+		return false
+	}
+	delete(q.pending, id)
+	sample := float64(time.Since(sent)) / float64(time.Millisecond)
+	if q.samples == 0 {
+		q.rtt = sample
+	} else {
+		q.rtt += (sample - q.rtt) / 8
+		d := sample - q.last
+		if d < 0 {
+			d = -d
+		}
+		q.jitter += (d - q.jitter) / 16
+	}
+	q.last = sample
+	q.samples++
+	q.record(true)
+	return true
+}
+
+func (q *LinkQuality) expire(now time.Time) {
+	for id, sent := range q.pending {
+		if now.Sub(sent) > qualityTimeout {
+			delete(q.pending, id)
+			q.record(false)
+		}
+	}
+}
+
+func (q *LinkQuality) record(ok bool) {
+	q.results = append(q.results, ok)
+	if len(q.results) > qualityWindow {
+		q.results = q.results[len(q.results)-qualityWindow:]
+	}
+}
+
+// 是否已有往返时间样本
+func (q *LinkQuality) Measured() bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.samples > 0
+}
+
+func (q *LinkQuality) Rtt() uint32 {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return uint32(q.rtt)
+}
+
+func (q *LinkQuality) Info() *QualityInfo {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.expire(time.Now())
+	info := &QualityInfo{Rtt: uint32(q.rtt), Jitter: uint32(q.jitter)}
+	if n := len(q.results); n > 0 {
+		lost := 0
+		for _, ok := range q.results {
+			if !ok {
+				lost++
+			}
+		}
+		info.Loss = uint32(lost * 100 / n)
+	}
+	return info
+}
+
+// 路径评分,越小越好:往返时间加抖动,丢包率每1%按10毫秒计
+func (q *LinkQuality) Score() uint32 {
+	info := q.Info()
+	return info.Rtt + info.Jitter + info.Loss*10
+}
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLinkQuality(t *testing.T) {
+	q := NewLinkQuality()
+	if q.Measured() {
+		t.Fatal("measured without samples")
+	}
+	id := q.Sent()
+	if q.Received(id+1) || q.Measured() {
+		t.Fatal("unknown id accepted")
+	}
+	if !q.Received(id) || !q.Measured() {
+		t.Fatal("pong not recorded")
+	}
+	if q.Received(id) {
+		t.Fatal("duplicate pong accepted")
+	}
+	if info := q.Info(); info.Loss != 0 {
+		t.Fatalf("loss %d != 0", info.Loss)
+	}
+}
+
+func TestLinkQualitySmoothing(t *testing.T) {
+	q := NewLinkQuality()
+	var cases = []struct {
+		sample time.Duration
+		rtt    uint32
+		jitter uint32
+	}{
+		{sample: 80 * time.Millisecond, rtt: 80, jitter: 0},
+		{sample: 80 * time.Millisecond, rtt: 80, jitter: 0},
+		{sample: 160 * time.Millisecond, rtt: 90, jitter: 5},
+	}
+	for i, c := range cases {
+		id := q.Sent()
+		q.pending[id] = time.Now().Add(-c.sample)
+		q.Received(id)
+		// 计时误差在1毫秒以内
+		if info := q.Info(); info.Rtt < c.rtt || info.Rtt > c.rtt+1 || info.Jitter < c.jitter || info.Jitter > c.jitter+1 {
+			t.Fatalf("%d: rtt %d jitter %d, want %d %d", i, info.Rtt, info.Jitter, c.rtt, c.jitter)
+		}
+	}
+}
+
+func TestLinkQualityLoss(t *testing.T) {
+	var cases = []struct {
+		name     string
+		received int
+		lost     int
+		loss     uint32
+	}{
+		{name: "none", received: 10, lost: 0, loss: 0},
+		{name: "quarter", received: 3, lost: 1, loss: 25},
+		{name: "all", received: 0, lost: 5, loss: 100},
+		{name: "window", received: 20, lost: 20, loss: 0}, // 只统计最近20次
+	}
+	for _, c := range cases {
+		q := NewLinkQuality()
+		for i := 0; i < c.lost; i++ {
+			q.pending[q.Sent()] = time.Now().Add(-qualityTimeout - time.Second)
+		}
+		for i := 0; i < c.received; i++ {
+			q.Received(q.Sent())
+		}
+		if info := q.Info(); info.Loss != c.loss {
+			t.Fatalf("%s: loss %d != %d", c.name, info.Loss, c.loss)
+		}
+	}
+}
+
+func TestLinkQualityScore(t *testing.T) {
+	q := NewLinkQuality()
+	id := q.Sent()
+	q.pending[id] = time.Now().Add(-50 * time.Millisecond)
+	q.Received(id)
+	q.pending[q.Sent()] = time.Now().Add(-qualityTimeout - time.Second)
+	q.Info() // 过期的心跳记为丢包
+	// 往返时间50ms,丢包率50%
+	if s := q.Score(); s < 550 || s > 551 {
+		t.Fatalf("score %d", s)
+	}
+}
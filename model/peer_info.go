@@ -2,23 +2,33 @@ package model
 
 import (
 	"fmt"
+	"net"
 	"strconv"
 	"vilan/protocol"
 )
 
 type PeerInfo struct {
-	PeerName    string `json:"peer_name,omitempty"`
-	PeerMac     string `json:"peer_mac,omitempty"`
-	DevType     string `json:"dev_type,omitempty"`
-	NetAddr     string `json:"net_addr,omitempty"`
-	InterAddr   string `json:"inter_addr,omitempty"`
-	Online      bool   `json:"online"`
-	LinkMode    uint32 `json:"link_mode"`
-	LinkQuality uint32 `json:"link_quality"`
-	ConnectType uint   `json:"connect_type"` // 0 转发 ,1 p2p
-	TotalRxTx   string `json:"total_rx_tx"`
-	P2PRxTx     string `json:"p2p_rx_tx"`
-	TransRxTx   string `json:"trans_rx_tx"`
+	PeerName    string       `json:"peer_name,omitempty"`
+	PeerMac     string       `json:"peer_mac,omitempty"`
+	DevType     string       `json:"dev_type,omitempty"`
+	NetAddr     string       `json:"net_addr,omitempty"`
+	InterAddr   string       `json:"inter_addr,omitempty"`
+	NetAddr6    string       `json:"net_addr6,omitempty"`
+	Online      bool         `json:"online"`
+	LinkMode    uint32       `json:"link_mode"`
+	LinkQuality uint32       `json:"link_quality"`
+	ConnectType uint         `json:"connect_type"` // 0 转发 ,1 p2p, 2 经其他终端中转
+	Routed      bool         `json:"routed"`       // 已添加到该终端内网的路由
+	Rtt         uint32       `json:"rtt"`          // 当前路径(P2P或中转)的往返时间(毫秒),经服务端转发时为0
+	Jitter      uint32       `json:"jitter"`       // 抖动(毫秒)
+	Loss        uint32       `json:"loss"`         // 丢包率(%)
+	Mtu         int          `json:"mtu"`          // 按P2P路径MTU计算的隧道MTU,未探测时为0
+	NatType     string       `json:"nat_type"`
+	TotalRxTx   string       `json:"total_rx_tx"`
+	P2PRxTx     string       `json:"p2p_rx_tx"`
+	TransRxTx   string       `json:"trans_rx_tx"`
+	RateTxRx    string       `json:"rate_tx_rx"` // 本终端与该终端之间最近10秒的发送/接收速率
+	Traffic     *PeerTraffic `json:"traffic,omitempty"`
 }
 
 func ProtoToModel(info *protocol.PeerInfo) *PeerInfo {
@@ -31,23 +41,31 @@ func ProtoToModel(info *protocol.PeerInfo) *PeerInfo {
 	m.DevType = info.DevType
 	m.NetAddr = uint2IpV4(info.NetAddr) + "/" + strconv.Itoa(int(info.NetBitLen))
 	m.InterAddr = uint2IpV4(info.InterAddr) + "/" + strconv.Itoa(int(info.InterNetBitLen))
+	if a := info.NetAddr6; a != nil && len(a.NetAddr6) == net.IPv6len {
+		m.NetAddr6 = net.IP(a.NetAddr6).String() + "/" + strconv.Itoa(int(a.NetBitLen))
+	}
 	m.Online = info.Online
+	m.NatType = NatType(info.NatType).String()
 	m.ConnectType = 0
 	m.LinkMode = info.LinkMode
 	m.LinkQuality = info.LinkQuality
 	if info.Stats != nil {
-		m.TotalRxTx = sizeFormat(info.Stats.TransSend+info.Stats.P2PSend) + " / " + sizeFormat(info.Stats.TransReceive+info.Stats.P2PReceive)
-		m.TransRxTx = sizeFormat(info.Stats.TransSend) + " / " + sizeFormat(info.Stats.TransReceive)
-		m.P2PRxTx = sizeFormat(info.Stats.P2PSend) + " / " + sizeFormat(info.Stats.P2PReceive)
+		m.TotalRxTx = SizeFormat(info.Stats.TransSend+info.Stats.P2PSend) + " / " + SizeFormat(info.Stats.TransReceive+info.Stats.P2PReceive)
+		m.TransRxTx = SizeFormat(info.Stats.TransSend) + " / " + SizeFormat(info.Stats.TransReceive)
+		m.P2PRxTx = SizeFormat(info.Stats.P2PSend) + " / " + SizeFormat(info.Stats.P2PReceive)
 	}
 	return m
 }
 
+func RateFormat(rate uint64) string {
+	return SizeFormat(rate) + "/s"
+}
+
 func uint2IpV4(ip uint32) string {
 	return fmt.Sprintf("%d.%d.%d.%d", ip>>24, (ip>>16)&0xFF, (ip>>8)&0xFF, ip&0xFF)
 }
 
-func sizeFormat(size uint64) string {
+func SizeFormat(size uint64) string {
 	if size < 1024 {
 		return fmt.Sprintf("%d B", size)
 	} else if size < 1048576 {
@@ -17,3 +17,17 @@ func (r *RouteInfo) GetMask() string {
 func (r *RouteInfo) GetGw() string {
 	return fmt.Sprintf("%d.%d.%d.%d", r.Gateway>>24, (r.Gateway>>16)&0xFF, (r.Gateway>>8)&0xFF, r.Gateway&0xFF)
 }
+
+// 虚拟网卡上由本程序添加的路由
+type TapRoute struct {
+	Dst  string `json:"dst"`  // 目标网段
+	Mask string `json:"mask"` // 子网掩码
+	Gw   string `json:"gw"`   // 网关,对端终端的虚拟IP
+	Dev  string `json:"dev"`  // 网卡名称
+}
+
+// 配置中保存的终端路由,终端上线后按其内网网段添加路由
+type RouteConfig struct {
+	PeerMac  uint64 `json:"peer_mac,string"`
+	PeerName string `json:"peer_name"`
+}
@@ -1,6 +1,11 @@
 package model
 
 import (
+	"errors"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"syscall"
 	"time"
 	"vilan/common"
 	"vilan/netty"
@@ -15,8 +20,15 @@ type ServerSockContext struct {
 	Heartbeat   uint32
 	Offline     uint32
 	Connected   bool
+	Checker     LivenessChecker // 心跳超时后的存活检查,为nil时等到离线时间
 }
 
+// 连接存活检查,服务端心跳超时但未到离线时间时调用;检查在后台进行,不可达时由调用方另行处理结果
+type LivenessChecker interface {
+	Check()
+}
+
+// 心跳超时但未到离线时间时启动存活检查,检查结果返回前仍视为已连接
 func (s *ServerSockContext) IsConnected() bool {
 	if !s.Connected {
 		return false
@@ -24,15 +36,63 @@ func (s *ServerSockContext) IsConnected() bool {
 	now := time.Now().Unix()
 	if now-s.LastReceive >= int64(s.Offline) {
 		return false
-	} else if now-s.LastReceive > int64(s.Heartbeat+3) {
-		return isNetOk()
+	} else if s.HeartbeatTimeout() {
+		if s.Checker != nil {
+			s.Checker.Check()
+		}
+		return true
 	} else {
 		return true
 	}
 }
 
-func isNetOk() bool {
-	return common.Ping("www.baidu.com", 1000)
+// 已连接且心跳超时
+func (s *ServerSockContext) HeartbeatTimeout() bool {
+	return s.Connected && time.Now().Unix()-s.LastReceive > int64(s.Heartbeat+3)
+}
+
+// Sock.Family 取值,AF_INET6 在各系统定义不同,协议中固定使用Linux的取值
+const (
+	FamilyIpv4 = syscall.AF_INET
+	FamilyIpv6 = 10
+)
+
+// 由 ip:port 或 [ipv6]:port 格式地址生成Sock
+func NewSock(addr string) (*protocol.Sock, error) {
+	ip, port, err := common.SplitAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	return IpToSock(ip, port)
+}
+
+func IpToSock(ip net.IP, port uint32) (*protocol.Sock, error) {
+	if ip4 := ip.To4(); ip4 != nil {
+		v, err := common.IpV4toUint32(ip4.String())
+		if err != nil {
+			return nil, err
+		}
+		return &protocol.Sock{Family: FamilyIpv4, Addr: v, Port: port}, nil
+	}
+	if ip6 := ip.To16(); ip6 != nil {
+		return &protocol.Sock{Family: FamilyIpv6, Addr6: ip6, Port: port}, nil
+	}
+	return nil, errors.New("invalid ip")
+}
+
+// Sock对应的地址字符串,用于建立连接
+func SockAddr(s *protocol.Sock) string {
+	if s == nil {
+		return ""
+	}
+	return net.JoinHostPort(SockIp(s).String(), strconv.Itoa(int(s.Port)))
+}
+
+func SockIp(s *protocol.Sock) net.IP {
+	if len(s.GetAddr6()) == net.IPv6len {
+		return s.Addr6
+	}
+	return net.ParseIP(common.Uint32toIpV4(s.GetAddr()))
 }
 
 // p2p成功后的会话
@@ -48,18 +108,49 @@ type PeerSockContext struct {
 	Heartbeat       uint32 // 心跳间隔时间
 	Offline         uint32
 	Connected       bool
+	Ipv6            bool               // 通过IPv6直连
+	Lan             bool               // 双方在同一NAT后,通过内网地址直连
+	Probe           bool               // 端口预测打洞时附加的尝试套接字
+	Probes          []*PeerSockContext // 同时尝试的附加套接字(端口预测、内网直连时的公网地址),打洞成功后只保留收到打洞报文的一个
+	Quality         *LinkQuality       // 往返时间、抖动及丢包率
+	RttTime         int64              // 上次测量往返时间的时间
+	Pmtu            int                // 探测到的路径MTU,0为未探测
+	PmtuTime        int64              // 上次探测路径MTU的时间
+	relayLinks      atomic.Value       // *relayLinks,由数据处理协程更新,发送时读取
+}
+
+// 对端开启中转时可到达的终端及往返时间
+type relayLinks struct {
+	links map[uint64]uint32
+	time  int64 // 收到中转信息的时间
 }
 
 func (s *PeerSockContext) IsConnected() bool {
 	return s.Connected && uint32(time.Now().Unix()-s.LastReceive) < s.Offline
 }
 
+// 替换对端的中转信息,links存入后不再修改
+func (s *PeerSockContext) SetRelayLinks(links map[uint64]uint32) {
+	s.relayLinks.Store(&relayLinks{links: links, time: time.Now().Unix()})
+}
+
+// 对端最近expire秒内告知的到dstMac的往返时间,不可到达或中转信息过期时ok为false
+func (s *PeerSockContext) RelayRtt(dstMac uint64, expire int64) (rtt uint32, ok bool) {
+	l, _ := s.relayLinks.Load().(*relayLinks)
+	if l == nil || l.time < time.Now().Unix()-expire {
+		return 0, false
+	}
+	rtt, ok = l.links[dstMac]
+	return
+}
+
 // 打洞使用的sock
 type PunchSockContext struct {
 	PeerMac     uint64 // 对端mac
 	Bootstrap   netty.Bootstrap
 	Handler     netty.HandlerContext
 	LocalSock   *protocol.Sock
+	Candidates  []*protocol.Sock // 本端直连候选地址(公网IPv6、内网IPv4),随触发消息发送
 	StartTime   int64
 	LastReceive int64
 	Connected   bool
@@ -68,6 +159,7 @@ type PunchFailInfo struct {
 	DstMac      uint64
 	FailedCount uint32    // 失败次数 次数大于10次 不在允许继续
 	FailedTime  time.Time // 失败时间
+	Ipv6Failed  bool      // IPv6直连失败,之后只尝试IPv4打洞
 }
 
 type MessageOut struct {
@@ -0,0 +1,154 @@
+package model
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 按终端统计的流量:分别记录经服务端转发、P2P直连、经其他终端中转的字节数及报文数,
+// 按秒分桶保留最近 trafficWindow 秒,用于计算10秒及1分钟的平均速率
+
+type StatsPath uint32
+
+const (
+	PathServer StatsPath = 0 // 经服务端转发
+	PathP2P    StatsPath = 1 // P2P直连
+	PathRelay  StatsPath = 2 // 经其他终端中转
+)
+
+const trafficWindow = 60
+
+type TrafficCounter struct {
+	TxBytes   uint64 `json:"tx_bytes"`
+	RxBytes   uint64 `json:"rx_bytes"`
+	TxPackets uint64 `json:"tx_packets"`
+	RxPackets uint64 `json:"rx_packets"`
+	TxRate    uint64 `json:"tx_rate"`    // 最近10秒的平均发送速率(字节/秒)
+	RxRate    uint64 `json:"rx_rate"`    // 最近10秒的平均接收速率(字节/秒)
+	TxRate1m  uint64 `json:"tx_rate_1m"` // 最近1分钟的平均发送速率(字节/秒)
+	RxRate1m  uint64 `json:"rx_rate_1m"` // 最近1分钟的平均接收速率(字节/秒)
+}
+
+type PeerTraffic struct {
+	PeerMac    string          `json:"peer_mac"`
+	Server     *TrafficCounter `json:"server"`
+	P2P        *TrafficCounter `json:"p2p"`
+	Relay      *TrafficCounter `json:"relay"`
+	AuthFail   uint64          `json:"auth_fail"`   // 解密或认证失败丢弃的报文数
+	ReplayDrop uint64          `json:"replay_drop"` // 重放或过旧而丢弃的报文数
+	Decompress uint64          `json:"decompress"`  // 解压失败丢弃的报文数
+	NoSession  uint64          `json:"no_session"`  // 会话密钥未协商而未发送的报文数
+	ZipRaw     uint64          `json:"zip_raw"`     // 压缩的报文压缩前字节数(发送及接收)
+	ZipSize    uint64          `json:"zip_size"`    // 压缩的报文压缩后字节数
+}
+
+// 各路径合计的10秒平均速率
+func (t *PeerTraffic) Rate() (tx uint64, rx uint64) {
+	for _, c := range []*TrafficCounter{t.Server, t.P2P, t.Relay} {
+		if c != nil {
+			tx += c.TxRate
+			rx += c.RxRate
+		}
+	}
+	return
+}
+
+// 压缩比(压缩前/压缩后),没有压缩的报文时为空
+func CompressRatio(raw uint64, size uint64) string {
+	if size == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.2f", float64(raw)/float64(size))
+}
+
+type pathMeter struct {
+	counter TrafficCounter
+	tx      [trafficWindow]uint64 // 每秒发送字节数
+	rx      [trafficWindow]uint64
+	stamps  [trafficWindow]int64 // 桶对应的秒
+}
+
+func (p *pathMeter) add(now int64, size uint64, rx bool) {
+	i := now % trafficWindow
+	if p.stamps[i] != now {
+		p.stamps[i], p.tx[i], p.rx[i] = now, 0, 0
+	}
+	if rx {
+		p.counter.RxBytes += size
+		p.counter.RxPackets++
+		p.rx[i] += size
+	} else {
+		p.counter.TxBytes += size
+		p.counter.TxPackets++
+		p.tx[i] += size
+	}
+}
+
+// 最近n个完整秒的平均速率,不含当前秒
+func (p *pathMeter) rate(now int64, n int64) (tx uint64, rx uint64) {
+	for i := range p.stamps {
+		if s := p.stamps[i]; s < now && s >= now-n {
+			tx += p.tx[i]
+			rx += p.rx[i]
+		}
+	}
+	return tx / uint64(n), rx / uint64(n)
+}
+
+func (p *pathMeter) snapshot(now int64) *TrafficCounter {
+	c := p.counter
+	c.TxRate, c.RxRate = p.rate(now, 10)
+	c.TxRate1m, c.RxRate1m = p.rate(now, trafficWindow-1)
+	return &c
+}
+
+type TrafficMeter struct {
+	mutex      sync.Mutex
+	paths      [3]pathMeter
+	authFail   uint64
+	replayDrop uint64
+	decompress uint64
+	noSession  uint64
+	zipRaw     uint64
+	zipSize    uint64
+}
+
+func (m *TrafficMeter) Add(path StatsPath, size uint64, rx bool) {
+	if int(path) >= len(m.paths) {
+		return
+	}
+	m.mutex.Lock()
+	m.paths[path].add(time.Now().Unix(), size, rx)
+	m.mutex.Unlock()
+}
+
+func (m *TrafficMeter) Drop(reason DropReason) {
+	m.mutex.Lock()
+	switch reason {
+	case DropAuthFail:
+		m.authFail++
+	case DropReplay:
+		m.replayDrop++
+	case DropDecompress:
+		m.decompress++
+	case DropNoSession:
+		m.noSession++
+	}
+	m.mutex.Unlock()
+}
+
+func (m *TrafficMeter) Compress(raw uint64, size uint64) {
+	m.mutex.Lock()
+	m.zipRaw += raw
+	m.zipSize += size
+	m.mutex.Unlock()
+}
+
+func (m *TrafficMeter) Snapshot(mac string) *PeerTraffic {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	now := time.Now().Unix()
+	return &PeerTraffic{PeerMac: mac, Server: m.paths[PathServer].snapshot(now), P2P: m.paths[PathP2P].snapshot(now),
+		Relay: m.paths[PathRelay].snapshot(now), AuthFail: m.authFail, ReplayDrop: m.replayDrop, Decompress: m.decompress, NoSession: m.noSession, ZipRaw: m.zipRaw, ZipSize: m.zipSize}
+}
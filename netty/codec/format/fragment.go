@@ -0,0 +1,196 @@
+/*
+ * Copyright 2019 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package format
+
+import (
+	"encoding/binary"
+	"github.com/golang/protobuf/proto"
+	"sync/atomic"
+	"time"
+	"vilan/app"
+	"vilan/model"
+	"vilan/netty"
+	"vilan/netty/utils"
+	"vilan/protocol"
+)
+
+// 数据帧分片:加密后的报文超过路径MTU时将密文按序切分,每片为带相同分片编号及序号的数据帧,
+// 接收端在同一连接上按(源终端,分片编号)重组后再解密认证;超时未到齐、超出内存或报文数上限、
+// 重组后超过最大帧长的分片直接丢弃。
+// 以TCP连接服务端时服务端可能以UDP转发给对端,同样按1500字节的路径MTU分片。
+// 中转报文对内层报文分片,中转终端原样转发各分片,由目的终端重组。
+
+const (
+	fragMaxCount   = 64              // 单个报文的最大分片数
+	fragTimeout    = 3 * time.Second // 分片到齐的最长时间
+	fragMaxBytes   = 512 * 1024      // 每个连接重组中的分片最多占用的内存
+	fragMaxEntries = 256             // 每个连接同时重组的报文数
+	fragEntrySize  = 256             // 每个重组中的报文按该大小计入内存,限制大量小分片占用的内存
+	udpOverhead    = 40 + 8          // 按IPv6头计算,IPv4时略保守
+)
+
+var fragIdSeq uint32
+
+type fragKey struct {
+	src uint64
+	id  uint32
+}
+
+type fragEntry struct {
+	parts    [][]byte
+	received int
+	size     int // 已收到的分片长度加 fragEntrySize
+	start    time.Time
+}
+
+type reassembler struct {
+	entries    map[fragKey]*fragEntry
+	bytes      int
+	lastExpire time.Time
+}
+
+// 加入分片,所有分片到齐时返回拼接后的数据
+func (r *reassembler) add(msg *protocol.MsgDataFrame) []byte {
+	if msg.FragCount > fragMaxCount || msg.FragIndex >= msg.FragCount || len(msg.Data) == 0 {
+		return nil
+	}
+	now := time.Now()
+	if now.Sub(r.lastExpire) > time.Second {
+		r.expire(now)
+	}
+	if r.entries == nil {
+		r.entries = make(map[fragKey]*fragEntry)
+	}
+	key := fragKey{src: msg.SrcMac, id: msg.FragId}
+	e := r.entries[key]
+	if e == nil {
+		if len(r.entries) >= fragMaxEntries || r.bytes+fragEntrySize > fragMaxBytes {
+			app.Logger.Debug("分片重组超出报文数上限,丢弃:", msg.SrcMac)
+			return nil
+		}
+		e = &fragEntry{parts: make([][]byte, msg.FragCount), size: fragEntrySize, start: now}
+		r.entries[key] = e
+		r.bytes += fragEntrySize
+	}
+	if len(e.parts) != int(msg.FragCount) || e.parts[msg.FragIndex] != nil { // 分片数不一致或重复
+		return nil
+	}
+	if e.size-fragEntrySize+len(msg.Data) > model.SizeMaxFrame {
+		app.Logger.Debug("分片重组后超过最大帧长,丢弃:", msg.SrcMac)
+		r.remove(key, e)
+		return nil
+	}
+	if r.bytes+len(msg.Data) > fragMaxBytes {
+		app.Logger.Debug("分片重组超出内存上限,丢弃:", msg.SrcMac)
+		r.remove(key, e)
+		return nil
+	}
+	e.parts[msg.FragIndex] = msg.Data
+	e.received++
+	e.size += len(msg.Data)
+	r.bytes += len(msg.Data)
+	if e.received < len(e.parts) {
+		return nil
+	}
+	r.remove(key, e)
+	data := make([]byte, 0, e.size-fragEntrySize)
+	for _, p := range e.parts {
+		data = append(data, p...)
+	}
+	return data
+}
+
+func (r *reassembler) remove(key fragKey, e *fragEntry) {
+	delete(r.entries, key)
+	r.bytes -= e.size
+}
+
+func (r *reassembler) expire(now time.Time) {
+	r.lastExpire = now
+	for k, e := range r.entries {
+		if now.Sub(e.start) > fragTimeout {
+			r.remove(k, e)
+		}
+	}
+}
+
+// 重组分片,返回false表示分片未到齐或被丢弃
+func (r *reassembler) reassemble(msg *protocol.MsgDataFrame) bool {
+	data := r.add(msg)
+	if data == nil {
+		return false
+	}
+	msg.Data = data
+	msg.FragId, msg.FragIndex, msg.FragCount = 0, 0, 0
+	return true
+}
+
+// 单个数据帧编码后的最大长度,按到对端(P2P)或服务端的路径MTU计算,未探测时按1500
+func (v *protobufCodec) datagramLimit(m *protocol.MsgDataFrame) int {
+	pmtu := 0
+	if v.p2p {
+		pmtu = app.P2pService.PathMtu(m.DstMac)
+	} else {
+		pmtu = app.RuntimeService.ServerPmtu()
+	}
+	if pmtu == 0 {
+		pmtu = model.PathMtuDefault
+	}
+	return pmtu - udpOverhead
+}
+
+// 分片发送,报文不需要或不能分片时返回false,由调用方整体发送
+func (v *protobufCodec) writeFragments(ctx netty.OutboundContext, m *protocol.MsgDataFrame, limit int) bool {
+	inner := m
+	if m.MsgType == protocol.MsgType_Msg_Relay {
+		inner = m.Relay
+	}
+	if inner == nil || inner.MsgType != protocol.MsgType_Msg_Packet || len(inner.Data) == 0 || inner.FragCount > 0 {
+		return false
+	}
+	payload := inner.Data
+	// 按最大的分片字段计算每片的头部开销
+	inner.Data = nil
+	inner.FragId = atomic.AddUint32(&fragIdSeq, 1)
+	inner.FragIndex, inner.FragCount = fragMaxCount, fragMaxCount
+	head := proto.Size(m) + 2 + proto.SizeVarint(uint64(limit)) + binary.MaxVarintLen32
+	chunk := limit - head
+	if chunk <= 0 || (len(payload)+chunk-1)/chunk > fragMaxCount {
+		// 路径MTU过小或分片过多,不丢弃报文,整体发送由IP层分片
+		app.Logger.Debug("报文不能按路径MTU分片,整体发送:", len(payload), ",", limit)
+		inner.Data, inner.FragId, inner.FragIndex, inner.FragCount = payload, 0, 0, 0
+		return false
+	}
+	count := (len(payload) + chunk - 1) / chunk
+	inner.FragCount = uint32(count)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * chunk
+		if end > len(payload) {
+			end = len(payload)
+		}
+		inner.FragIndex = uint32(i)
+		inner.Data = payload[i*chunk : end]
+		data, err := proto.Marshal(m)
+		if err != nil {
+			return true
+		}
+		var head = [binary.MaxVarintLen32]byte{}
+		n := utils.PutUvarint32(head[:], uint32(len(data)))
+		ctx.HandleWrite([][]byte{head[:n], data})
+	}
+	return true
+}
@@ -0,0 +1,170 @@
+/*
+ * Copyright 2019 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package format
+
+import (
+	"bytes"
+	"github.com/golang/protobuf/proto"
+	"testing"
+	"vilan/app"
+	"vilan/common"
+	"vilan/model"
+	"vilan/netty"
+	"vilan/protocol"
+)
+
+func init() {
+	if app.Logger == nil {
+		app.Logger = common.NewLogger(false, false, common.Error)
+	}
+}
+
+func fragment(id uint32, index uint32, count uint32, data []byte) *protocol.MsgDataFrame {
+	return &protocol.MsgDataFrame{MsgType: protocol.MsgType_Msg_Packet, SrcMac: 1, DstMac: 2,
+		FragId: id, FragIndex: index, FragCount: count, Data: data}
+}
+
+func TestReassembler(t *testing.T) {
+	big := bytes.Repeat([]byte{1}, model.SizeMaxFrame/2+1)
+	var cases = []struct {
+		name  string
+		parts []*protocol.MsgDataFrame
+		want  []byte // 最后一个分片加入后的结果
+	}{
+		{name: "in order", parts: []*protocol.MsgDataFrame{
+			fragment(1, 0, 3, []byte("ab")), fragment(1, 1, 3, []byte("cd")), fragment(1, 2, 3, []byte("e"))},
+			want: []byte("abcde")},
+		{name: "out of order", parts: []*protocol.MsgDataFrame{
+			fragment(1, 2, 3, []byte("e")), fragment(1, 0, 3, []byte("ab")), fragment(1, 1, 3, []byte("cd"))},
+			want: []byte("abcde")},
+		{name: "duplicate", parts: []*protocol.MsgDataFrame{
+			fragment(1, 0, 2, []byte("ab")), fragment(1, 0, 2, []byte("xx"))}},
+		{name: "count mismatch", parts: []*protocol.MsgDataFrame{
+			fragment(1, 0, 2, []byte("ab")), fragment(1, 1, 3, []byte("cd"))}},
+		{name: "other id", parts: []*protocol.MsgDataFrame{
+			fragment(1, 0, 2, []byte("ab")), fragment(2, 1, 2, []byte("cd"))}},
+		{name: "index out of range", parts: []*protocol.MsgDataFrame{fragment(1, 2, 2, []byte("ab"))}},
+		{name: "too many fragments", parts: []*protocol.MsgDataFrame{fragment(1, 0, fragMaxCount+1, []byte("ab"))}},
+		{name: "empty", parts: []*protocol.MsgDataFrame{fragment(1, 0, 1, nil)}},
+		{name: "single", parts: []*protocol.MsgDataFrame{fragment(1, 0, 1, []byte("ab"))}, want: []byte("ab")},
+		{name: "over max frame", parts: []*protocol.MsgDataFrame{
+			fragment(1, 0, 3, big), fragment(1, 1, 3, big), fragment(1, 2, 3, []byte("e"))}},
+	}
+	for _, c := range cases {
+		r := &reassembler{}
+		var got []byte
+		for _, p := range c.parts {
+			got = r.add(p)
+		}
+		if !bytes.Equal(got, c.want) {
+			t.Fatalf("%s: %q != %q", c.name, got, c.want)
+		}
+		if c.want != nil && (len(r.entries) != 0 || r.bytes != 0) {
+			t.Fatalf("%s: %d entries %d bytes left", c.name, len(r.entries), r.bytes)
+		}
+	}
+}
+
+func TestReassemblerLimits(t *testing.T) {
+	r := &reassembler{}
+	// 大量只到一片的小报文
+	for id := uint32(0); id < fragMaxEntries*2; id++ {
+		r.add(fragment(id, 0, 2, []byte{1}))
+	}
+	if len(r.entries) != fragMaxEntries || r.bytes != fragMaxEntries*(fragEntrySize+1) {
+		t.Fatalf("entries %d bytes %d", len(r.entries), r.bytes)
+	}
+
+	// 大分片按内存上限丢弃
+	r = &reassembler{}
+	part := bytes.Repeat([]byte{1}, 4096)
+	for id := 0; id < fragMaxBytes/len(part)*2; id++ {
+		r.add(fragment(uint32(id), 0, 2, part))
+		if r.bytes > fragMaxBytes {
+			t.Fatalf("bytes %d over limit", r.bytes)
+		}
+	}
+	for k, e := range r.entries {
+		r.remove(k, e)
+	}
+	if r.bytes != 0 {
+		t.Fatalf("bytes %d after remove", r.bytes)
+	}
+}
+
+func TestWriteFragments(t *testing.T) {
+	payload := make([]byte, 5000)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+	var cases = []struct {
+		name    string
+		msg     *protocol.MsgDataFrame
+		limit   int
+		handled bool
+		count   int
+	}{
+		{name: "packet", msg: &protocol.MsgDataFrame{MsgType: protocol.MsgType_Msg_Packet, SrcMac: 1, DstMac: 2, Data: payload},
+			limit: 1400, handled: true, count: 4},
+		{name: "relay", msg: &protocol.MsgDataFrame{MsgType: protocol.MsgType_Msg_Relay, SrcMac: 1, DstMac: 3,
+			Relay: &protocol.MsgDataFrame{MsgType: protocol.MsgType_Msg_Packet, SrcMac: 1, DstMac: 2, Data: payload}},
+			limit: 1400, handled: true, count: 4},
+		{name: "tiny limit", msg: &protocol.MsgDataFrame{MsgType: protocol.MsgType_Msg_Packet, SrcMac: 1, DstMac: 2, Data: payload},
+			limit: 10},
+		{name: "too many fragments", msg: &protocol.MsgDataFrame{MsgType: protocol.MsgType_Msg_Packet, SrcMac: 1, DstMac: 2, Data: payload},
+			limit: 100},
+		{name: "not packet", msg: &protocol.MsgDataFrame{MsgType: protocol.MsgType_Msg_Ping, SrcMac: 1, DstMac: 2, Data: payload},
+			limit: 1400},
+		{name: "already fragment", msg: fragment(1, 0, 2, payload), limit: 1400},
+	}
+	v := &protobufCodec{}
+	for _, c := range cases {
+		var writes [][][]byte
+		ctx := MockHandlerContext{MockHandleWrite: func(message netty.Message) {
+			writes = append(writes, message.([][]byte))
+		}}
+		if handled := v.writeFragments(ctx, c.msg, c.limit); handled != c.handled || len(writes) != c.count {
+			t.Fatalf("%s: handled %v writes %d", c.name, handled, len(writes))
+		}
+		if inner := c.msg; !c.handled && c.msg.FragCount == 0 {
+			if inner.Relay != nil {
+				inner = inner.Relay
+			}
+			if inner.FragCount != 0 || !bytes.Equal(inner.Data, payload) { // 不分片时报文不变,由调用方整体发送
+				t.Fatalf("%s: message changed", c.name)
+			}
+		}
+		r := &reassembler{}
+		var data []byte
+		for _, w := range writes {
+			if n := len(w[0]) + len(w[1]); n > c.limit {
+				t.Fatalf("%s: datagram %d over limit %d", c.name, n, c.limit)
+			}
+			m := &protocol.MsgDataFrame{}
+			if err := proto.Unmarshal(w[1], m); err != nil {
+				t.Fatal(err)
+			}
+			if m.MsgType == protocol.MsgType_Msg_Relay {
+				m = m.Relay
+			}
+			data = r.add(m)
+		}
+		if c.count > 0 && !bytes.Equal(data, payload) {
+			t.Fatalf("%s: reassembled %d bytes", c.name, len(data))
+		}
+	}
+}
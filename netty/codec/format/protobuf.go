@@ -21,13 +21,16 @@ import (
 	"fmt"
 	"github.com/golang/protobuf/proto"
 	"vilan/app"
+	"vilan/common"
+	"vilan/config"
+	"vilan/model"
 	"vilan/netty"
 	"vilan/netty/codec"
 	"vilan/netty/utils"
 	"vilan/protocol"
 )
 
-const PackLength = 2048
+const PackLength = model.SizeMaxFrame
 const ExSize = 30
 
 // scheme :0 -> tcp; !=0 -> udp
@@ -40,14 +43,27 @@ func ProtobufCodec(scheme uint32, maxFrameLength uint32) codec.Codec {
 		packetBuffer:   []byte{},
 		rxBuffer:       make([]byte, PackLength),
 		txBuffer:       make([]byte, PackLength),
+		zipBuffer:      make([]byte, PackLength),
+		unzipBuffer:    make([]byte, PackLength),
 	}
 }
 
+// P2P连接使用,数据帧按P2P直连或经其他终端中转统计流量
+func P2PProtobufCodec(maxFrameLength uint32) codec.Codec {
+	c := ProtobufCodec(1, maxFrameLength).(*protobufCodec)
+	c.p2p = true
+	return c
+}
+
 type protobufCodec struct {
 	scheme         uint32
+	p2p            bool
 	packetBuffer   []byte
 	rxBuffer       []byte
 	txBuffer       []byte
+	zipBuffer      []byte // 发送时压缩
+	unzipBuffer    []byte // 接收时解压
+	fragments      reassembler
 	frameLength    uint32
 	maxFrameLength uint32
 }
@@ -72,22 +88,11 @@ func (v *protobufCodec) HandleRead(ctx netty.InboundContext, message netty.Messa
 			return
 		}
 		if v.rxBuffer[num] == 144 && v.rxBuffer[num+1] == 3 {
-			msg := &protocol.MsgDataFrame{}
-			if err := proto.Unmarshal(v.rxBuffer[num:rn], msg); err == nil {
-				app.RuntimeService.SetStats(uint64(ExSize+len(msg.Data)), true, msg.Token == 0)
-				if len(msg.Data) > 0 {
-					if l, e := app.RuntimeService.DecryptMsg(msg, v.txBuffer[:]); e == nil {
-						msg.Data = v.txBuffer[:l]
-					}
-					ctx.HandleRead(msg)
-				} else {
-					ctx.HandleRead(msg)
-				}
-			}
+			v.handleDataFrame(ctx, v.rxBuffer[num:rn])
 		} else {
 			msg := &protocol.MsgServerFrame{}
 			if err := proto.Unmarshal(v.rxBuffer[num:rn], msg); err == nil {
-				app.RuntimeService.SetStats(uint64(msg.XXX_Size()), true, false)
+				app.RuntimeService.SetStats(0, uint64(msg.XXX_Size()), true, model.PathServer)
 				ctx.HandleRead(msg)
 			}
 		}
@@ -129,22 +134,11 @@ func (v *protobufCodec) readAndDeal(ctx netty.InboundContext) {
 		v.packetBuffer = nil
 		v.frameLength = 0
 		if data[0] == 144 && data[1] == 3 { // field index 区分
-			msg := &protocol.MsgDataFrame{}
-			if err := proto.Unmarshal(data, msg); err == nil {
-				app.RuntimeService.SetStats(uint64(ExSize+len(msg.Data)), true, msg.Token == 0)
-				if len(msg.Data) > 0 {
-					if l, e := app.RuntimeService.DecryptMsg(msg, v.txBuffer[:]); e == nil {
-						msg.Data = v.txBuffer[:l]
-					}
-					ctx.HandleRead(msg)
-				} else {
-					ctx.HandleRead(msg)
-				}
-			}
+			v.handleDataFrame(ctx, data)
 		} else {
 			msg := &protocol.MsgServerFrame{}
 			if err := proto.Unmarshal(data, msg); err == nil {
-				app.RuntimeService.SetStats(uint64(msg.XXX_Size()), true, false)
+				app.RuntimeService.SetStats(0, uint64(msg.XXX_Size()), true, model.PathServer)
 				ctx.HandleRead(msg)
 			}
 		}
@@ -154,22 +148,11 @@ func (v *protobufCodec) readAndDeal(ctx netty.InboundContext) {
 			data := v.packetBuffer[:frameLength]
 			v.packetBuffer = v.packetBuffer[frameLength:]
 			if data[0] == 144 && data[1] == 3 {
-				msg := &protocol.MsgDataFrame{}
-				if err := proto.Unmarshal(data, msg); err == nil {
-					app.RuntimeService.SetStats(uint64(ExSize+len(msg.Data)), true, msg.Token == 0)
-					if len(msg.Data) > 0 {
-						if l, e := app.RuntimeService.DecryptMsg(msg, v.txBuffer[:]); e == nil {
-							msg.Data = v.txBuffer[:l]
-						}
-						ctx.HandleRead(msg)
-					} else {
-						ctx.HandleRead(msg)
-					}
-				}
+				v.handleDataFrame(ctx, data)
 			} else {
 				msg := &protocol.MsgServerFrame{}
 				if err := proto.Unmarshal(data, msg); err == nil {
-					app.RuntimeService.SetStats(uint64(msg.XXX_Size()), true, false)
+					app.RuntimeService.SetStats(0, uint64(msg.XXX_Size()), true, model.PathServer)
 					ctx.HandleRead(msg)
 				}
 			}
@@ -187,22 +170,11 @@ func (v *protobufCodec) readAndDeal(ctx netty.InboundContext) {
 			v.packetBuffer = []byte{}
 			v.frameLength = 0
 			if data[0] == 144 && data[1] == 3 {
-				msg := &protocol.MsgDataFrame{}
-				if err := proto.Unmarshal(data, msg); err == nil {
-					app.RuntimeService.SetStats(uint64(ExSize+len(msg.Data)), true, msg.Token == 0)
-					if len(msg.Data) > 0 {
-						if l, e := app.RuntimeService.DecryptMsg(msg, v.txBuffer[:]); e == nil {
-							msg.Data = v.txBuffer[:l]
-						}
-						ctx.HandleRead(msg)
-					} else {
-						ctx.HandleRead(msg)
-					}
-				}
+				v.handleDataFrame(ctx, data)
 			} else {
 				msg := &protocol.MsgServerFrame{}
 				if err := proto.Unmarshal(data, msg); err == nil {
-					app.RuntimeService.SetStats(uint64(msg.XXX_Size()), true, false)
+					app.RuntimeService.SetStats(0, uint64(msg.XXX_Size()), true, model.PathServer)
 					ctx.HandleRead(msg)
 				}
 			}
@@ -214,6 +186,102 @@ func (v *protobufCodec) readAndDeal(ctx netty.InboundContext) {
 	}
 }
 
+// 数据帧解码并解密,解密或认证失败的报文直接丢弃并计数,接收流量只统计通过认证的报文
+func (v *protobufCodec) handleDataFrame(ctx netty.InboundContext, data []byte) {
+	msg := &protocol.MsgDataFrame{}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return
+	}
+	mac, path := v.statsPath(msg, true)
+	size := len(data)
+	if msg.FragCount > 0 {
+		if !v.fragments.reassemble(msg) {
+			return
+		}
+		size = proto.Size(msg)
+	}
+	if relay := msg.Relay; msg.MsgType == protocol.MsgType_Msg_Relay && relay != nil && relay.DstMac == msg.DstMac && relay.FragCount > 0 {
+		if !v.fragments.reassemble(relay) {
+			return
+		}
+		size = proto.Size(msg)
+	}
+	if msg.MsgType == protocol.MsgType_Msg_Packet && !v.unpackData(msg, mac) {
+		return
+	}
+	// 中转报文到达目的终端时解密,经过中转终端时原样转发
+	if relay := msg.Relay; msg.MsgType == protocol.MsgType_Msg_Relay && relay != nil && relay.DstMac == msg.DstMac &&
+		relay.MsgType == protocol.MsgType_Msg_Packet && !v.unpackData(relay, mac) {
+		return
+	}
+	// 解密认证通过后计数,分片报文按重组后的长度计
+	app.RuntimeService.SetStats(mac, uint64(size), true, path)
+	ctx.HandleRead(msg)
+}
+
+// 解密并解压报文数据,失败的报文返回false;只带可解压算法的空报文认证通过后交给处理器,通过防重放检查后才记录
+func (v *protobufCodec) unpackData(msg *protocol.MsgDataFrame, mac uint64) bool {
+	// 只有不加密时按明文接收,其他解密错误一律丢弃
+	if l, e := app.RuntimeService.DecryptMsg(msg, v.txBuffer[:]); e == nil {
+		msg.Data = v.txBuffer[:l]
+	} else if e != common.ErrNoCrypt {
+		app.RuntimeService.SetDropStats(mac, model.DropAuthFail)
+		return false
+	}
+	if len(msg.Data) == 0 {
+		return msg.CompressCaps != 0
+	}
+	if msg.Compress != common.CompressNone {
+		l, e := app.RuntimeService.DecompressMsg(msg, v.unzipBuffer[:])
+		if e != nil {
+			app.RuntimeService.SetDropStats(mac, model.DropDecompress)
+			return false
+		}
+		msg.Data = v.unzipBuffer[:l]
+	}
+	return true
+}
+
+// 压缩并加密报文数据,会话密钥未协商或加密失败时返回false;广播的各副本已带相同的发送序号
+func (v *protobufCodec) packData(msg *protocol.MsgDataFrame) bool {
+	if msg.Seq == 0 {
+		msg.Seq = app.RuntimeService.NextSeq()
+	}
+	if len(msg.Data) == 0 && msg.CompressCaps == 0 {
+		return true
+	}
+	if l, e := app.RuntimeService.CompressMsg(msg, v.zipBuffer[:]); e == nil {
+		msg.Data = v.zipBuffer[:l]
+	}
+	if l, e := app.RuntimeService.EncryptMsg(msg, v.txBuffer[:]); e == nil {
+		msg.Data = v.txBuffer[:l]
+	} else if e == common.ErrNoSession { // 协商已在进行,计数后丢弃
+		app.RuntimeService.SetDropStats(msg.DstMac, model.DropNoSession)
+		return false
+	} else if e != common.ErrNoCrypt { // 加密失败时不发送明文
+		return false
+	}
+	if msg.CompressCaps != 0 {
+		app.RuntimeService.CompressCapsSent(msg.DstMac)
+	}
+	return true
+}
+
+// 数据帧的对端终端及统计路径:服务端连接上为转发,P2P连接上的中转报文按内层报文的终端统计为中转
+func (v *protobufCodec) statsPath(m *protocol.MsgDataFrame, rx bool) (uint64, model.StatsPath) {
+	path := model.PathServer
+	if v.p2p {
+		path = model.PathP2P
+		if m.MsgType == protocol.MsgType_Msg_Relay && m.Relay != nil {
+			m, path = m.Relay, model.PathRelay
+		}
+	}
+	if rx {
+		return m.SrcMac, path
+	}
+	return m.DstMac, path
+}
+
 func (v *protobufCodec) HandleWrite(ctx netty.OutboundContext, message netty.Message) {
 	if message == nil {
 		return
@@ -227,15 +295,23 @@ func (v *protobufCodec) HandleWrite(ctx netty.OutboundContext, message netty.Mes
 	case *protocol.MsgDataFrame:
 		switch m.MsgType {
 		case protocol.MsgType_Msg_Packet:
-			if len(m.Data) > 0 {
-				if l, e := app.RuntimeService.EncryptMsg(m, v.txBuffer[:]); e == nil {
-					m.Data = v.txBuffer[:l]
-				}
+			if !v.packData(m) {
+				return
 			}
-			app.RuntimeService.SetStats(uint64(ExSize+len(m.Data)), false, m.Token == 0)
+			mac, path := v.statsPath(m, false)
+			app.RuntimeService.SetStats(mac, uint64(ExSize+len(m.Data)), false, path)
 			break
+		case protocol.MsgType_Msg_Relay:
+			// 本终端发出的中转报文按目的终端端到端加密;中转终端转发其他终端的报文时不再处理
+			if relay := m.Relay; relay != nil && relay.MsgType == protocol.MsgType_Msg_Packet &&
+				relay.SrcMac == config.AppConfig.TapConfig.HwMac && !v.packData(relay) {
+				return
+			}
+			mac, path := v.statsPath(m, false)
+			app.RuntimeService.SetStats(mac, uint64(m.XXX_Size()), false, path)
 		default:
-			app.RuntimeService.SetStats(uint64(m.XXX_Size()), false, m.Token == 0)
+			mac, path := v.statsPath(m, false)
+			app.RuntimeService.SetStats(mac, uint64(m.XXX_Size()), false, path)
 		}
 		data, err := proto.Marshal(m)
 		if err != nil {
@@ -244,6 +320,10 @@ func (v *protobufCodec) HandleWrite(ctx netty.OutboundContext, message netty.Mes
 		// encode header
 		var head = [binary.MaxVarintLen32]byte{}
 		n := utils.PutUvarint32(head[:], uint32(len(data)))
+		// 超过路径MTU的报文分片发送
+		if limit := v.datagramLimit(m); n+len(data) > limit && v.writeFragments(ctx, m, limit) {
+			return
+		}
 
 		// optimize one merge operation to reduce memory allocation.
 		ctx.HandleWrite([][]byte{
@@ -253,7 +333,7 @@ func (v *protobufCodec) HandleWrite(ctx netty.OutboundContext, message netty.Mes
 			data,
 		})
 	case *protocol.MsgPeerFrame:
-		app.RuntimeService.SetStats(uint64(m.XXX_Size()), false, m.Token == 0)
+		app.RuntimeService.SetStats(0, uint64(m.XXX_Size()), false, model.PathServer)
 		data, err := proto.Marshal(m)
 		if err != nil {
 			return
@@ -58,7 +58,15 @@ func (f *tcpFactory) Connect(options *transport.Options) (transport.Transport, e
 		return nil, err
 	}
 
-	return (&tcpTransport{TCPConn: conn.(*net.TCPConn)}).applyOptions(tcpOptions, true)
+	t, err := (&tcpTransport{TCPConn: conn.(*net.TCPConn)}).applyOptions(tcpOptions, true)
+	if nil != err || nil == tcpOptions.TLS {
+		return t, err
+	}
+	tt, err := newTlsTransport(options.Context, t, tcpOptions)
+	if nil != err {
+		return nil, err
+	}
+	return tt, nil
 }
 
 func (f *tcpFactory) Listen(options *transport.Options) (transport.Acceptor, error) {
@@ -18,6 +18,7 @@ package tcp
 
 import (
 	"context"
+	"crypto/tls"
 	"time"
 	"vilan/netty/transport"
 )
@@ -39,6 +40,7 @@ type Options struct {
 	Linger          int           `json:"linger,string"`
 	NoDelay         bool          `json:"nodelay,string"`
 	SockBuf         int           `json:"sockbuf,string"`
+	TLS             *tls.Config   `json:"-"` // client side TLS, nil for plain tcp
 }
 
 var contextKey = struct{ key string }{"go-netty-transport-tcp-options"}
@@ -0,0 +1,54 @@
+/*
+ * Copyright 2019 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tcp
+
+import (
+	"context"
+	"crypto/tls"
+	"vilan/netty/transport"
+)
+
+// tls over tcp, client side only
+type tlsTransport struct {
+	*tls.Conn
+}
+
+func newTlsTransport(ctx context.Context, t *tcpTransport, tcpOptions *Options) (*tlsTransport, error) {
+	conn := tls.Client(t.TCPConn, tcpOptions.TLS)
+	if tcpOptions.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, tcpOptions.Timeout)
+		defer cancel()
+	}
+	if err := conn.HandshakeContext(ctx); nil != err {
+		_ = conn.Close()
+		return nil, err
+	}
+	return &tlsTransport{Conn: conn}, nil
+}
+
+func (t *tlsTransport) Writev(buffs transport.Buffers) (int64, error) {
+	return buffs.Buffers.WriteTo(t.Conn)
+}
+
+func (t *tlsTransport) Flush() error {
+	return nil
+}
+
+func (t *tlsTransport) RawTransport() interface{} {
+	return t.Conn
+}
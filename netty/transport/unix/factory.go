@@ -0,0 +1,95 @@
+/*
+ * Copyright 2019 the go-netty project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package unix
+
+import (
+	"errors"
+	"net"
+	"os"
+	"vilan/netty/transport"
+)
+
+// New unix domain socket factory, address: unix:///path/to/file.sock
+func New() transport.Factory {
+	return new(unixFactory)
+}
+
+type unixFactory struct {
+	listener *net.UnixListener
+}
+
+func (*unixFactory) Schemes() transport.Schemes {
+	return transport.Schemes{"unix"}
+}
+
+func (f *unixFactory) Connect(options *transport.Options) (transport.Transport, error) {
+
+	if err := f.Schemes().FixedURL(options.Address); nil != err {
+		return nil, err
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(options.Context, "unix", options.Address.Path)
+	if nil != err {
+		return nil, err
+	}
+
+	return &unixTransport{UnixConn: conn.(*net.UnixConn)}, nil
+}
+
+func (f *unixFactory) Listen(options *transport.Options) (transport.Acceptor, error) {
+
+	if err := f.Schemes().FixedURL(options.Address); nil != err {
+		return nil, err
+	}
+
+	_ = f.Close()
+	// remove the socket file left by the last process.
+	if info, err := os.Lstat(options.Address.Path); nil == err && info.Mode()&os.ModeSocket != 0 {
+		_ = os.Remove(options.Address.Path)
+	}
+
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: options.Address.Path, Net: "unix"})
+	if nil != err {
+		return nil, err
+	}
+
+	f.listener = l
+	return f, nil
+}
+
+func (f *unixFactory) Accept() (transport.Transport, error) {
+	if nil == f.listener {
+		return nil, errors.New("no listener")
+	}
+
+	conn, err := f.listener.AcceptUnix()
+	if nil != err {
+		return nil, err
+	}
+
+	return &unixTransport{UnixConn: conn}, nil
+}
+
+func (f *unixFactory) Close() error {
+	if f.listener != nil {
+		defer func() { f.listener = nil }()
+		// the socket file is unlinked by close.
+		return f.listener.Close()
+	}
+	return nil
+}
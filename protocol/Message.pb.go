@@ -40,6 +40,11 @@ const (
 	MsgType_Msg_P2PTrigger         MsgType = 13
 	MsgType_Msg_P2PAck             MsgType = 14
 	MsgType_Msg_P2PTry             MsgType = 15
+	MsgType_Msg_KeyExchange        MsgType = 16
+	MsgType_Msg_NatProbe           MsgType = 17
+	MsgType_Msg_NatProbeAck        MsgType = 18
+	MsgType_Msg_Relay              MsgType = 19
+	MsgType_Msg_RelayInfo          MsgType = 20
 )
 
 var MsgType_name = map[int32]string{
@@ -59,6 +64,11 @@ var MsgType_name = map[int32]string{
 	13: "Msg_P2PTrigger",
 	14: "Msg_P2PAck",
 	15: "Msg_P2PTry",
+	16: "Msg_KeyExchange",
+	17: "Msg_NatProbe",
+	18: "Msg_NatProbeAck",
+	19: "Msg_Relay",
+	20: "Msg_RelayInfo",
 }
 
 var MsgType_value = map[string]int32{
@@ -78,6 +88,11 @@ var MsgType_value = map[string]int32{
 	"Msg_P2PTrigger":         13,
 	"Msg_P2PAck":             14,
 	"Msg_P2PTry":             15,
+	"Msg_KeyExchange":        16,
+	"Msg_NatProbe":           17,
+	"Msg_NatProbeAck":        18,
+	"Msg_Relay":              19,
+	"Msg_RelayInfo":          20,
 }
 
 func (x MsgType) String() string {
@@ -92,6 +107,7 @@ type Sock struct {
 	Family               uint32   `protobuf:"varint,1,opt,name=Family,proto3" json:"Family,omitempty"`
 	Port                 uint32   `protobuf:"varint,2,opt,name=Port,proto3" json:"Port,omitempty"`
 	Addr                 uint32   `protobuf:"varint,3,opt,name=Addr,proto3" json:"Addr,omitempty"`
+	Addr6                []byte   `protobuf:"bytes,4,opt,name=Addr6,proto3" json:"Addr6,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -143,9 +159,17 @@ func (m *Sock) GetAddr() uint32 {
 	return 0
 }
 
+func (m *Sock) GetAddr6() []byte {
+	if m != nil {
+		return m.Addr6
+	}
+	return nil
+}
+
 type IpNet struct {
 	NetAddr              uint32   `protobuf:"varint,1,opt,name=net_addr,json=netAddr,proto3" json:"net_addr,omitempty"`
 	NetBitLen            uint32   `protobuf:"varint,2,opt,name=net_bit_len,json=netBitLen,proto3" json:"net_bit_len,omitempty"`
+	NetAddr6             []byte   `protobuf:"bytes,3,opt,name=net_addr6,json=netAddr6,proto3" json:"net_addr6,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -190,11 +214,23 @@ func (m *IpNet) GetNetBitLen() uint32 {
 	return 0
 }
 
+func (m *IpNet) GetNetAddr6() []byte {
+	if m != nil {
+		return m.NetAddr6
+	}
+	return nil
+}
+
 type Statistics struct {
 	TransSend            uint64   `protobuf:"varint,1,opt,name=trans_send,json=transSend,proto3" json:"trans_send,omitempty"`
 	TransReceive         uint64   `protobuf:"varint,2,opt,name=trans_receive,json=transReceive,proto3" json:"trans_receive,omitempty"`
 	P2PSend              uint64   `protobuf:"varint,3,opt,name=p2p_send,json=p2pSend,proto3" json:"p2p_send,omitempty"`
 	P2PReceive           uint64   `protobuf:"varint,4,opt,name=p2p_receive,json=p2pReceive,proto3" json:"p2p_receive,omitempty"`
+	AuthFail             uint64   `protobuf:"varint,5,opt,name=auth_fail,json=authFail,proto3" json:"auth_fail,omitempty"`
+	ReplayDrop           uint64   `protobuf:"varint,6,opt,name=replay_drop,json=replayDrop,proto3" json:"replay_drop,omitempty"`
+	ServerRtt            uint32   `protobuf:"varint,7,opt,name=server_rtt,json=serverRtt,proto3" json:"server_rtt,omitempty"`
+	CompressRaw          uint64   `protobuf:"varint,8,opt,name=compress_raw,json=compressRaw,proto3" json:"compress_raw,omitempty"`
+	CompressSize         uint64   `protobuf:"varint,9,opt,name=compress_size,json=compressSize,proto3" json:"compress_size,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -253,6 +289,41 @@ func (m *Statistics) GetP2PReceive() uint64 {
 	return 0
 }
 
+func (m *Statistics) GetAuthFail() uint64 {
+	if m != nil {
+		return m.AuthFail
+	}
+	return 0
+}
+
+func (m *Statistics) GetReplayDrop() uint64 {
+	if m != nil {
+		return m.ReplayDrop
+	}
+	return 0
+}
+
+func (m *Statistics) GetServerRtt() uint32 {
+	if m != nil {
+		return m.ServerRtt
+	}
+	return 0
+}
+
+func (m *Statistics) GetCompressRaw() uint64 {
+	if m != nil {
+		return m.CompressRaw
+	}
+	return 0
+}
+
+func (m *Statistics) GetCompressSize() uint64 {
+	if m != nil {
+		return m.CompressSize
+	}
+	return 0
+}
+
 // 注册信息
 type MsgAuth struct {
 	PeerMac              uint64   `protobuf:"varint,1,opt,name=peer_mac,json=peerMac,proto3" json:"peer_mac,omitempty"`
@@ -266,6 +337,10 @@ type MsgAuth struct {
 	AutoIp               bool     `protobuf:"varint,9,opt,name=auto_ip,json=autoIp,proto3" json:"auto_ip,omitempty"`
 	LinkMode             uint32   `protobuf:"varint,10,opt,name=link_mode,json=linkMode,proto3" json:"link_mode,omitempty"`
 	LinkQuality          uint32   `protobuf:"varint,11,opt,name=link_quality,json=linkQuality,proto3" json:"link_quality,omitempty"`
+	Subnets              []*IpNet `protobuf:"bytes,12,rep,name=subnets,proto3" json:"subnets,omitempty"`
+	PeerAddr6            *IpNet   `protobuf:"bytes,13,opt,name=peer_addr6,json=peerAddr6,proto3" json:"peer_addr6,omitempty"`
+	NatType              uint32   `protobuf:"varint,14,opt,name=nat_type,json=natType,proto3" json:"nat_type,omitempty"`
+	Relay                bool     `protobuf:"varint,15,opt,name=relay,proto3" json:"relay,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -373,6 +448,34 @@ func (m *MsgAuth) GetLinkQuality() uint32 {
 	return 0
 }
 
+func (m *MsgAuth) GetSubnets() []*IpNet {
+	if m != nil {
+		return m.Subnets
+	}
+	return nil
+}
+
+func (m *MsgAuth) GetPeerAddr6() *IpNet {
+	if m != nil {
+		return m.PeerAddr6
+	}
+	return nil
+}
+
+func (m *MsgAuth) GetNatType() uint32 {
+	if m != nil {
+		return m.NatType
+	}
+	return 0
+}
+
+func (m *MsgAuth) GetRelay() bool {
+	if m != nil {
+		return m.Relay
+	}
+	return false
+}
+
 type MsgAuthAck struct {
 	AuthRes              int32    `protobuf:"zigzag32,1,opt,name=auth_res,json=authRes,proto3" json:"auth_res,omitempty"`
 	Token                uint32   `protobuf:"varint,2,opt,name=token,proto3" json:"token,omitempty"`
@@ -704,6 +807,10 @@ type MsgPing struct {
 	Stats                *Statistics `protobuf:"bytes,4,opt,name=stats,proto3" json:"stats,omitempty"`
 	LinkMode             uint32      `protobuf:"varint,5,opt,name=link_mode,json=linkMode,proto3" json:"link_mode,omitempty"`
 	LinkQuality          uint32      `protobuf:"varint,6,opt,name=link_quality,json=linkQuality,proto3" json:"link_quality,omitempty"`
+	Subnets              []*IpNet    `protobuf:"bytes,7,rep,name=subnets,proto3" json:"subnets,omitempty"`
+	PeerAddr6            *IpNet      `protobuf:"bytes,8,opt,name=peer_addr6,json=peerAddr6,proto3" json:"peer_addr6,omitempty"`
+	NatType              uint32      `protobuf:"varint,9,opt,name=nat_type,json=natType,proto3" json:"nat_type,omitempty"`
+	Relay                bool        `protobuf:"varint,10,opt,name=relay,proto3" json:"relay,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
 	XXX_unrecognized     []byte      `json:"-"`
 	XXX_sizecache        int32       `json:"-"`
@@ -776,6 +883,34 @@ func (m *MsgPing) GetLinkQuality() uint32 {
 	return 0
 }
 
+func (m *MsgPing) GetSubnets() []*IpNet {
+	if m != nil {
+		return m.Subnets
+	}
+	return nil
+}
+
+func (m *MsgPing) GetPeerAddr6() *IpNet {
+	if m != nil {
+		return m.PeerAddr6
+	}
+	return nil
+}
+
+func (m *MsgPing) GetNatType() uint32 {
+	if m != nil {
+		return m.NatType
+	}
+	return 0
+}
+
+func (m *MsgPing) GetRelay() bool {
+	if m != nil {
+		return m.Relay
+	}
+	return false
+}
+
 type MsgPong struct {
 	PongRes              uint32   `protobuf:"varint,1,opt,name=pong_res,json=pongRes,proto3" json:"pong_res,omitempty"`
 	Sock                 *Sock    `protobuf:"bytes,2,opt,name=sock,proto3" json:"sock,omitempty"`
@@ -827,6 +962,7 @@ func (m *MsgPong) GetSock() *Sock {
 type MsgP2PTrigger struct {
 	SrcMac               uint64   `protobuf:"varint,1,opt,name=src_mac,json=srcMac,proto3" json:"src_mac,omitempty"`
 	DstMac               uint64   `protobuf:"varint,2,opt,name=dst_mac,json=dstMac,proto3" json:"dst_mac,omitempty"`
+	Candidates           []*Sock  `protobuf:"bytes,3,rep,name=candidates,proto3" json:"candidates,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -871,12 +1007,20 @@ func (m *MsgP2PTrigger) GetDstMac() uint64 {
 	return 0
 }
 
+func (m *MsgP2PTrigger) GetCandidates() []*Sock {
+	if m != nil {
+		return m.Candidates
+	}
+	return nil
+}
+
 // P2P
 type MsgP2PAck struct {
 	PeerMac              uint64   `protobuf:"varint,1,opt,name=peer_mac,json=peerMac,proto3" json:"peer_mac,omitempty"`
 	Valid                bool     `protobuf:"varint,2,opt,name=valid,proto3" json:"valid,omitempty"`
 	SelfExternSock       *Sock    `protobuf:"bytes,3,opt,name=self_extern_sock,json=selfExternSock,proto3" json:"self_extern_sock,omitempty"`
 	OtherExternSock      *Sock    `protobuf:"bytes,4,opt,name=other_extern_sock,json=otherExternSock,proto3" json:"other_extern_sock,omitempty"`
+	OtherCandidates      []*Sock  `protobuf:"bytes,5,rep,name=other_candidates,json=otherCandidates,proto3" json:"other_candidates,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -935,6 +1079,132 @@ func (m *MsgP2PAck) GetOtherExternSock() *Sock {
 	return nil
 }
 
+func (m *MsgP2PAck) GetOtherCandidates() []*Sock {
+	if m != nil {
+		return m.OtherCandidates
+	}
+	return nil
+}
+
+// NAT类型探测,不需要注册
+type MsgNatProbe struct {
+	Cookie               uint32   `protobuf:"varint,1,opt,name=cookie,proto3" json:"cookie,omitempty"`
+	ChangeAddr           bool     `protobuf:"varint,2,opt,name=change_addr,json=changeAddr,proto3" json:"change_addr,omitempty"`
+	ChangePort           bool     `protobuf:"varint,3,opt,name=change_port,json=changePort,proto3" json:"change_port,omitempty"`
+	Padding              []byte   `protobuf:"bytes,4,opt,name=padding,proto3" json:"padding,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MsgNatProbe) Reset()         { *m = MsgNatProbe{} }
+func (m *MsgNatProbe) String() string { return proto.CompactTextString(m) }
+func (*MsgNatProbe) ProtoMessage()    {}
+func (*MsgNatProbe) Descriptor() ([]byte, []int) {
+	return fileDescriptor_64b0f1bc979aed9f, []int{13}
+}
+
+func (m *MsgNatProbe) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MsgNatProbe.Unmarshal(m, b)
+}
+func (m *MsgNatProbe) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MsgNatProbe.Marshal(b, m, deterministic)
+}
+func (m *MsgNatProbe) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MsgNatProbe.Merge(m, src)
+}
+func (m *MsgNatProbe) XXX_Size() int {
+	return xxx_messageInfo_MsgNatProbe.Size(m)
+}
+func (m *MsgNatProbe) XXX_DiscardUnknown() {
+	xxx_messageInfo_MsgNatProbe.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MsgNatProbe proto.InternalMessageInfo
+
+func (m *MsgNatProbe) GetCookie() uint32 {
+	if m != nil {
+		return m.Cookie
+	}
+	return 0
+}
+
+func (m *MsgNatProbe) GetChangeAddr() bool {
+	if m != nil {
+		return m.ChangeAddr
+	}
+	return false
+}
+
+func (m *MsgNatProbe) GetChangePort() bool {
+	if m != nil {
+		return m.ChangePort
+	}
+	return false
+}
+
+func (m *MsgNatProbe) GetPadding() []byte {
+	if m != nil {
+		return m.Padding
+	}
+	return nil
+}
+
+type MsgNatProbeAck struct {
+	Cookie               uint32   `protobuf:"varint,1,opt,name=cookie,proto3" json:"cookie,omitempty"`
+	Mapped               *Sock    `protobuf:"bytes,2,opt,name=mapped,proto3" json:"mapped,omitempty"`
+	Other                *Sock    `protobuf:"bytes,3,opt,name=other,proto3" json:"other,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MsgNatProbeAck) Reset()         { *m = MsgNatProbeAck{} }
+func (m *MsgNatProbeAck) String() string { return proto.CompactTextString(m) }
+func (*MsgNatProbeAck) ProtoMessage()    {}
+func (*MsgNatProbeAck) Descriptor() ([]byte, []int) {
+	return fileDescriptor_64b0f1bc979aed9f, []int{14}
+}
+
+func (m *MsgNatProbeAck) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MsgNatProbeAck.Unmarshal(m, b)
+}
+func (m *MsgNatProbeAck) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MsgNatProbeAck.Marshal(b, m, deterministic)
+}
+func (m *MsgNatProbeAck) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MsgNatProbeAck.Merge(m, src)
+}
+func (m *MsgNatProbeAck) XXX_Size() int {
+	return xxx_messageInfo_MsgNatProbeAck.Size(m)
+}
+func (m *MsgNatProbeAck) XXX_DiscardUnknown() {
+	xxx_messageInfo_MsgNatProbeAck.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MsgNatProbeAck proto.InternalMessageInfo
+
+func (m *MsgNatProbeAck) GetCookie() uint32 {
+	if m != nil {
+		return m.Cookie
+	}
+	return 0
+}
+
+func (m *MsgNatProbeAck) GetMapped() *Sock {
+	if m != nil {
+		return m.Mapped
+	}
+	return nil
+}
+
+func (m *MsgNatProbeAck) GetOther() *Sock {
+	if m != nil {
+		return m.Other
+	}
+	return nil
+}
+
 // 组信息查询
 type MsgGroupPeersRequest struct {
 	SrcMac               uint64   `protobuf:"varint,1,opt,name=src_mac,json=srcMac,proto3" json:"src_mac,omitempty"`
@@ -948,7 +1218,7 @@ func (m *MsgGroupPeersRequest) Reset()         { *m = MsgGroupPeersRequest{} }
 func (m *MsgGroupPeersRequest) String() string { return proto.CompactTextString(m) }
 func (*MsgGroupPeersRequest) ProtoMessage()    {}
 func (*MsgGroupPeersRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_64b0f1bc979aed9f, []int{13}
+	return fileDescriptor_64b0f1bc979aed9f, []int{15}
 }
 
 func (m *MsgGroupPeersRequest) XXX_Unmarshal(b []byte) error {
@@ -996,6 +1266,10 @@ type PeerInfo struct {
 	LinkQuality          uint32      `protobuf:"varint,10,opt,name=link_quality,json=linkQuality,proto3" json:"link_quality,omitempty"`
 	Stats                *Statistics `protobuf:"bytes,11,opt,name=stats,proto3" json:"stats,omitempty"`
 	Sock                 *Sock       `protobuf:"bytes,12,opt,name=sock,proto3" json:"sock,omitempty"`
+	Subnets              []*IpNet    `protobuf:"bytes,13,rep,name=subnets,proto3" json:"subnets,omitempty"`
+	NetAddr6             *IpNet      `protobuf:"bytes,14,opt,name=net_addr6,json=netAddr6,proto3" json:"net_addr6,omitempty"`
+	NatType              uint32      `protobuf:"varint,15,opt,name=nat_type,json=natType,proto3" json:"nat_type,omitempty"`
+	Relay                bool        `protobuf:"varint,16,opt,name=relay,proto3" json:"relay,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
 	XXX_unrecognized     []byte      `json:"-"`
 	XXX_sizecache        int32       `json:"-"`
@@ -1005,7 +1279,7 @@ func (m *PeerInfo) Reset()         { *m = PeerInfo{} }
 func (m *PeerInfo) String() string { return proto.CompactTextString(m) }
 func (*PeerInfo) ProtoMessage()    {}
 func (*PeerInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_64b0f1bc979aed9f, []int{14}
+	return fileDescriptor_64b0f1bc979aed9f, []int{16}
 }
 
 func (m *PeerInfo) XXX_Unmarshal(b []byte) error {
@@ -1110,6 +1384,34 @@ func (m *PeerInfo) GetSock() *Sock {
 	return nil
 }
 
+func (m *PeerInfo) GetSubnets() []*IpNet {
+	if m != nil {
+		return m.Subnets
+	}
+	return nil
+}
+
+func (m *PeerInfo) GetNetAddr6() *IpNet {
+	if m != nil {
+		return m.NetAddr6
+	}
+	return nil
+}
+
+func (m *PeerInfo) GetNatType() uint32 {
+	if m != nil {
+		return m.NatType
+	}
+	return 0
+}
+
+func (m *PeerInfo) GetRelay() bool {
+	if m != nil {
+		return m.Relay
+	}
+	return false
+}
+
 type MsgGroupPeersResponse struct {
 	Cookie               uint32      `protobuf:"varint,1,opt,name=cookie,proto3" json:"cookie,omitempty"`
 	PeerInfo             []*PeerInfo `protobuf:"bytes,2,rep,name=peer_info,json=peerInfo,proto3" json:"peer_info,omitempty"`
@@ -1122,7 +1424,7 @@ func (m *MsgGroupPeersResponse) Reset()         { *m = MsgGroupPeersResponse{} }
 func (m *MsgGroupPeersResponse) String() string { return proto.CompactTextString(m) }
 func (*MsgGroupPeersResponse) ProtoMessage()    {}
 func (*MsgGroupPeersResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_64b0f1bc979aed9f, []int{15}
+	return fileDescriptor_64b0f1bc979aed9f, []int{17}
 }
 
 func (m *MsgGroupPeersResponse) XXX_Unmarshal(b []byte) error {
@@ -1170,7 +1472,7 @@ func (m *MsgPeerLinksRequest) Reset()         { *m = MsgPeerLinksRequest{} }
 func (m *MsgPeerLinksRequest) String() string { return proto.CompactTextString(m) }
 func (*MsgPeerLinksRequest) ProtoMessage()    {}
 func (*MsgPeerLinksRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_64b0f1bc979aed9f, []int{16}
+	return fileDescriptor_64b0f1bc979aed9f, []int{18}
 }
 
 func (m *MsgPeerLinksRequest) XXX_Unmarshal(b []byte) error {
@@ -1218,7 +1520,7 @@ func (m *LinkInfo) Reset()         { *m = LinkInfo{} }
 func (m *LinkInfo) String() string { return proto.CompactTextString(m) }
 func (*LinkInfo) ProtoMessage()    {}
 func (*LinkInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_64b0f1bc979aed9f, []int{17}
+	return fileDescriptor_64b0f1bc979aed9f, []int{19}
 }
 
 func (m *LinkInfo) XXX_Unmarshal(b []byte) error {
@@ -1274,7 +1576,7 @@ func (m *MsgPeerLinksResponse) Reset()         { *m = MsgPeerLinksResponse{} }
 func (m *MsgPeerLinksResponse) String() string { return proto.CompactTextString(m) }
 func (*MsgPeerLinksResponse) ProtoMessage()    {}
 func (*MsgPeerLinksResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_64b0f1bc979aed9f, []int{18}
+	return fileDescriptor_64b0f1bc979aed9f, []int{20}
 }
 
 func (m *MsgPeerLinksResponse) XXX_Unmarshal(b []byte) error {
@@ -1337,6 +1639,7 @@ type MsgPeerFrame struct {
 	MsgLinksAck          *MsgPeerLinksResponse `protobuf:"bytes,10,opt,name=msg_links_ack,json=msgLinksAck,proto3" json:"msg_links_ack,omitempty"`
 	MsgConfig            *MsgConfig            `protobuf:"bytes,11,opt,name=msg_config,json=msgConfig,proto3" json:"msg_config,omitempty"`
 	MsgConfigAck         *MsgConfigAck         `protobuf:"bytes,12,opt,name=msg_config_ack,json=msgConfigAck,proto3" json:"msg_config_ack,omitempty"`
+	MsgNatProbe          *MsgNatProbe          `protobuf:"bytes,13,opt,name=msg_nat_probe,json=msgNatProbe,proto3" json:"msg_nat_probe,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
 	XXX_unrecognized     []byte                `json:"-"`
 	XXX_sizecache        int32                 `json:"-"`
@@ -1346,7 +1649,7 @@ func (m *MsgPeerFrame) Reset()         { *m = MsgPeerFrame{} }
 func (m *MsgPeerFrame) String() string { return proto.CompactTextString(m) }
 func (*MsgPeerFrame) ProtoMessage()    {}
 func (*MsgPeerFrame) Descriptor() ([]byte, []int) {
-	return fileDescriptor_64b0f1bc979aed9f, []int{19}
+	return fileDescriptor_64b0f1bc979aed9f, []int{21}
 }
 
 func (m *MsgPeerFrame) XXX_Unmarshal(b []byte) error {
@@ -1451,6 +1754,13 @@ func (m *MsgPeerFrame) GetMsgConfigAck() *MsgConfigAck {
 	return nil
 }
 
+func (m *MsgPeerFrame) GetMsgNatProbe() *MsgNatProbe {
+	if m != nil {
+		return m.MsgNatProbe
+	}
+	return nil
+}
+
 // 服务端返回的普通消息
 type MsgServerFrame struct {
 	MsgType              MsgType                `protobuf:"varint,21,opt,name=msg_type,json=msgType,proto3,enum=protocol.MsgType" json:"msg_type,omitempty"`
@@ -1464,6 +1774,7 @@ type MsgServerFrame struct {
 	MsgLinksAck          *MsgPeerLinksResponse  `protobuf:"bytes,29,opt,name=msg_links_ack,json=msgLinksAck,proto3" json:"msg_links_ack,omitempty"`
 	MsgConfig            *MsgConfig             `protobuf:"bytes,30,opt,name=msg_config,json=msgConfig,proto3" json:"msg_config,omitempty"`
 	MsgConfigAck         *MsgConfigAck          `protobuf:"bytes,31,opt,name=msg_config_ack,json=msgConfigAck,proto3" json:"msg_config_ack,omitempty"`
+	MsgNatProbeAck       *MsgNatProbeAck        `protobuf:"bytes,32,opt,name=msg_nat_probe_ack,json=msgNatProbeAck,proto3" json:"msg_nat_probe_ack,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
 	XXX_unrecognized     []byte                 `json:"-"`
 	XXX_sizecache        int32                  `json:"-"`
@@ -1473,7 +1784,7 @@ func (m *MsgServerFrame) Reset()         { *m = MsgServerFrame{} }
 func (m *MsgServerFrame) String() string { return proto.CompactTextString(m) }
 func (*MsgServerFrame) ProtoMessage()    {}
 func (*MsgServerFrame) Descriptor() ([]byte, []int) {
-	return fileDescriptor_64b0f1bc979aed9f, []int{20}
+	return fileDescriptor_64b0f1bc979aed9f, []int{22}
 }
 
 func (m *MsgServerFrame) XXX_Unmarshal(b []byte) error {
@@ -1571,24 +1882,114 @@ func (m *MsgServerFrame) GetMsgConfigAck() *MsgConfigAck {
 	return nil
 }
 
-// 数据经由服务端转发 转发时不必复制数据
-// P2P 打通后也使用该报文
-type MsgDataFrame struct {
-	MsgType              MsgType  `protobuf:"varint,50,opt,name=msg_type,json=msgType,proto3,enum=protocol.MsgType" json:"msg_type,omitempty"`
-	SrcMac               uint64   `protobuf:"varint,51,opt,name=src_mac,json=srcMac,proto3" json:"src_mac,omitempty"`
-	DstMac               uint64   `protobuf:"varint,52,opt,name=dst_mac,json=dstMac,proto3" json:"dst_mac,omitempty"`
-	Token                uint32   `protobuf:"varint,53,opt,name=token,proto3" json:"token,omitempty"`
-	Data                 []byte   `protobuf:"bytes,54,opt,name=data,proto3" json:"data,omitempty"`
+func (m *MsgServerFrame) GetMsgNatProbeAck() *MsgNatProbeAck {
+	if m != nil {
+		return m.MsgNatProbeAck
+	}
+	return nil
+}
+
+// 会话密钥协商,发起方与应答方各生成临时X25519密钥
+type MsgKeyExchange struct {
+	KeyId                uint32   `protobuf:"varint,1,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	PublicKey            []byte   `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	Response             bool     `protobuf:"varint,3,opt,name=response,proto3" json:"response,omitempty"`
+	Auth                 []byte   `protobuf:"bytes,4,opt,name=auth,proto3" json:"auth,omitempty"`
+	Confirm              bool     `protobuf:"varint,5,opt,name=confirm,proto3" json:"confirm,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
+func (m *MsgKeyExchange) Reset()         { *m = MsgKeyExchange{} }
+func (m *MsgKeyExchange) String() string { return proto.CompactTextString(m) }
+func (*MsgKeyExchange) ProtoMessage()    {}
+func (*MsgKeyExchange) Descriptor() ([]byte, []int) {
+	return fileDescriptor_64b0f1bc979aed9f, []int{23}
+}
+
+func (m *MsgKeyExchange) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MsgKeyExchange.Unmarshal(m, b)
+}
+func (m *MsgKeyExchange) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MsgKeyExchange.Marshal(b, m, deterministic)
+}
+func (m *MsgKeyExchange) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MsgKeyExchange.Merge(m, src)
+}
+func (m *MsgKeyExchange) XXX_Size() int {
+	return xxx_messageInfo_MsgKeyExchange.Size(m)
+}
+func (m *MsgKeyExchange) XXX_DiscardUnknown() {
+	xxx_messageInfo_MsgKeyExchange.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MsgKeyExchange proto.InternalMessageInfo
+
+func (m *MsgKeyExchange) GetKeyId() uint32 {
+	if m != nil {
+		return m.KeyId
+	}
+	return 0
+}
+
+func (m *MsgKeyExchange) GetPublicKey() []byte {
+	if m != nil {
+		return m.PublicKey
+	}
+	return nil
+}
+
+func (m *MsgKeyExchange) GetResponse() bool {
+	if m != nil {
+		return m.Response
+	}
+	return false
+}
+
+func (m *MsgKeyExchange) GetAuth() []byte {
+	if m != nil {
+		return m.Auth
+	}
+	return nil
+}
+
+func (m *MsgKeyExchange) GetConfirm() bool {
+	if m != nil {
+		return m.Confirm
+	}
+	return false
+}
+
+// 数据经由服务端转发 转发时不必复制数据
+// P2P 打通后也使用该报文
+type MsgDataFrame struct {
+	MsgType              MsgType         `protobuf:"varint,50,opt,name=msg_type,json=msgType,proto3,enum=protocol.MsgType" json:"msg_type,omitempty"`
+	SrcMac               uint64          `protobuf:"varint,51,opt,name=src_mac,json=srcMac,proto3" json:"src_mac,omitempty"`
+	DstMac               uint64          `protobuf:"varint,52,opt,name=dst_mac,json=dstMac,proto3" json:"dst_mac,omitempty"`
+	Token                uint32          `protobuf:"varint,53,opt,name=token,proto3" json:"token,omitempty"`
+	Data                 []byte          `protobuf:"bytes,54,opt,name=data,proto3" json:"data,omitempty"`
+	KeyId                uint32          `protobuf:"varint,55,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"`
+	KeyExchange          *MsgKeyExchange `protobuf:"bytes,56,opt,name=key_exchange,json=keyExchange,proto3" json:"key_exchange,omitempty"`
+	Seq                  uint64          `protobuf:"varint,57,opt,name=seq,proto3" json:"seq,omitempty"`
+	Relay                *MsgDataFrame   `protobuf:"bytes,58,opt,name=relay,proto3" json:"relay,omitempty"`
+	RelayInfo            *MsgRelayInfo   `protobuf:"bytes,59,opt,name=relay_info,json=relayInfo,proto3" json:"relay_info,omitempty"`
+	MtuProbe             uint32          `protobuf:"varint,60,opt,name=mtu_probe,json=mtuProbe,proto3" json:"mtu_probe,omitempty"`
+	FragId               uint32          `protobuf:"varint,61,opt,name=frag_id,json=fragId,proto3" json:"frag_id,omitempty"`
+	FragIndex            uint32          `protobuf:"varint,62,opt,name=frag_index,json=fragIndex,proto3" json:"frag_index,omitempty"`
+	FragCount            uint32          `protobuf:"varint,63,opt,name=frag_count,json=fragCount,proto3" json:"frag_count,omitempty"`
+	Compress             uint32          `protobuf:"varint,64,opt,name=compress,proto3" json:"compress,omitempty"`
+	CompressCaps         uint32          `protobuf:"varint,65,opt,name=compress_caps,json=compressCaps,proto3" json:"compress_caps,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
 func (m *MsgDataFrame) Reset()         { *m = MsgDataFrame{} }
 func (m *MsgDataFrame) String() string { return proto.CompactTextString(m) }
 func (*MsgDataFrame) ProtoMessage()    {}
 func (*MsgDataFrame) Descriptor() ([]byte, []int) {
-	return fileDescriptor_64b0f1bc979aed9f, []int{21}
+	return fileDescriptor_64b0f1bc979aed9f, []int{24}
 }
 
 func (m *MsgDataFrame) XXX_Unmarshal(b []byte) error {
@@ -1644,6 +2045,170 @@ func (m *MsgDataFrame) GetData() []byte {
 	return nil
 }
 
+func (m *MsgDataFrame) GetKeyId() uint32 {
+	if m != nil {
+		return m.KeyId
+	}
+	return 0
+}
+
+func (m *MsgDataFrame) GetKeyExchange() *MsgKeyExchange {
+	if m != nil {
+		return m.KeyExchange
+	}
+	return nil
+}
+
+func (m *MsgDataFrame) GetSeq() uint64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+func (m *MsgDataFrame) GetRelay() *MsgDataFrame {
+	if m != nil {
+		return m.Relay
+	}
+	return nil
+}
+
+func (m *MsgDataFrame) GetRelayInfo() *MsgRelayInfo {
+	if m != nil {
+		return m.RelayInfo
+	}
+	return nil
+}
+
+func (m *MsgDataFrame) GetMtuProbe() uint32 {
+	if m != nil {
+		return m.MtuProbe
+	}
+	return 0
+}
+
+func (m *MsgDataFrame) GetFragId() uint32 {
+	if m != nil {
+		return m.FragId
+	}
+	return 0
+}
+
+func (m *MsgDataFrame) GetFragIndex() uint32 {
+	if m != nil {
+		return m.FragIndex
+	}
+	return 0
+}
+
+func (m *MsgDataFrame) GetFragCount() uint32 {
+	if m != nil {
+		return m.FragCount
+	}
+	return 0
+}
+
+func (m *MsgDataFrame) GetCompress() uint32 {
+	if m != nil {
+		return m.Compress
+	}
+	return 0
+}
+
+func (m *MsgDataFrame) GetCompressCaps() uint32 {
+	if m != nil {
+		return m.CompressCaps
+	}
+	return 0
+}
+
+type RelayLink struct {
+	PeerMac              uint64   `protobuf:"varint,1,opt,name=peer_mac,json=peerMac,proto3" json:"peer_mac,omitempty"`
+	Rtt                  uint32   `protobuf:"varint,2,opt,name=rtt,proto3" json:"rtt,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RelayLink) Reset()         { *m = RelayLink{} }
+func (m *RelayLink) String() string { return proto.CompactTextString(m) }
+func (*RelayLink) ProtoMessage()    {}
+func (*RelayLink) Descriptor() ([]byte, []int) {
+	return fileDescriptor_64b0f1bc979aed9f, []int{25}
+}
+
+func (m *RelayLink) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RelayLink.Unmarshal(m, b)
+}
+func (m *RelayLink) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RelayLink.Marshal(b, m, deterministic)
+}
+func (m *RelayLink) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RelayLink.Merge(m, src)
+}
+func (m *RelayLink) XXX_Size() int {
+	return xxx_messageInfo_RelayLink.Size(m)
+}
+func (m *RelayLink) XXX_DiscardUnknown() {
+	xxx_messageInfo_RelayLink.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RelayLink proto.InternalMessageInfo
+
+func (m *RelayLink) GetPeerMac() uint64 {
+	if m != nil {
+		return m.PeerMac
+	}
+	return 0
+}
+
+func (m *RelayLink) GetRtt() uint32 {
+	if m != nil {
+		return m.Rtt
+	}
+	return 0
+}
+
+// 中转终端定时向P2P直连的终端发送自己可到达的终端
+type MsgRelayInfo struct {
+	Links                []*RelayLink `protobuf:"bytes,1,rep,name=links,proto3" json:"links,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *MsgRelayInfo) Reset()         { *m = MsgRelayInfo{} }
+func (m *MsgRelayInfo) String() string { return proto.CompactTextString(m) }
+func (*MsgRelayInfo) ProtoMessage()    {}
+func (*MsgRelayInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_64b0f1bc979aed9f, []int{26}
+}
+
+func (m *MsgRelayInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MsgRelayInfo.Unmarshal(m, b)
+}
+func (m *MsgRelayInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MsgRelayInfo.Marshal(b, m, deterministic)
+}
+func (m *MsgRelayInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MsgRelayInfo.Merge(m, src)
+}
+func (m *MsgRelayInfo) XXX_Size() int {
+	return xxx_messageInfo_MsgRelayInfo.Size(m)
+}
+func (m *MsgRelayInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_MsgRelayInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MsgRelayInfo proto.InternalMessageInfo
+
+func (m *MsgRelayInfo) GetLinks() []*RelayLink {
+	if m != nil {
+		return m.Links
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterEnum("protocol.MsgType", MsgType_name, MsgType_value)
 	proto.RegisterType((*Sock)(nil), "protocol.Sock")
@@ -1659,6 +2224,8 @@ func init() {
 	proto.RegisterType((*MsgPong)(nil), "protocol.MsgPong")
 	proto.RegisterType((*MsgP2PTrigger)(nil), "protocol.MsgP2PTrigger")
 	proto.RegisterType((*MsgP2PAck)(nil), "protocol.MsgP2PAck")
+	proto.RegisterType((*MsgNatProbe)(nil), "protocol.MsgNatProbe")
+	proto.RegisterType((*MsgNatProbeAck)(nil), "protocol.MsgNatProbeAck")
 	proto.RegisterType((*MsgGroupPeersRequest)(nil), "protocol.MsgGroupPeersRequest")
 	proto.RegisterType((*PeerInfo)(nil), "protocol.PeerInfo")
 	proto.RegisterType((*MsgGroupPeersResponse)(nil), "protocol.MsgGroupPeersResponse")
@@ -1667,7 +2234,10 @@ func init() {
 	proto.RegisterType((*MsgPeerLinksResponse)(nil), "protocol.MsgPeerLinksResponse")
 	proto.RegisterType((*MsgPeerFrame)(nil), "protocol.MsgPeerFrame")
 	proto.RegisterType((*MsgServerFrame)(nil), "protocol.MsgServerFrame")
+	proto.RegisterType((*MsgKeyExchange)(nil), "protocol.MsgKeyExchange")
 	proto.RegisterType((*MsgDataFrame)(nil), "protocol.MsgDataFrame")
+	proto.RegisterType((*RelayLink)(nil), "protocol.RelayLink")
+	proto.RegisterType((*MsgRelayInfo)(nil), "protocol.MsgRelayInfo")
 }
 
 func init() {
@@ -1675,103 +2245,144 @@ func init() {
 }
 
 var fileDescriptor_64b0f1bc979aed9f = []byte{
-	// 1565 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x57, 0xcd, 0x72, 0xdb, 0x46,
-	0x12, 0x5e, 0xfe, 0x83, 0xcd, 0x1f, 0x41, 0x63, 0x59, 0xa2, 0xec, 0x95, 0xad, 0xc5, 0x5e, 0xb4,
-	0xae, 0x2d, 0xb9, 0x0a, 0xde, 0x75, 0x6d, 0xb9, 0xb6, 0x76, 0x4b, 0xb6, 0xd7, 0x5e, 0x55, 0x99,
-	0x32, 0x03, 0x3b, 0x67, 0x04, 0x06, 0x47, 0x10, 0x8a, 0xe4, 0x00, 0xc6, 0x8c, 0x64, 0xe9, 0x0d,
-	0x52, 0x49, 0x2e, 0xb9, 0xe5, 0x39, 0x72, 0xcf, 0xab, 0xe4, 0x98, 0x73, 0x6e, 0xb9, 0xa6, 0xba,
-	0x07, 0x00, 0x01, 0x92, 0x12, 0x4b, 0xf1, 0x89, 0xe8, 0x99, 0xee, 0x9e, 0xee, 0x9e, 0xef, 0xeb,
-	0x69, 0x42, 0x6f, 0xc8, 0xa5, 0xf4, 0x02, 0x7e, 0x18, 0x27, 0x91, 0x8a, 0x98, 0x41, 0x3f, 0x7e,
-	0x34, 0xb5, 0x5e, 0x41, 0xfd, 0x5d, 0xe4, 0x4f, 0xd8, 0x36, 0x34, 0x5f, 0x79, 0xb3, 0x70, 0x7a,
-	0x35, 0xa8, 0xec, 0x57, 0x0e, 0x7a, 0x4e, 0x2a, 0x31, 0x06, 0xf5, 0x51, 0x94, 0xa8, 0x41, 0x95,
-	0x56, 0xe9, 0x1b, 0xd7, 0x8e, 0xc6, 0xe3, 0x64, 0x50, 0xd3, 0x6b, 0xf8, 0x6d, 0x3d, 0x87, 0xc6,
-	0x71, 0x7c, 0xc2, 0x15, 0xdb, 0x05, 0x43, 0x70, 0xe5, 0x7a, 0xa8, 0xa0, 0x5d, 0xb5, 0x04, 0x57,
-	0xa8, 0xc3, 0x1e, 0x40, 0x07, 0xb7, 0x3e, 0x84, 0xca, 0x9d, 0x72, 0x91, 0xba, 0x6c, 0x0b, 0xae,
-	0x9e, 0x87, 0xea, 0x0d, 0x17, 0xd6, 0x77, 0x15, 0x80, 0x77, 0xca, 0x53, 0xa1, 0x54, 0xa1, 0x2f,
-	0xd9, 0x1e, 0x80, 0x4a, 0x3c, 0x21, 0x5d, 0xc9, 0xc5, 0x98, 0x7c, 0xd5, 0x9d, 0x36, 0xad, 0xbc,
-	0xe3, 0x62, 0xcc, 0xfe, 0x0a, 0x3d, 0xbd, 0x9d, 0x70, 0x9f, 0x87, 0x17, 0x9c, 0xfc, 0xd5, 0x9d,
-	0x2e, 0x2d, 0x3a, 0x7a, 0x0d, 0xa3, 0x89, 0xed, 0x58, 0x7b, 0xa8, 0xd1, 0x7e, 0x2b, 0xb6, 0x63,
-	0xb2, 0x7f, 0x08, 0x1d, 0xdc, 0xca, 0xac, 0xeb, 0xb4, 0x0b, 0xb1, 0x1d, 0xa7, 0xb6, 0xd6, 0xcf,
-	0x55, 0x68, 0x0d, 0x65, 0x70, 0x74, 0xae, 0xce, 0xc8, 0x0f, 0xe7, 0x89, 0x3b, 0xf3, 0xfc, 0x34,
-	0x92, 0x16, 0xca, 0x43, 0xcf, 0x67, 0xf7, 0xa1, 0x4d, 0x5b, 0xc2, 0x9b, 0xe9, 0x18, 0xda, 0x0e,
-	0xe9, 0x9e, 0x78, 0x33, 0xce, 0xb6, 0xa0, 0x11, 0x24, 0xd1, 0x79, 0x4c, 0x87, 0xb7, 0x1d, 0x2d,
-	0xb0, 0x7b, 0x60, 0xc4, 0x9e, 0x94, 0x9f, 0xa2, 0x64, 0x4c, 0xe7, 0xa2, 0x45, 0x2a, 0xb3, 0x1d,
-	0x20, 0xcf, 0x6e, 0x24, 0x07, 0x0d, 0xda, 0x6a, 0xa2, 0xf8, 0x56, 0xb2, 0xbf, 0xa7, 0xe7, 0x50,
-	0x65, 0x9b, 0xfb, 0x95, 0x83, 0x8e, 0xbd, 0x71, 0x98, 0xdd, 0xe3, 0x21, 0x15, 0x5f, 0x1f, 0x4c,
-	0xb5, 0x3e, 0x04, 0x08, 0x85, 0xc8, 0xd4, 0x5b, 0xab, 0xd5, 0xdb, 0xa4, 0x42, 0xfa, 0xbb, 0x60,
-	0x78, 0xe7, 0x2a, 0xa2, 0x04, 0x8d, 0xfd, 0xca, 0x81, 0xe1, 0xb4, 0x50, 0xc6, 0x04, 0x77, 0x80,
-	0x3e, 0xdd, 0x30, 0x1e, 0xb4, 0x69, 0xa7, 0x89, 0xe2, 0x71, 0x8c, 0x99, 0x4f, 0x43, 0x31, 0x71,
-	0x67, 0xd1, 0x98, 0x0f, 0x80, 0x6e, 0xd3, 0xc0, 0x85, 0x61, 0x34, 0xe6, 0xec, 0x2f, 0xd0, 0xa5,
-	0xcd, 0x8f, 0xe7, 0xde, 0x34, 0x54, 0x57, 0x83, 0x0e, 0xed, 0x77, 0x70, 0xed, 0x0b, 0xbd, 0x64,
-	0xfd, 0x52, 0x01, 0x48, 0x0b, 0x7c, 0xe4, 0x4f, 0xd2, 0x10, 0xce, 0xdc, 0x84, 0x4b, 0xaa, 0xf1,
-	0x26, 0x85, 0x70, 0xe6, 0x70, 0x89, 0x65, 0x54, 0xd1, 0x24, 0xc7, 0x8c, 0x16, 0x10, 0x20, 0x9e,
-	0x94, 0x61, 0x20, 0x28, 0xea, 0x1a, 0xc5, 0xd6, 0xd6, 0x2b, 0x18, 0x77, 0xf1, 0xce, 0xea, 0x4b,
-	0x77, 0x96, 0x5a, 0x86, 0x31, 0x95, 0xd9, 0x70, 0x0c, 0xbd, 0x70, 0x1c, 0xdf, 0xb2, 0xd0, 0x16,
-	0xd4, 0x65, 0xe4, 0x4f, 0xd2, 0x12, 0xf7, 0xe7, 0x8a, 0x48, 0x2b, 0x87, 0xf6, 0x2c, 0x07, 0xda,
-	0x43, 0x19, 0x7c, 0x29, 0xd6, 0x41, 0x29, 0x47, 0x4b, 0xb5, 0x88, 0x96, 0x3c, 0xf9, 0x5a, 0x21,
-	0x79, 0x4b, 0x80, 0x31, 0x94, 0x01, 0xd2, 0x85, 0xdf, 0xe4, 0x72, 0x1b, 0x9a, 0x91, 0x98, 0x86,
-	0x42, 0x43, 0xd3, 0x70, 0x52, 0x89, 0x3d, 0x4e, 0x93, 0x0c, 0xc5, 0x69, 0x44, 0x8e, 0x3b, 0x36,
-	0x9b, 0xc7, 0x3e, 0xe2, 0x3c, 0x39, 0x16, 0xa7, 0x91, 0xce, 0x13, 0xbf, 0xac, 0xaf, 0x2b, 0x94,
-	0xc4, 0x8b, 0x48, 0x9c, 0x86, 0x01, 0x62, 0x42, 0x26, 0x7e, 0xe1, 0xc0, 0xa6, 0x4c, 0xfc, 0x14,
-	0x2c, 0x63, 0xa9, 0x68, 0x43, 0xf3, 0xb1, 0x39, 0x96, 0x2a, 0xbd, 0x0d, 0xc1, 0x3f, 0x69, 0x96,
-	0x68, 0x32, 0xb4, 0x04, 0xff, 0x44, 0x24, 0x29, 0x63, 0xb5, 0xbe, 0x0e, 0xab, 0x56, 0x00, 0xdd,
-	0x3c, 0x12, 0x04, 0xce, 0xed, 0x83, 0xb9, 0x03, 0x8d, 0x50, 0xba, 0xd1, 0x24, 0x05, 0x4d, 0x3d,
-	0x94, 0x6f, 0x27, 0xcc, 0x84, 0x9a, 0x0a, 0xe3, 0x94, 0x90, 0xf8, 0x69, 0xfd, 0x5a, 0xa1, 0x0e,
-	0x30, 0x0a, 0x45, 0x50, 0xa6, 0x79, 0x65, 0x81, 0xe6, 0x25, 0xc8, 0x54, 0x6f, 0xc7, 0xcd, 0xda,
-	0x5a, 0x6e, 0x3e, 0x82, 0x86, 0x54, 0x9e, 0x92, 0x69, 0x69, 0xb6, 0x0a, 0x18, 0xcb, 0xbb, 0xa5,
-	0xa3, 0x55, 0xca, 0x9c, 0x6c, 0xac, 0xe1, 0x64, 0x73, 0x99, 0x93, 0xff, 0xd7, 0x19, 0x47, 0x22,
-	0x20, 0x54, 0x45, 0x22, 0xc8, 0xf9, 0xd8, 0x73, 0x5a, 0x28, 0x23, 0x1f, 0x33, 0xd0, 0x57, 0x6f,
-	0x00, 0xfd, 0x11, 0xf4, 0xd0, 0x93, 0x3d, 0x7a, 0x9f, 0x84, 0x41, 0xc0, 0x93, 0xdb, 0x5f, 0x93,
-	0xf5, 0xa3, 0xc6, 0xdc, 0xc8, 0x1e, 0xa5, 0xfd, 0xe1, 0x06, 0xe2, 0x5c, 0x78, 0xd3, 0x70, 0x9c,
-	0x82, 0x5c, 0x0b, 0xec, 0x5f, 0x60, 0x4a, 0x3e, 0x3d, 0x75, 0xf9, 0xa5, 0xe2, 0x89, 0x70, 0x29,
-	0xe2, 0xda, 0xca, 0x88, 0xfb, 0xa8, 0xf7, 0x3f, 0x52, 0xa3, 0xd7, 0xf0, 0x19, 0x6c, 0x46, 0xea,
-	0x8c, 0x27, 0x25, 0xd3, 0xfa, 0x4a, 0xd3, 0x0d, 0x52, 0x9c, 0xdb, 0x5a, 0x27, 0xb0, 0x35, 0x94,
-	0xc1, 0x6b, 0xa4, 0x2e, 0xd2, 0x48, 0x3a, 0xfc, 0xe3, 0x39, 0x97, 0xea, 0xfa, 0xf4, 0xf7, 0x00,
-	0x88, 0xe8, 0xc5, 0x17, 0xa4, 0x4d, 0x2b, 0x88, 0x2d, 0xeb, 0x9b, 0x1a, 0x18, 0x19, 0x1f, 0x6f,
-	0x46, 0xe1, 0x2e, 0x18, 0x63, 0x7e, 0xe1, 0xaa, 0xab, 0x38, 0x73, 0xd3, 0x1a, 0xf3, 0x8b, 0xf7,
-	0x57, 0x31, 0x2f, 0xbd, 0xca, 0xb5, 0xf2, 0xab, 0xbc, 0x87, 0x68, 0x54, 0x45, 0xf6, 0xf5, 0x10,
-	0x7c, 0x6a, 0xfe, 0x30, 0xe4, 0x55, 0x6f, 0x94, 0xab, 0xbe, 0xf0, 0x9e, 0x37, 0x17, 0xde, 0x73,
-	0xf6, 0x37, 0xd8, 0xd4, 0x9e, 0x8b, 0x5a, 0x2d, 0xd2, 0xea, 0xd3, 0xc6, 0x49, 0xae, 0x3a, 0x6f,
-	0x53, 0x46, 0xa9, 0x4d, 0x95, 0xe0, 0xdc, 0x5e, 0x03, 0x67, 0x58, 0x82, 0xf3, 0x9c, 0x3a, 0x9d,
-	0xf5, 0xd4, 0xc9, 0x40, 0xdd, 0xbd, 0x01, 0xd4, 0x5f, 0xc1, 0xdd, 0x85, 0xcb, 0x95, 0x71, 0x24,
-	0x24, 0xc7, 0x04, 0xfc, 0x28, 0x9a, 0x84, 0x3c, 0x9b, 0x9f, 0xb4, 0x54, 0xee, 0xb3, 0xd5, 0xfd,
-	0xda, 0xda, 0x3e, 0xfb, 0x1a, 0xee, 0x20, 0xe4, 0x39, 0x4f, 0xde, 0x84, 0x62, 0xb2, 0x1e, 0x3d,
-	0xd7, 0x92, 0xe7, 0x3f, 0x60, 0xa0, 0x07, 0x82, 0x0d, 0x83, 0x7a, 0x61, 0x20, 0xa3, 0x6f, 0xd6,
-	0x87, 0x6a, 0x72, 0x99, 0xda, 0x54, 0x93, 0x4b, 0x94, 0xd5, 0x65, 0x3a, 0x24, 0x55, 0xd5, 0xa5,
-	0xf5, 0x7d, 0x85, 0x80, 0x5c, 0x88, 0x24, 0x4d, 0xf5, 0xf6, 0xed, 0x76, 0x5e, 0x9c, 0xda, 0x62,
-	0x71, 0xe8, 0x02, 0xa9, 0x38, 0xf5, 0xc5, 0xe2, 0x64, 0xd1, 0xeb, 0x1b, 0xa7, 0xe2, 0xfc, 0x56,
-	0xa7, 0xd6, 0x8f, 0x31, 0xbd, 0x4a, 0x74, 0xe3, 0x35, 0x66, 0x32, 0xd0, 0x90, 0xc7, 0x60, 0xfa,
-	0xf6, 0xe6, 0xdc, 0xc1, 0x50, 0x06, 0x08, 0x7e, 0xa7, 0x35, 0xd3, 0x1f, 0x25, 0x2c, 0x57, 0x97,
-	0x3a, 0xc8, 0xf2, 0x23, 0x9b, 0xb9, 0xc7, 0x31, 0x24, 0xa5, 0x7f, 0xd9, 0x3d, 0x3e, 0xe8, 0xe4,
-	0x9e, 0x5e, 0xf6, 0x27, 0xd0, 0x41, 0xed, 0x73, 0xa1, 0x0d, 0x1a, 0x64, 0x70, 0xa7, 0x64, 0xa0,
-	0x67, 0x00, 0xa7, 0x3d, 0xcb, 0xc7, 0x81, 0xf4, 0x88, 0x38, 0x14, 0x41, 0x3a, 0x6c, 0x94, 0x8f,
-	0xc0, 0xc7, 0x87, 0x8e, 0xa0, 0x57, 0xe8, 0xbf, 0xb0, 0x41, 0xda, 0x76, 0xec, 0x2a, 0xdd, 0x56,
-	0xd3, 0xc1, 0x63, 0xa7, 0x6c, 0x94, 0x77, 0x5d, 0xa7, 0x87, 0xa6, 0x76, 0x9c, 0x35, 0xe1, 0x97,
-	0xd0, 0x47, 0x07, 0xba, 0xe1, 0x60, 0xf2, 0x44, 0xb8, 0x8e, 0xfd, 0xa0, 0x64, 0xbf, 0xd4, 0xbd,
-	0x9c, 0xee, 0xac, 0xb0, 0xca, 0x9e, 0x01, 0x66, 0xe0, 0xe2, 0xbd, 0x48, 0xa2, 0x65, 0xc7, 0xde,
-	0x2b, 0x07, 0xb0, 0x80, 0x5f, 0x07, 0x93, 0xa4, 0x05, 0xf6, 0x1c, 0x7a, 0xb9, 0xad, 0xeb, 0xf9,
-	0x13, 0xa2, 0xed, 0x62, 0x00, 0x4b, 0xa8, 0x73, 0x3a, 0x99, 0x03, 0x7c, 0x0a, 0x6c, 0x00, 0xf4,
-	0xe1, 0xd3, 0x08, 0x90, 0x72, 0xbb, 0x5c, 0x68, 0x3d, 0x1d, 0x50, 0xa1, 0xd3, 0x91, 0xe5, 0xdf,
-	0x3a, 0x73, 0x6d, 0x43, 0x07, 0x6b, 0xa2, 0x6f, 0xaf, 0xb0, 0x3b, 0xf2, 0x27, 0x94, 0x71, 0x2e,
-	0x59, 0xdf, 0x36, 0xa0, 0x8f, 0xf3, 0x16, 0x4f, 0x2e, 0x56, 0x61, 0xef, 0xee, 0x5a, 0xec, 0x3d,
-	0x85, 0x6e, 0x06, 0x25, 0x3a, 0x7c, 0x7b, 0xb1, 0x21, 0xcd, 0x27, 0x61, 0x07, 0x66, 0xf3, 0xa9,
-	0xf8, 0xb1, 0x2e, 0x35, 0xb6, 0x28, 0x3e, 0xd8, 0x59, 0x1c, 0xd4, 0xb2, 0x11, 0x90, 0xea, 0xab,
-	0x87, 0xc1, 0x0c, 0x50, 0x91, 0x08, 0x06, 0x83, 0x55, 0x80, 0x8a, 0x32, 0x40, 0x45, 0xab, 0x01,
-	0xb5, 0x7b, 0x2b, 0x40, 0xa5, 0xa0, 0x47, 0x07, 0x98, 0xd6, 0xbd, 0x15, 0x77, 0xa1, 0xdf, 0x6f,
-	0xba, 0x8b, 0x91, 0x1d, 0x63, 0x52, 0x6f, 0x80, 0x95, 0x51, 0x48, 0xb6, 0xf7, 0xc9, 0xf6, 0xe1,
-	0xb5, 0x48, 0x4c, 0x91, 0xb0, 0x51, 0x84, 0xe2, 0x11, 0xbd, 0xd6, 0x05, 0x34, 0xfe, 0xf9, 0x33,
-	0xd1, 0xb8, 0xf7, 0xb9, 0x68, 0x7c, 0xf0, 0x07, 0xd1, 0xf8, 0xf0, 0x16, 0x68, 0xfc, 0xa1, 0x42,
-	0x7d, 0xf0, 0xa5, 0xa7, 0xbc, 0x65, 0x2c, 0xda, 0x6b, 0xb1, 0x58, 0xe8, 0xe0, 0x4f, 0xae, 0xeb,
-	0xe0, 0xff, 0x28, 0x75, 0xf0, 0xbc, 0x3d, 0xfe, 0xb3, 0xd8, 0x1e, 0x19, 0xd4, 0xc7, 0x9e, 0xf2,
-	0x06, 0x4f, 0xf7, 0x2b, 0x07, 0x5d, 0x87, 0xbe, 0x1f, 0xfd, 0xa4, 0xff, 0x35, 0xd3, 0x39, 0x5d,
-	0xfa, 0x8f, 0xe2, 0x22, 0x94, 0xcd, 0x3f, 0xb1, 0x0d, 0xe8, 0x64, 0xd2, 0x91, 0x3f, 0x31, 0x2b,
-	0xac, 0x4f, 0x7f, 0xff, 0x5c, 0xdd, 0x08, 0xcd, 0x2a, 0xdb, 0x02, 0x13, 0x65, 0x82, 0xf1, 0x8b,
-	0x33, 0x4f, 0x04, 0x7c, 0x6c, 0xd6, 0x32, 0x2d, 0x9d, 0xbc, 0x59, 0x67, 0x9b, 0x34, 0x57, 0xba,
-	0x79, 0x31, 0xcc, 0x46, 0x76, 0x0e, 0x76, 0x48, 0xb3, 0x99, 0x4b, 0x91, 0x08, 0xcc, 0x16, 0xdb,
-	0xa5, 0x17, 0xdb, 0x5d, 0xea, 0x68, 0xa6, 0xc1, 0xee, 0xc1, 0xf6, 0xe2, 0x96, 0xbe, 0x5e, 0xb3,
-	0xcd, 0x06, 0xf4, 0xf8, 0xb9, 0x8b, 0xc8, 0x31, 0x21, 0x73, 0xb8, 0x84, 0x09, 0xb3, 0x93, 0x85,
-	0x3a, 0xf2, 0xfc, 0x09, 0x57, 0x66, 0x97, 0x31, 0xea, 0x19, 0xee, 0x9c, 0x3c, 0x66, 0x2f, 0xd7,
-	0x21, 0x4e, 0x98, 0xfd, 0x82, 0xfc, 0x3e, 0xb9, 0x32, 0x37, 0x3e, 0x34, 0xe9, 0xda, 0x9e, 0xfc,
-	0x1e, 0x00, 0x00, 0xff, 0xff, 0xef, 0xe3, 0xb3, 0xb6, 0xb2, 0x11, 0x00, 0x00,
+	// 2215 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x58, 0xcd, 0x8e, 0xdc, 0xc6,
+	0xf1, 0xff, 0xcf, 0x37, 0xa7, 0xe6, 0x63, 0xb9, 0xad, 0x95, 0x44, 0x49, 0x7f, 0x49, 0x1b, 0x26,
+	0x08, 0x2c, 0x43, 0x50, 0x80, 0x51, 0xbc, 0x91, 0x64, 0xc7, 0xce, 0x7a, 0x65, 0x39, 0x82, 0xb5,
+	0xf2, 0x86, 0x52, 0x6e, 0x01, 0x18, 0x8a, 0xec, 0xe5, 0x12, 0x33, 0x43, 0x52, 0xec, 0x9e, 0xd5,
+	0x8e, 0x6f, 0xb9, 0xe5, 0x98, 0x00, 0x39, 0x04, 0xc8, 0x53, 0x24, 0x4f, 0x92, 0xa7, 0xc8, 0x21,
+	0x40, 0xde, 0x20, 0x87, 0xa0, 0xaa, 0x9b, 0x1c, 0x72, 0x3e, 0x76, 0xb0, 0xf6, 0x89, 0x5d, 0xdd,
+	0x5d, 0xdd, 0x5d, 0xd5, 0xbf, 0xfa, 0x55, 0x35, 0x61, 0x70, 0xcc, 0x85, 0xf0, 0x42, 0xfe, 0x28,
+	0xcd, 0x12, 0x99, 0x30, 0x83, 0x3e, 0x7e, 0x32, 0xb1, 0x7f, 0x07, 0xcd, 0x37, 0x89, 0x3f, 0x66,
+	0x37, 0xa0, 0xfd, 0xc2, 0x9b, 0x46, 0x93, 0xb9, 0x55, 0xdb, 0xaf, 0x7d, 0x34, 0x70, 0xb4, 0xc4,
+	0x18, 0x34, 0x4f, 0x92, 0x4c, 0x5a, 0x75, 0xea, 0xa5, 0x36, 0xf6, 0x1d, 0x06, 0x41, 0x66, 0x35,
+	0x54, 0x1f, 0xb6, 0xd9, 0x1e, 0xb4, 0xf0, 0x7b, 0x60, 0x35, 0xf7, 0x6b, 0x1f, 0xf5, 0x1d, 0x25,
+	0xd8, 0x2e, 0xb4, 0x5e, 0xa6, 0xaf, 0xb9, 0x64, 0xb7, 0xc0, 0x88, 0xb9, 0x74, 0x3d, 0x54, 0x53,
+	0x1b, 0x74, 0x62, 0x2e, 0x49, 0xf3, 0x1e, 0xf4, 0x70, 0xe8, 0x5d, 0x24, 0xdd, 0x09, 0x8f, 0xf5,
+	0x46, 0xdd, 0x98, 0xcb, 0x2f, 0x23, 0xf9, 0x8a, 0xc7, 0xec, 0x0e, 0x74, 0x73, 0xd5, 0x03, 0xda,
+	0xb2, 0xef, 0x18, 0x5a, 0xf7, 0xc0, 0xfe, 0x7b, 0x1d, 0xe0, 0x8d, 0xf4, 0x64, 0x24, 0x64, 0xe4,
+	0x0b, 0x76, 0x17, 0x40, 0x66, 0x5e, 0x2c, 0x5c, 0xc1, 0xe3, 0x80, 0x36, 0x6a, 0x3a, 0x5d, 0xea,
+	0x79, 0xc3, 0xe3, 0x80, 0xfd, 0x18, 0x06, 0x6a, 0x38, 0xe3, 0x3e, 0x8f, 0xce, 0x39, 0x6d, 0xd6,
+	0x74, 0xfa, 0xd4, 0xe9, 0xa8, 0x3e, 0x3c, 0x6a, 0x3a, 0x4a, 0xd5, 0x0a, 0x0d, 0x1a, 0xef, 0xa4,
+	0xa3, 0x94, 0xf4, 0xef, 0x43, 0x0f, 0x87, 0x72, 0xed, 0x26, 0x8d, 0x42, 0x3a, 0x4a, 0x73, 0xdd,
+	0x3b, 0xd0, 0xf5, 0x66, 0xf2, 0xcc, 0x3d, 0xf5, 0xa2, 0x89, 0xd5, 0xa2, 0x61, 0x03, 0x3b, 0x5e,
+	0x78, 0xd1, 0x04, 0xb5, 0x33, 0x9e, 0x4e, 0xbc, 0xb9, 0x1b, 0x64, 0x49, 0x6a, 0xb5, 0x95, 0xb6,
+	0xea, 0x7a, 0x9e, 0x25, 0x29, 0x9e, 0x5e, 0xf0, 0xec, 0x9c, 0x67, 0x6e, 0x26, 0xa5, 0xd5, 0x51,
+	0x8e, 0x50, 0x3d, 0x8e, 0x94, 0xec, 0x47, 0xd0, 0xf7, 0x93, 0x69, 0x9a, 0x71, 0x21, 0xdc, 0xcc,
+	0xfb, 0x60, 0x19, 0xb4, 0x40, 0x2f, 0xef, 0x73, 0xbc, 0x0f, 0x68, 0x60, 0x31, 0x45, 0x44, 0xdf,
+	0x71, 0xab, 0xab, 0x0c, 0xcc, 0x3b, 0xdf, 0x44, 0xdf, 0x71, 0xfb, 0xdf, 0x0d, 0xe8, 0x1c, 0x8b,
+	0xf0, 0x70, 0x26, 0xcf, 0xc8, 0x58, 0xce, 0x33, 0x77, 0xea, 0xf9, 0xda, 0x5d, 0x1d, 0x94, 0x8f,
+	0x3d, 0x1f, 0x6d, 0xa1, 0xa1, 0xd8, 0x9b, 0x2a, 0x47, 0x75, 0x1d, 0x9a, 0xfb, 0xda, 0x9b, 0x72,
+	0xbc, 0xee, 0x30, 0x4b, 0x66, 0x29, 0x79, 0xa8, 0xeb, 0x28, 0x81, 0xdd, 0x06, 0x23, 0xf5, 0x84,
+	0xf8, 0x90, 0x64, 0x81, 0xd5, 0xd4, 0x1a, 0x5a, 0x66, 0x37, 0x81, 0x56, 0x76, 0x13, 0x41, 0x8e,
+	0xe9, 0x3a, 0x6d, 0x14, 0xbf, 0x15, 0xec, 0xa1, 0xde, 0x87, 0xb0, 0x81, 0x4e, 0xe9, 0x8d, 0x76,
+	0x1e, 0xe5, 0xf8, 0x7c, 0x44, 0xf0, 0x51, 0x1b, 0x13, 0x5a, 0x1e, 0x01, 0x44, 0x71, 0x9c, 0x4f,
+	0xef, 0xac, 0x9f, 0xde, 0xa5, 0x29, 0x34, 0xff, 0x16, 0xe0, 0x05, 0x24, 0x64, 0x20, 0x3a, 0xcc,
+	0x70, 0x3a, 0x28, 0xa3, 0x81, 0x37, 0x81, 0x9a, 0x6e, 0x94, 0x92, 0x9b, 0x0c, 0xa7, 0x8d, 0xe2,
+	0xcb, 0x14, 0x2d, 0x9f, 0x44, 0xf1, 0xd8, 0x9d, 0x26, 0x01, 0xb7, 0x80, 0xae, 0xc1, 0xc0, 0x8e,
+	0xe3, 0x24, 0xe0, 0x78, 0x0b, 0x34, 0xf8, 0x7e, 0xe6, 0x4d, 0x22, 0x39, 0xb7, 0x7a, 0x34, 0xde,
+	0xc3, 0xbe, 0xdf, 0xa8, 0x2e, 0xf6, 0x00, 0x3a, 0x62, 0xf6, 0x2e, 0xe6, 0x52, 0x58, 0xfd, 0xfd,
+	0xc6, 0xba, 0x03, 0xe6, 0xe3, 0x68, 0x4e, 0x61, 0xfc, 0x81, 0x35, 0xd8, 0x60, 0x4e, 0x6e, 0xfd,
+	0x01, 0xc5, 0x91, 0x27, 0x5d, 0x39, 0x4f, 0xb9, 0x35, 0xd4, 0x71, 0xe4, 0xc9, 0xb7, 0xf3, 0x94,
+	0xae, 0x24, 0xe3, 0x13, 0x6f, 0x6e, 0xed, 0x90, 0x31, 0x4a, 0xb0, 0xff, 0x55, 0x03, 0xd0, 0x97,
+	0x7d, 0xe8, 0x8f, 0xb5, 0x3b, 0xce, 0xdc, 0x8c, 0x0b, 0xba, 0xef, 0x5d, 0x72, 0xc7, 0x99, 0xc3,
+	0x05, 0xea, 0xcb, 0x64, 0x5c, 0x44, 0xa0, 0x12, 0x10, 0x93, 0x9e, 0x10, 0x51, 0x18, 0x93, 0x07,
+	0x1b, 0xb4, 0x74, 0x57, 0xf5, 0xa0, 0x0f, 0xcb, 0xf8, 0x69, 0xae, 0xe0, 0x47, 0x6b, 0x46, 0x29,
+	0x5d, 0xb9, 0xe1, 0x18, 0xaa, 0xe3, 0x65, 0x7a, 0xc5, 0x4b, 0xb7, 0xa1, 0x29, 0x12, 0x7f, 0xac,
+	0xaf, 0x7b, 0xb8, 0x98, 0x88, 0xd4, 0xe5, 0xd0, 0x98, 0xed, 0x40, 0xf7, 0x58, 0x84, 0xbf, 0x8d,
+	0xb7, 0xc1, 0xba, 0x40, 0x6e, 0xbd, 0x8c, 0xdc, 0xc2, 0xf8, 0x46, 0xc9, 0x78, 0x3b, 0x06, 0xe3,
+	0x58, 0x84, 0xc8, 0x2f, 0xfc, 0xb2, 0x25, 0x6f, 0x40, 0x3b, 0x89, 0x27, 0x51, 0xac, 0xc2, 0xc4,
+	0x70, 0xb4, 0xc4, 0x7e, 0xa6, 0x8d, 0x8c, 0xe2, 0xd3, 0x84, 0x16, 0xee, 0x8d, 0xd8, 0xe2, 0xec,
+	0x27, 0x9c, 0x67, 0x2f, 0xe3, 0xd3, 0x44, 0xd9, 0x89, 0x2d, 0xfb, 0x8f, 0x35, 0x32, 0xe2, 0x28,
+	0x89, 0x4f, 0xa3, 0x10, 0xf1, 0x29, 0x32, 0xbf, 0xb4, 0x61, 0x5b, 0x64, 0xbe, 0x06, 0x6e, 0x20,
+	0x24, 0x0d, 0x28, 0x02, 0x6b, 0x07, 0x42, 0xea, 0xdb, 0x88, 0xf9, 0x07, 0x15, 0xb1, 0x2a, 0x30,
+	0x3b, 0x31, 0xff, 0x40, 0x01, 0x5b, 0x8d, 0x9b, 0xe6, 0xb6, 0xb8, 0xb1, 0x43, 0xe8, 0x17, 0x27,
+	0x41, 0xe0, 0x5c, 0xfd, 0x30, 0xd7, 0xa0, 0x15, 0x09, 0x37, 0x19, 0x6b, 0xd0, 0x34, 0x23, 0xf1,
+	0xed, 0x98, 0x99, 0xd0, 0x90, 0x51, 0xaa, 0xc9, 0x01, 0x9b, 0xf6, 0x7f, 0xeb, 0xc4, 0x46, 0x27,
+	0x51, 0x1c, 0x56, 0x29, 0xa7, 0xb6, 0x44, 0x39, 0x15, 0xc8, 0xd4, 0xaf, 0xc6, 0x13, 0x8d, 0xad,
+	0x3c, 0xf1, 0x31, 0xb4, 0x84, 0xf4, 0xa4, 0xd0, 0xae, 0xd9, 0x2b, 0x61, 0xac, 0x48, 0x2f, 0x8e,
+	0x9a, 0x52, 0xe5, 0x87, 0xd6, 0x16, 0x7e, 0x68, 0x5f, 0xca, 0x0f, 0x9d, 0x2b, 0xf1, 0x83, 0x71,
+	0x25, 0x7e, 0xe8, 0x6e, 0xe0, 0x07, 0x28, 0xf3, 0xc3, 0xaf, 0x95, 0xf7, 0x93, 0x38, 0x24, 0x84,
+	0x27, 0x71, 0x58, 0x70, 0xc3, 0xc0, 0xe9, 0xa0, 0x8c, 0xdc, 0x90, 0x07, 0x60, 0xfd, 0x92, 0x00,
+	0x7c, 0x0f, 0x03, 0x5c, 0x69, 0x74, 0xf2, 0x36, 0x8b, 0xc2, 0x90, 0x67, 0xdf, 0x03, 0x32, 0x8f,
+	0x00, 0x7c, 0x2f, 0x0e, 0xa2, 0xc0, 0x93, 0x5c, 0x58, 0x8d, 0xfd, 0xc6, 0x9a, 0xcd, 0x4a, 0x33,
+	0xec, 0xff, 0xa8, 0x78, 0x39, 0x19, 0x9d, 0x68, 0x6e, 0xbb, 0x24, 0xe8, 0xcf, 0xbd, 0x49, 0x14,
+	0xe8, 0x00, 0x55, 0x02, 0x7b, 0x02, 0xa6, 0xe0, 0x93, 0x53, 0x97, 0x5f, 0x48, 0x9e, 0xc5, 0x2e,
+	0x59, 0xd8, 0x58, 0x6b, 0xe1, 0x10, 0xe7, 0x7d, 0x45, 0xd3, 0x50, 0x66, 0xcf, 0x60, 0x37, 0x91,
+	0x67, 0x3c, 0xab, 0xa8, 0x36, 0xd7, 0xaa, 0xee, 0xd0, 0xc4, 0x92, 0xee, 0x53, 0x30, 0x95, 0x6e,
+	0xc9, 0xd4, 0xd6, 0x7e, 0x63, 0xa3, 0xea, 0xd1, 0xc2, 0xde, 0x3f, 0xd4, 0xa0, 0x77, 0x2c, 0xc2,
+	0xd7, 0x9e, 0x3c, 0xc9, 0x92, 0x77, 0x1c, 0x89, 0xc7, 0x4f, 0x92, 0x71, 0xc4, 0xf3, 0xa2, 0x4d,
+	0x49, 0x58, 0x69, 0xf8, 0x67, 0x5e, 0x1c, 0xf2, 0x45, 0xb0, 0x18, 0x0e, 0xa8, 0x2e, 0x42, 0xfb,
+	0x62, 0x42, 0x8a, 0xc5, 0x5d, 0xa3, 0x3c, 0x81, 0x4a, 0x3c, 0x0b, 0x3a, 0xa9, 0x17, 0x04, 0x51,
+	0x1c, 0xea, 0x82, 0x2e, 0x17, 0xed, 0x73, 0x18, 0x96, 0x8e, 0x70, 0xa8, 0x4a, 0xc7, 0xb5, 0xa7,
+	0xf8, 0x29, 0xb4, 0xa7, 0x5e, 0x9a, 0xf2, 0x60, 0x03, 0x6c, 0xf4, 0x28, 0xfb, 0x09, 0xb4, 0xc8,
+	0xd0, 0x0d, 0xbe, 0x57, 0x83, 0xf6, 0x6b, 0xd8, 0x3b, 0x16, 0xe1, 0xd7, 0xc8, 0xd6, 0xc8, 0x9c,
+	0xc2, 0xe1, 0xef, 0x67, 0x5c, 0xc8, 0xcd, 0x28, 0xbb, 0x0b, 0x40, 0xdc, 0x5e, 0x2e, 0x60, 0xba,
+	0xd4, 0x83, 0x74, 0x62, 0xff, 0xa5, 0x09, 0x46, 0x4e, 0xc1, 0x97, 0x13, 0xcf, 0x2d, 0x30, 0x02,
+	0x7e, 0xae, 0x62, 0x4a, 0x2d, 0xd3, 0x09, 0xf8, 0x39, 0xc5, 0x54, 0xb9, 0xac, 0x6d, 0x54, 0xcb,
+	0xda, 0xbb, 0x48, 0x40, 0xb2, 0x4c, 0xb8, 0x03, 0xe4, 0x1b, 0xb9, 0xa8, 0x4b, 0x0a, 0xb0, 0xb6,
+	0xaa, 0x60, 0x5d, 0x2a, 0x88, 0xdb, 0xcb, 0x05, 0xf1, 0x03, 0xd8, 0x55, 0x2b, 0x97, 0x67, 0xa9,
+	0x6a, 0x71, 0x48, 0x03, 0xaf, 0x8b, 0xa9, 0x8b, 0xcc, 0x64, 0x54, 0x32, 0x53, 0x85, 0xc1, 0xba,
+	0x5b, 0x18, 0x0c, 0x56, 0x19, 0xac, 0x60, 0xcb, 0xde, 0x76, 0xb6, 0xcc, 0xb9, 0xa3, 0xbf, 0x99,
+	0x3b, 0xca, 0x8c, 0x38, 0xd8, 0xc2, 0x88, 0x0f, 0xcb, 0xcf, 0x81, 0xe1, 0x86, 0x34, 0x90, 0xbf,
+	0x0f, 0x2a, 0x7c, 0xb8, 0xb3, 0x81, 0x0f, 0xcd, 0x32, 0x1f, 0xfe, 0x1e, 0xae, 0x2f, 0xc1, 0x4c,
+	0xa4, 0x49, 0x2c, 0x36, 0xc7, 0x5a, 0x25, 0xc9, 0xd7, 0xf7, 0x1b, 0x5b, 0x93, 0xfc, 0xd7, 0x70,
+	0x0d, 0x39, 0x8b, 0xf3, 0xec, 0x55, 0x14, 0x8f, 0xb7, 0xe3, 0x78, 0x13, 0x5b, 0xda, 0x9f, 0x83,
+	0x81, 0x2b, 0x10, 0x80, 0x19, 0x34, 0x4b, 0x6f, 0x2b, 0x6a, 0xb3, 0x21, 0xd4, 0xb3, 0x0b, 0xad,
+	0x53, 0xcf, 0x2e, 0x50, 0x96, 0x17, 0xfa, 0x49, 0x53, 0x97, 0x17, 0xf6, 0x9f, 0x6b, 0xb0, 0x57,
+	0x3d, 0x89, 0x36, 0xf5, 0xea, 0xc4, 0xbd, 0x70, 0x4e, 0x63, 0xd9, 0x39, 0x04, 0x25, 0x72, 0x4e,
+	0x73, 0xd9, 0x39, 0xf9, 0xe9, 0x15, 0xf6, 0xc8, 0x39, 0xff, 0x68, 0x41, 0x5f, 0x9f, 0xe9, 0x45,
+	0xa6, 0xb2, 0xbe, 0x31, 0x15, 0xa1, 0xba, 0x40, 0x3c, 0xcc, 0x70, 0xb4, 0xbb, 0x58, 0xe0, 0x58,
+	0x84, 0x78, 0x95, 0x4e, 0x67, 0xaa, 0x1a, 0x95, 0xa8, 0xaa, 0xaf, 0xa4, 0x80, 0xd5, 0x0a, 0x2f,
+	0x5f, 0x1e, 0x6b, 0x60, 0xcd, 0xdf, 0xd5, 0xe5, 0xb1, 0x9a, 0xa4, 0xe5, 0xb1, 0xc1, 0x1e, 0x43,
+	0x0f, 0x67, 0xcf, 0x62, 0xa5, 0xd0, 0x22, 0x85, 0x6b, 0x15, 0x05, 0x55, 0x80, 0x3a, 0xdd, 0x69,
+	0xde, 0xcc, 0xb7, 0x48, 0x91, 0x4b, 0xdb, 0x6b, 0xb6, 0xc0, 0xca, 0x87, 0xb6, 0xc0, 0x06, 0xfb,
+	0x02, 0x76, 0x68, 0xf6, 0x28, 0x75, 0xa5, 0xca, 0xa3, 0xba, 0xea, 0xbd, 0x59, 0x55, 0x2a, 0xd2,
+	0xac, 0x33, 0x40, 0xd5, 0x51, 0xaa, 0x45, 0xf6, 0x1c, 0x86, 0xb8, 0x80, 0xa2, 0x3e, 0x34, 0x5e,
+	0x57, 0x0d, 0xf7, 0x2a, 0xfa, 0x2b, 0x3c, 0xea, 0xf4, 0xa7, 0xa5, 0x5e, 0xf6, 0x0c, 0xd0, 0x02,
+	0x17, 0xef, 0x45, 0x10, 0x41, 0xf4, 0x46, 0x77, 0xab, 0x07, 0x58, 0xc2, 0xaf, 0x83, 0x46, 0x52,
+	0x07, 0xfb, 0x12, 0x06, 0x85, 0xae, 0xeb, 0xf9, 0x63, 0x0b, 0xd6, 0x1c, 0x60, 0x05, 0x75, 0x4e,
+	0x2f, 0x5f, 0x00, 0x73, 0xca, 0x08, 0x00, 0xd7, 0xf0, 0xa9, 0xfe, 0xb4, 0x7a, 0x6b, 0x1c, 0xad,
+	0x4a, 0x53, 0x72, 0xb4, 0x6a, 0xb2, 0xcf, 0x60, 0xb8, 0xd0, 0x71, 0xbd, 0x82, 0x72, 0x6e, 0xac,
+	0xd1, 0x3b, 0xf4, 0xc7, 0x64, 0x71, 0x21, 0xb1, 0xa7, 0xea, 0xd4, 0xc8, 0x16, 0x29, 0x66, 0x36,
+	0xfd, 0x18, 0xbb, 0x5e, 0x51, 0xce, 0xd3, 0x1e, 0x1d, 0x36, 0x17, 0xec, 0x7f, 0xb6, 0x28, 0x27,
+	0xbe, 0xa1, 0x97, 0xfa, 0x2a, 0x6c, 0xaf, 0x6f, 0x85, 0xed, 0x01, 0xf4, 0x73, 0x14, 0xd2, 0xb9,
+	0x6f, 0x2c, 0xb3, 0xea, 0xe2, 0x05, 0xe7, 0xc0, 0xb4, 0x68, 0x63, 0x78, 0xa1, 0x1e, 0xf2, 0x2c,
+	0xb7, 0x6e, 0x2e, 0x3f, 0x30, 0xf2, 0xa7, 0x0b, 0x5d, 0x0d, 0xb5, 0x0a, 0x2c, 0x26, 0x71, 0x68,
+	0x59, 0xeb, 0xb0, 0x98, 0xe4, 0x58, 0x4c, 0xd6, 0x63, 0xf1, 0xd6, 0x95, 0xb0, 0xa8, 0xe3, 0x05,
+	0x17, 0x40, 0xb3, 0x6e, 0xaf, 0xb9, 0x46, 0x55, 0xbb, 0xd1, 0x35, 0x9e, 0x8c, 0x52, 0x34, 0xea,
+	0x15, 0xb0, 0x2a, 0x80, 0x49, 0xf7, 0x0e, 0xe9, 0xde, 0xdf, 0x08, 0x62, 0x0d, 0xa2, 0x9d, 0x32,
+	0x8a, 0x0f, 0xa9, 0x52, 0x2b, 0x01, 0xf9, 0xff, 0x7f, 0x20, 0x90, 0xef, 0xfe, 0x50, 0x20, 0xdf,
+	0xfb, 0x9e, 0x40, 0xbe, 0x7f, 0x05, 0x20, 0x1f, 0xc1, 0x6e, 0x05, 0xc8, 0xb4, 0xc0, 0x3e, 0x2d,
+	0x60, 0xad, 0x05, 0x33, 0x2e, 0x31, 0x9c, 0x56, 0x64, 0xfb, 0x4f, 0x35, 0x82, 0xf4, 0x37, 0x7c,
+	0xfe, 0xd5, 0x85, 0x2a, 0x0b, 0xd9, 0x75, 0x68, 0x8f, 0xf9, 0xdc, 0x8d, 0x02, 0x9d, 0x64, 0x5a,
+	0x63, 0x3e, 0x7f, 0x19, 0x60, 0x9d, 0x93, 0xce, 0xde, 0x4d, 0x22, 0xdf, 0x1d, 0xf3, 0x39, 0x91,
+	0x6e, 0xdf, 0xe9, 0xaa, 0x9e, 0x6f, 0xf8, 0x1c, 0x7f, 0x09, 0x65, 0xda, 0x31, 0xba, 0xcc, 0x2c,
+	0x64, 0x4a, 0x5a, 0x39, 0xf1, 0xf6, 0x1d, 0x6a, 0x63, 0xe1, 0x49, 0x76, 0x67, 0x53, 0xfd, 0xcf,
+	0x20, 0x17, 0xed, 0xbf, 0x36, 0x29, 0x35, 0x3c, 0xf7, 0xa4, 0xb7, 0x1a, 0x63, 0xa3, 0xad, 0x31,
+	0x56, 0x4a, 0x6a, 0x8f, 0x37, 0x25, 0xb5, 0x9f, 0x57, 0x92, 0x5a, 0x91, 0x31, 0x3e, 0x29, 0x67,
+	0x0c, 0x06, 0xcd, 0xc0, 0x93, 0x9e, 0x75, 0xa0, 0x0e, 0x8d, 0xed, 0x92, 0x6b, 0x7e, 0x51, 0x76,
+	0xcd, 0xa7, 0xd0, 0xc7, 0x6e, 0xae, 0x3d, 0x68, 0x3d, 0x59, 0x73, 0x09, 0x25, 0x0f, 0x3b, 0xbd,
+	0xf1, 0x42, 0xc0, 0x97, 0xb2, 0xe0, 0xef, 0xad, 0xa7, 0x74, 0x24, 0x6c, 0xb2, 0x87, 0x79, 0xc1,
+	0xf2, 0x6c, 0x0d, 0x1a, 0x0a, 0xb7, 0xe8, 0x42, 0x86, 0x7d, 0x02, 0x40, 0x0d, 0x95, 0x7b, 0x3f,
+	0x5d, 0xa3, 0xe2, 0xe0, 0x30, 0xe5, 0xdf, 0x6e, 0x96, 0x37, 0xb1, 0x32, 0x9c, 0xca, 0x99, 0xa6,
+	0xc0, 0xcf, 0x54, 0x65, 0x38, 0x95, 0x33, 0xf5, 0xde, 0xb8, 0x09, 0x9d, 0xd3, 0xcc, 0x0b, 0xd1,
+	0xd0, 0x5f, 0xaa, 0x3c, 0x8f, 0xa2, 0x02, 0x81, 0x1a, 0x88, 0x03, 0x7e, 0x61, 0x7d, 0x4e, 0x63,
+	0x5d, 0x1a, 0xc3, 0x8e, 0x62, 0xd8, 0x4f, 0x66, 0xb1, 0xb4, 0xbe, 0x58, 0x0c, 0x1f, 0x61, 0x07,
+	0x62, 0x24, 0xff, 0x41, 0x69, 0xfd, 0x4a, 0x6d, 0x99, 0xcb, 0x95, 0x3f, 0x9a, 0xbe, 0x97, 0x0a,
+	0xeb, 0x90, 0x26, 0x14, 0x7f, 0x34, 0x8f, 0xbc, 0x54, 0xd8, 0x4f, 0xa0, 0x4b, 0xc6, 0x60, 0xd4,
+	0x5d, 0xf6, 0x0c, 0x34, 0xa1, 0x81, 0x7f, 0x56, 0xd5, 0x0f, 0x2e, 0x6c, 0xda, 0x4f, 0x09, 0x53,
+	0x85, 0x27, 0xd8, 0x03, 0x68, 0x29, 0xaa, 0xa8, 0xed, 0x37, 0xaa, 0x91, 0x5a, 0x6c, 0xe0, 0xa8,
+	0x19, 0x1f, 0xff, 0x4d, 0xfd, 0x46, 0x25, 0x70, 0xf5, 0xe9, 0x47, 0x91, 0x8b, 0xbc, 0x6c, 0xfe,
+	0x1f, 0xdb, 0x81, 0x5e, 0x2e, 0x1d, 0xfa, 0x63, 0xb3, 0xc6, 0x86, 0xf4, 0x0f, 0xce, 0x55, 0x05,
+	0x81, 0x59, 0x67, 0x7b, 0x60, 0xa2, 0x4c, 0x9c, 0x7c, 0x44, 0xd7, 0x1d, 0x98, 0x8d, 0x7c, 0x96,
+	0x8a, 0x64, 0xb3, 0xc9, 0x76, 0x61, 0xb0, 0x90, 0x71, 0xa1, 0x56, 0xbe, 0x0f, 0x56, 0x0a, 0x66,
+	0xbb, 0x90, 0x92, 0x38, 0x34, 0x3b, 0xec, 0x16, 0x55, 0xae, 0xee, 0x4a, 0x66, 0x37, 0x0d, 0x76,
+	0x1b, 0x6e, 0x2c, 0x0f, 0xa9, 0x10, 0x34, 0xbb, 0xcc, 0xa2, 0x22, 0xd0, 0x5d, 0xa6, 0x41, 0x13,
+	0xf2, 0x05, 0x57, 0x08, 0xce, 0xec, 0xe5, 0x47, 0x3d, 0xf1, 0xfc, 0x31, 0x97, 0x66, 0x9f, 0x31,
+	0x62, 0x0b, 0x77, 0x91, 0x09, 0xcc, 0x41, 0x31, 0x87, 0x08, 0xde, 0x1c, 0x96, 0xe4, 0xb7, 0xd9,
+	0xdc, 0xdc, 0x61, 0xd7, 0x60, 0x07, 0xe5, 0x52, 0x00, 0x98, 0x26, 0x33, 0xe9, 0x3e, 0xdc, 0x9c,
+	0x8a, 0xcc, 0xdd, 0x7c, 0x5a, 0x89, 0x9c, 0x4c, 0xc6, 0x06, 0xf4, 0xee, 0x77, 0xe9, 0x4e, 0xcc,
+	0x6b, 0xb9, 0xa7, 0x8a, 0x6b, 0x34, 0xf7, 0xde, 0xb5, 0xe9, 0xe2, 0x1e, 0xff, 0x6f, 0x00, 0xaf,
+	0x82, 0x53, 0x56, 0xf9, 0x18, 0x00, 0x00,
 }
@@ -65,6 +65,9 @@ func (s *PortService) Start() error {
 }
 
 func (s *PortService) Stop() {
+	if s.bootstrap == nil {
+		return
+	}
 	s.serverCtx.Range(func(key, value interface{}) bool {
 		v := value.(*ServerContext)
 		_ = v.Stop()
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"encoding/binary"
+	"vilan/app"
+	"vilan/config"
+	"vilan/model"
+)
+
+// ARP代答:TAP模式下本机查询在线终端虚拟IP的ARP请求由本端按已知的终端MAC直接应答,不再经服务端广播,
+// 只有未知的查询才转发;终端上线时向本机发送该终端的免费ARP,更新本机缓存中该IP对应的MAC,同时向其发送本终端的免费ARP
+
+const (
+	arpRequest = 1
+	arpReply   = 2
+	sizeArp    = 28
+)
+
+var broadcastMac = []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+
+func macBytes(mac uint64) []byte {
+	b := make([]byte, 8)
+	binary.LittleEndian.PutUint64(b, mac)
+	return b[:6]
+}
+
+// 构造以太网ARP帧
+func arpFrame(op uint16, ethDst []byte, senderMac []byte, senderIp uint32, targetMac []byte, targetIp uint32) []byte {
+	frame := make([]byte, model.SizeEthFrame+sizeArp)
+	copy(frame[0:6], ethDst)
+	copy(frame[6:12], senderMac)
+	binary.BigEndian.PutUint16(frame[12:14], ethTypeArp)
+	arp := frame[model.SizeEthFrame:]
+	binary.BigEndian.PutUint16(arp[0:2], 1) // 以太网
+	binary.BigEndian.PutUint16(arp[2:4], ethTypeIpv4)
+	arp[4], arp[5] = 6, 4
+	binary.BigEndian.PutUint16(arp[6:8], op)
+	copy(arp[8:14], senderMac)
+	binary.BigEndian.PutUint32(arp[14:18], senderIp)
+	copy(arp[18:24], targetMac)
+	binary.BigEndian.PutUint32(arp[24:28], targetIp)
+	return frame
+}
+
+// 解析IPv4以太网ARP请求,返回请求方MAC、IP及查询的IP
+func parseArpRequest(frame []byte) (senderMac []byte, senderIp uint32, targetIp uint32, ok bool) {
+	if len(frame) < model.SizeEthFrame+sizeArp || binary.BigEndian.Uint16(frame[12:14]) != ethTypeArp {
+		return nil, 0, 0, false
+	}
+	arp := frame[model.SizeEthFrame:]
+	if binary.BigEndian.Uint16(arp[2:4]) != ethTypeIpv4 || arp[4] != 6 || arp[5] != 4 ||
+		binary.BigEndian.Uint16(arp[6:8]) != arpRequest {
+		return nil, 0, 0, false
+	}
+	return arp[8:14], binary.BigEndian.Uint32(arp[14:18]), binary.BigEndian.Uint32(arp[24:28]), true
+}
+
+// 本机查询在线终端的ARP请求直接应答,返回true表示已应答,不再转发
+func (t *TunTapService) proxyArp(frame []byte) bool {
+	senderMac, senderIp, targetIp, ok := parseArpRequest(frame)
+	// 免费ARP及查询本机IP的请求照常转发
+	if !ok || senderIp == targetIp || targetIp == config.AppConfig.TapConfig.IpAddr {
+		return false
+	}
+	mac, ok := app.RuntimeService.FindPeerByIp(targetIp)
+	if !ok {
+		return false
+	}
+	if peer := app.RuntimeService.FindPeer(mac); peer == nil || !peer.Online {
+		return false
+	}
+	peerMac := macBytes(mac)
+	reply := arpFrame(arpReply, senderMac, peerMac, targetIp, senderMac, senderIp)
+	if _, err := t.tapper.Write(reply); err != nil {
+		return false
+	}
+	return true
+}
+
+// 终端上线时向本机发送免费ARP
+func (t *TunTapService) AnnouncePeer(mac uint64, ip uint32) {
+	if t.tun || ip == 0 || t.state != model.TunTapRunning || t.tapper == nil {
+		return
+	}
+	peerMac := macBytes(mac)
+	if _, err := t.tapper.Write(arpFrame(arpRequest, broadcastMac, peerMac, ip, make([]byte, 6), ip)); err != nil {
+		app.Logger.Debug("免费ARP发送失败:", err)
+	}
+}
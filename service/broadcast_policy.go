@@ -0,0 +1,194 @@
+package service
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"vilan/app"
+	"vilan/model"
+)
+
+// 广播及组播策略:TAP模式下本机发出的广播、组播帧按配置的规则顺序匹配以太网类型、IP协议、目的组播组及UDP端口,
+// 第一条匹配的规则决定转发或丢弃,用于过滤mDNS、SSDP、NetBIOS等局域网发现报文;
+// 开启p2p_fanout时广播复制为单播发送给有P2P连接或中转路径的终端,其余终端仍经服务端广播,重复的副本由接收端按发送序号丢弃
+
+const (
+	ipProtoIcmp   = 1
+	ipProtoIgmp   = 2
+	ipProtoTcp    = 6
+	ipProtoUdp    = 17
+	ipProtoIcmpv6 = 58
+)
+
+type broadcastRule struct {
+	drop      bool
+	etherType uint16
+	protocol  int // -1为不限
+	group     *net.IPNet
+	port      uint16
+}
+
+type broadcastPolicy struct {
+	rules       []*broadcastRule
+	defaultDrop bool
+	fanout      bool
+}
+
+// 按配置生成广播策略,格式错误的规则忽略
+func newBroadcastPolicy(conf *model.BroadcastConfig) *broadcastPolicy {
+	p := &broadcastPolicy{}
+	if conf == nil {
+		return p
+	}
+	p.fanout = conf.P2PFanout
+	p.defaultDrop = strings.EqualFold(conf.Default, "drop")
+	for _, c := range conf.Rules {
+		if c == nil {
+			continue
+		}
+		rule, err := parseBroadcastRule(c)
+		if err != nil {
+			app.Logger.Warn("广播规则格式错误:", err)
+			continue
+		}
+		p.rules = append(p.rules, rule)
+	}
+	return p
+}
+
+func parseBroadcastRule(c *model.BroadcastRule) (*broadcastRule, error) {
+	rule := &broadcastRule{protocol: -1, port: c.Port}
+	switch strings.ToLower(c.Action) {
+	case "drop":
+		rule.drop = true
+	case "allow":
+	default:
+		return nil, errors.New("处理方式错误:" + c.Action)
+	}
+	switch t := strings.ToLower(c.EtherType); t {
+	case "":
+	case "ipv4":
+		rule.etherType = ethTypeIpv4
+	case "ipv6":
+		rule.etherType = ethTypeIpv6
+	case "arp":
+		rule.etherType = ethTypeArp
+	default:
+		v, err := strconv.ParseUint(t, 0, 16)
+		if err != nil {
+			return nil, errors.New("以太网类型错误:" + c.EtherType)
+		}
+		rule.etherType = uint16(v)
+	}
+	switch p := strings.ToLower(c.Protocol); p {
+	case "":
+	case "icmp":
+		rule.protocol = ipProtoIcmp
+	case "igmp":
+		rule.protocol = ipProtoIgmp
+	case "tcp":
+		rule.protocol = ipProtoTcp
+	case "udp":
+		rule.protocol = ipProtoUdp
+	case "icmpv6":
+		rule.protocol = ipProtoIcmpv6
+	default:
+		v, err := strconv.ParseUint(p, 0, 8)
+		if err != nil {
+			return nil, errors.New("IP协议错误:" + c.Protocol)
+		}
+		rule.protocol = int(v)
+	}
+	if g := c.Group; len(g) > 0 {
+		if !strings.Contains(g, "/") {
+			if ip := net.ParseIP(g); ip == nil {
+				return nil, errors.New("组播地址错误:" + g)
+			} else if ip.To4() != nil {
+				g += "/32"
+			} else {
+				g += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(g)
+		if err != nil {
+			return nil, err
+		}
+		rule.group = ipNet
+	}
+	return rule, nil
+}
+
+// 广播帧的以太网类型、IP协议、目的地址及UDP目的端口,非IP报文协议为-1
+type castFrame struct {
+	etherType uint16
+	protocol  int
+	dst       net.IP
+	port      uint16
+}
+
+func parseCastFrame(frame []byte) *castFrame {
+	if len(frame) < model.SizeEthFrame {
+		return &castFrame{protocol: -1}
+	}
+	f := &castFrame{etherType: binary.BigEndian.Uint16(frame[12:14]), protocol: -1}
+	ip := frame[model.SizeEthFrame:]
+	var payload []byte
+	switch f.etherType {
+	case ethTypeIpv4:
+		ipHdr := 0
+		if len(ip) >= 20 {
+			ipHdr = int(ip[0]&0x0F) * 4
+		}
+		if ipHdr < 20 || len(ip) < ipHdr {
+			return f
+		}
+		f.protocol = int(ip[9])
+		f.dst = net.IP(ip[16:20])
+		if binary.BigEndian.Uint16(ip[6:8])&0x1FFF == 0 { // 非首个分片没有UDP头
+			payload = ip[ipHdr:]
+		}
+	case ethTypeIpv6:
+		if len(ip) < 40 {
+			return f
+		}
+		f.protocol = int(ip[6]) // 不处理扩展头
+		f.dst = net.IP(ip[24:40])
+		payload = ip[40:]
+	}
+	if f.protocol == ipProtoUdp && len(payload) >= 8 {
+		f.port = binary.BigEndian.Uint16(payload[2:4])
+	}
+	return f
+}
+
+func (r *broadcastRule) match(f *castFrame) bool {
+	if r.etherType != 0 && r.etherType != f.etherType {
+		return false
+	}
+	if r.protocol >= 0 && r.protocol != f.protocol {
+		return false
+	}
+	if r.group != nil && (f.dst == nil || !r.group.Contains(f.dst)) {
+		return false
+	}
+	if r.port != 0 && r.port != f.port {
+		return false
+	}
+	return true
+}
+
+// 广播或组播帧是否转发
+func (p *broadcastPolicy) allow(frame []byte) bool {
+	if p == nil || len(p.rules) == 0 {
+		return p == nil || !p.defaultDrop
+	}
+	f := parseCastFrame(frame)
+	for _, r := range p.rules {
+		if r.match(f) {
+			return !r.drop
+		}
+	}
+	return !p.defaultDrop
+}
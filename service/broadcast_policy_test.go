@@ -0,0 +1,138 @@
+package service
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"vilan/app"
+	"vilan/common"
+	"vilan/model"
+)
+
+func init() {
+	if app.Logger == nil {
+		app.Logger = common.NewLogger(false, false, common.Error)
+	}
+}
+
+func TestParseBroadcastRule(t *testing.T) {
+	var cases = []struct {
+		name string
+		rule model.BroadcastRule
+		ok   bool
+		want broadcastRule
+	}{
+		{name: "drop mdns", rule: model.BroadcastRule{Action: "drop", Protocol: "udp", Port: 5353}, ok: true,
+			want: broadcastRule{drop: true, protocol: ipProtoUdp, port: 5353}},
+		{name: "allow arp", rule: model.BroadcastRule{Action: "Allow", EtherType: "ARP"}, ok: true,
+			want: broadcastRule{etherType: ethTypeArp, protocol: -1}},
+		{name: "hex ether type", rule: model.BroadcastRule{Action: "drop", EtherType: "0x88cc"}, ok: true,
+			want: broadcastRule{drop: true, etherType: 0x88cc, protocol: -1}},
+		{name: "numeric protocol", rule: model.BroadcastRule{Action: "drop", Protocol: "89"}, ok: true,
+			want: broadcastRule{drop: true, protocol: 89}},
+		{name: "bad action", rule: model.BroadcastRule{Action: "deny"}},
+		{name: "bad ether type", rule: model.BroadcastRule{Action: "drop", EtherType: "ipx"}},
+		{name: "ether type overflow", rule: model.BroadcastRule{Action: "drop", EtherType: "0x10000"}},
+		{name: "bad protocol", rule: model.BroadcastRule{Action: "drop", Protocol: "sctp"}},
+		{name: "protocol overflow", rule: model.BroadcastRule{Action: "drop", Protocol: "256"}},
+		{name: "bad group", rule: model.BroadcastRule{Action: "drop", Group: "239.255.255"}},
+		{name: "bad group cidr", rule: model.BroadcastRule{Action: "drop", Group: "239.0.0.0/33"}},
+	}
+	for _, c := range cases {
+		rule, err := parseBroadcastRule(&c.rule)
+		if (err == nil) != c.ok {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if !c.ok {
+			continue
+		}
+		if rule.drop != c.want.drop || rule.etherType != c.want.etherType || rule.protocol != c.want.protocol || rule.port != c.want.port {
+			t.Fatalf("%s: %+v != %+v", c.name, *rule, c.want)
+		}
+	}
+
+	var groups = []struct {
+		group string
+		want  string
+	}{
+		{group: "239.255.255.250", want: "239.255.255.250/32"},
+		{group: "224.0.0.0/4", want: "224.0.0.0/4"},
+		{group: "ff02::fb", want: "ff02::fb/128"},
+	}
+	for _, g := range groups {
+		rule, err := parseBroadcastRule(&model.BroadcastRule{Action: "drop", Group: g.group})
+		if err != nil || rule.group.String() != g.want {
+			t.Fatalf("%s: %v %v", g.group, err, rule)
+		}
+	}
+}
+
+// 发往组播地址dst的UDP以太网帧
+func udpCastFrame(dst string, port uint16) []byte {
+	ip := net.ParseIP(dst)
+	if ip4 := ip.To4(); ip4 != nil {
+		frame := make([]byte, model.SizeEthFrame+20+8)
+		binary.BigEndian.PutUint16(frame[12:14], ethTypeIpv4)
+		hdr := frame[model.SizeEthFrame:]
+		hdr[0], hdr[9] = 0x45, ipProtoUdp
+		copy(hdr[16:20], ip4)
+		binary.BigEndian.PutUint16(hdr[22:24], port)
+		return frame
+	}
+	frame := make([]byte, model.SizeEthFrame+40+8)
+	binary.BigEndian.PutUint16(frame[12:14], ethTypeIpv6)
+	hdr := frame[model.SizeEthFrame:]
+	hdr[0], hdr[6] = 0x60, ipProtoUdp
+	copy(hdr[24:40], ip.To16())
+	binary.BigEndian.PutUint16(hdr[42:44], port)
+	return frame
+}
+
+func TestBroadcastPolicyAllow(t *testing.T) {
+	mdns := udpCastFrame("224.0.0.251", 5353)
+	mdns6 := udpCastFrame("ff02::fb", 5353)
+	ssdp := udpCastFrame("239.255.255.250", 1900)
+	dhcp := udpCastFrame("255.255.255.255", 67)
+	arp := arpFrame(arpRequest, broadcastMac, make([]byte, 6), 1, make([]byte, 6), 2)
+	fragment := udpCastFrame("224.0.0.251", 5353)
+	fragment[model.SizeEthFrame+7] = 1 // 非首个分片没有UDP头
+	short := []byte{0xFF, 0xFF, 0xFF}
+
+	filter := newBroadcastPolicy(&model.BroadcastConfig{Rules: []*model.BroadcastRule{
+		{Action: "drop", Protocol: "udp", Port: 5353},
+		{Action: "drop", Group: "239.255.255.250"},
+		nil,
+		{Action: "deny"}, // 格式错误的规则忽略
+	}})
+	onlyArp := newBroadcastPolicy(&model.BroadcastConfig{Default: "drop", Rules: []*model.BroadcastRule{
+		{Action: "allow", EtherType: "arp"},
+	}})
+	var cases = []struct {
+		name   string
+		policy *broadcastPolicy
+		frame  []byte
+		allow  bool
+	}{
+		{name: "nil policy", policy: nil, frame: mdns, allow: true},
+		{name: "no rules", policy: newBroadcastPolicy(nil), frame: mdns, allow: true},
+		{name: "default drop", policy: newBroadcastPolicy(&model.BroadcastConfig{Default: "Drop"}), frame: arp},
+		{name: "mdns", policy: filter, frame: mdns},
+		{name: "mdns ipv6", policy: filter, frame: mdns6},
+		{name: "ssdp", policy: filter, frame: ssdp},
+		{name: "dhcp", policy: filter, frame: dhcp, allow: true},
+		{name: "arp", policy: filter, frame: arp, allow: true},
+		{name: "fragment", policy: filter, frame: fragment, allow: true},
+		{name: "short", policy: filter, frame: short, allow: true},
+		{name: "only arp", policy: onlyArp, frame: arp, allow: true},
+		{name: "only arp mdns", policy: onlyArp, frame: mdns},
+		{name: "only arp short", policy: onlyArp, frame: short},
+	}
+	if len(filter.rules) != 2 {
+		t.Fatalf("rules %d", len(filter.rules))
+	}
+	for _, c := range cases {
+		if allow := c.policy.allow(c.frame); allow != c.allow {
+			t.Fatalf("%s: allow %v", c.name, allow)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"sync"
+	"time"
+	"vilan/common"
+	"vilan/protocol"
+)
+
+// 数据帧压缩:
+// 终端向组内在线终端发送数据时,每隔 compressCapsInterval 秒先发送一个只带本端可解压算法的报文,数据为空,同样加密,
+// 可解压算法参与认证,接收方解密认证并通过防重放检查后才记录;收到对端的算法后若本端尚未发送过则立即回复,
+// 报文加密发送后才记为已发送,会话密钥协商完成前未能发送时每隔 compressCapsRetry 秒重试,
+// 配置了压缩算法且对端可解压时,单播报文先压缩再加密;压缩后节省不到1/16视为不可压缩(如已加密或压缩过的数据),
+// 之后跳过的报文数按次加倍(1、3、7...),最多 compressBackoffMax 个,避免对不可压缩的数据流反复压缩
+
+const (
+	compressMin          = 128 // 小于该长度的报文不压缩
+	compressCapsInterval = 30
+	compressCapsRetry    = 5 // 未能加密发送时的重试间隔
+	compressBackoffMax   = 64
+)
+
+type peerCompress struct {
+	mutex    sync.Mutex
+	caps     uint32 // 对端可解压的算法,未知时为0
+	capsSent int64  // 最近一次向对端发送本端可解压算法的时间
+	capsTry  int64  // 最近一次尝试发送的时间
+	skip     int    // 剩余跳过压缩的报文数
+	backoff  int
+}
+
+// 组内在线终端的压缩状态,其他MAC返回nil
+func (r *RuntimeService) peerCompress(mac uint64) *peerCompress {
+	if r.compress == nil || !common.IsUniCast(mac) || mac == r.appConfig.TapConfig.HwMac {
+		return nil
+	}
+	if peer := r.FindPeer(mac); peer == nil || !peer.Online {
+		return nil
+	}
+	if v, ok := r.compress.Load(mac); ok {
+		return v.(*peerCompress)
+	}
+	v, _ := r.compress.LoadOrStore(mac, &peerCompress{})
+	return v.(*peerCompress)
+}
+
+// 需要告知对端时发送只带本端可解压算法的报文
+func (r *RuntimeService) sendCompressCaps(mac uint64) {
+	p := r.peerCompress(mac)
+	if p == nil {
+		return
+	}
+	p.mutex.Lock()
+	due := p.capsDue(time.Now().Unix())
+	p.mutex.Unlock()
+	if due {
+		_ = r.postDataFrame(&protocol.MsgDataFrame{MsgType: protocol.MsgType_Msg_Packet, SrcMac: r.appConfig.TapConfig.HwMac, DstMac: mac,
+			CompressCaps: common.CompressCaps()})
+	}
+}
+
+// 是否需要发送本端可解压的算法,调用时持有p.mutex
+func (p *peerCompress) capsDue(now int64) bool {
+	if now-p.capsSent < compressCapsInterval || now-p.capsTry < compressCapsRetry {
+		return false
+	}
+	p.capsTry = now
+	return true
+}
+
+// 只带可解压算法的报文已加密发送
+func (r *RuntimeService) CompressCapsSent(mac uint64) {
+	if p := r.peerCompress(mac); p != nil {
+		p.mutex.Lock()
+		p.capsSent = time.Now().Unix()
+		p.mutex.Unlock()
+	}
+}
+
+// 收到对端可解压的算法(已通过认证及防重放检查),本端尚未告知对端时回复
+func (r *RuntimeService) ProcessCompressCaps(mac uint64, caps uint32) {
+	p := r.peerCompress(mac)
+	if p == nil {
+		return
+	}
+	p.mutex.Lock()
+	p.caps = caps
+	p.mutex.Unlock()
+	r.sendCompressCaps(mac)
+}
+
+// 压缩数据到out,不压缩时返回错误,压缩后设置报文的压缩算法
+func (r *RuntimeService) CompressMsg(msg *protocol.MsgDataFrame, out []byte) (int, error) {
+	alg := r.compressAlg
+	if alg == common.CompressNone || len(msg.Data) < compressMin {
+		return 0, common.ErrIncompressible
+	}
+	p := r.peerCompress(msg.DstMac)
+	if p == nil {
+		return 0, common.ErrIncompressible
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.caps&(1<<alg) == 0 {
+		return 0, common.ErrIncompressible
+	}
+	if p.skip > 0 {
+		p.skip--
+		return 0, common.ErrIncompressible
+	}
+	n, err := common.Compress(alg, msg.Data, out)
+	if err != nil || n > len(msg.Data)-len(msg.Data)/16 {
+		if p.backoff = p.backoff*2 + 1; p.backoff > compressBackoffMax {
+			p.backoff = compressBackoffMax
+		}
+		p.skip = p.backoff
+		return 0, common.ErrIncompressible
+	}
+	p.backoff = 0
+	msg.Compress = alg
+	r.setCompressStats(msg.DstMac, len(msg.Data), n)
+	return n, nil
+}
+
+// 按报文的压缩算法解压数据到out
+func (r *RuntimeService) DecompressMsg(msg *protocol.MsgDataFrame, out []byte) (int, error) {
+	n, err := common.Decompress(msg.Compress, msg.Data, out)
+	if err != nil {
+		return 0, err
+	}
+	r.setCompressStats(msg.SrcMac, n, len(msg.Data))
+	return n, nil
+}
+
+func (r *RuntimeService) setCompressStats(mac uint64, raw int, size int) {
+	if r.stats == nil {
+		return
+	}
+	r.stats.CompressRaw += uint64(raw)
+	r.stats.CompressSize += uint64(size)
+	if m := r.trafficMeter(mac); m != nil {
+		m.Compress(uint64(raw), uint64(size))
+	}
+}
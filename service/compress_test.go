@@ -0,0 +1,136 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"sync"
+	"testing"
+	"time"
+	"vilan/common"
+	"vilan/model"
+	"vilan/protocol"
+)
+
+const testPeerMac = 0x0000563412EE
+
+func compressService(online bool) *RuntimeService {
+	r := &RuntimeService{appConfig: &model.AppConfig{TapConfig: &model.TapConfig{HwMac: 0x0000010203EE}},
+		compress: &sync.Map{}, groupPeers: &sync.Map{}, compressAlg: common.CompressLz4}
+	r.groupPeers.Store(uint64(testPeerMac), &protocol.PeerInfo{PeerMac: testPeerMac, Online: online})
+	return r
+}
+
+func TestCompressMsg(t *testing.T) {
+	text := bytes.Repeat([]byte("temperature=21.5;humidity=40;"), 20)
+	var cases = []struct {
+		name   string
+		online bool
+		caps   uint32
+		dst    uint64
+		data   []byte
+		ok     bool
+	}{
+		{name: "compress", online: true, caps: common.CompressCaps(), dst: testPeerMac, data: text, ok: true},
+		{name: "caps unknown", online: true, dst: testPeerMac, data: text},
+		{name: "lz4 not supported", online: true, caps: 1 << common.CompressZstd, dst: testPeerMac, data: text},
+		{name: "offline peer", online: false, caps: common.CompressCaps(), dst: testPeerMac, data: text},
+		{name: "unknown peer", online: true, caps: common.CompressCaps(), dst: 0x0000AABBCCEE, data: text},
+		{name: "short", online: true, caps: common.CompressCaps(), dst: testPeerMac, data: text[:compressMin-1]},
+	}
+	for _, c := range cases {
+		r := compressService(c.online)
+		if c.caps != 0 {
+			if p := r.peerCompress(testPeerMac); p != nil {
+				p.caps = c.caps
+			}
+		}
+		msg := &protocol.MsgDataFrame{SrcMac: r.appConfig.TapConfig.HwMac, DstMac: c.dst, Data: c.data}
+		out := make([]byte, len(c.data))
+		n, err := r.CompressMsg(msg, out)
+		if (err == nil) != c.ok {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if !c.ok {
+			if msg.Compress != common.CompressNone {
+				t.Fatalf("%s: compress set", c.name)
+			}
+			continue
+		}
+		if msg.Compress != common.CompressLz4 {
+			t.Fatalf("%s: compress %d", c.name, msg.Compress)
+		}
+		plain := make([]byte, len(c.data))
+		msg.Data = out[:n]
+		if m, err := r.DecompressMsg(msg, plain); err != nil || !bytes.Equal(plain[:m], c.data) {
+			t.Fatalf("%s: round trip %v", c.name, err)
+		}
+	}
+}
+
+func TestCompressBackoff(t *testing.T) {
+	r := compressService(true)
+	r.peerCompress(testPeerMac).caps = common.CompressCaps()
+	random := make([]byte, 1024)
+	_, _ = rand.Read(random)
+	text := bytes.Repeat([]byte("temperature=21.5;humidity=40;"), 40)
+	out := make([]byte, 2048)
+	send := func(data []byte) bool {
+		_, err := r.CompressMsg(&protocol.MsgDataFrame{DstMac: testPeerMac, Data: data}, out)
+		return err == nil
+	}
+
+	// 每次不可压缩后跳过的报文数 1、3、7...,最多 compressBackoffMax
+	for _, skip := range []int{1, 3, 7, 15, 31, 63, compressBackoffMax, compressBackoffMax} {
+		if send(random) {
+			t.Fatal("random data compressed")
+		}
+		for i := 0; i < skip; i++ {
+			if send(text) {
+				t.Fatalf("skip %d: compressed after %d", skip, i)
+			}
+		}
+	}
+	if !send(text) {
+		t.Fatal("not compressed after backoff")
+	}
+	// 压缩成功后重新计数
+	if send(random) || send(text) || !send(text) {
+		t.Fatal("backoff not reset")
+	}
+}
+
+func TestCompressCapsDue(t *testing.T) {
+	const now = 100000
+	var cases = []struct {
+		name  string
+		sent  int64
+		tried int64
+		want  bool
+	}{
+		{name: "never sent", want: true},
+		{name: "recently sent", sent: now - compressCapsInterval + 1, tried: now - compressCapsInterval + 1},
+		{name: "interval passed", sent: now - compressCapsInterval, tried: now - compressCapsInterval, want: true},
+		{name: "not sent retry wait", tried: now - compressCapsRetry + 1},
+		{name: "not sent retry", tried: now - compressCapsRetry, want: true},
+	}
+	for _, c := range cases {
+		p := &peerCompress{capsSent: c.sent, capsTry: c.tried}
+		if got := p.capsDue(now); got != c.want {
+			t.Fatalf("%s: %v != %v", c.name, got, c.want)
+		}
+		// 尝试后在重试间隔内不再发送
+		if c.want && p.capsDue(now+1) {
+			t.Fatalf("%s: due twice", c.name)
+		}
+	}
+}
+
+func TestCompressCapsSent(t *testing.T) {
+	r := compressService(true)
+	p := r.peerCompress(testPeerMac)
+	p.capsTry = time.Now().Unix()
+	r.CompressCapsSent(testPeerMac)
+	if p.capsSent == 0 || p.capsDue(time.Now().Unix()+compressCapsRetry) {
+		t.Fatal("caps resent after success")
+	}
+}
@@ -29,6 +29,15 @@ func (p *DataHandler) HandleActive(ctx netty.ActiveContext) {
 	p.sockContext.Handler = ctx
 	msgFrame := &protocol.MsgDataFrame{SrcMac: config.AppConfig.TapConfig.HwMac, DstMac: p.sockContext.PeerMac, MsgType: protocol.MsgType_Msg_Ping}
 	ctx.Write(msgFrame)
+	if p.sockContext.Probe { // 端口预测的附加套接字,由主套接字发起协商
+		return
+	}
+	// 新的P2P通道建立时重新协商会话密钥
+	go func() {
+		if err := app.RuntimeService.StartKeyExchange(p.sockContext.PeerMac); err != nil {
+			app.Logger.Debug("会话密钥协商发起失败:", err)
+		}
+	}()
 }
 
 // 信息处理
@@ -56,6 +65,16 @@ func (p *DataHandler) processMsg(msg *protocol.MsgDataFrame, ctx netty.InboundCo
 	}
 	switch msg.MsgType {
 	case protocol.MsgType_Msg_Packet:
+		if !app.RuntimeService.CheckReplay(msg) {
+			app.RuntimeService.SetDropStats(msg.SrcMac, model.DropReplay)
+			break
+		}
+		if msg.CompressCaps != 0 {
+			app.RuntimeService.ProcessCompressCaps(msg.SrcMac, msg.CompressCaps)
+		}
+		if len(msg.Data) == 0 { // 只带可解压算法的空报文
+			break
+		}
 		if _, err := app.TunTapService.WriteData2TunTap(msg.Data[:]); err != nil { // todo 数据解密
 			app.Logger.Error("data write to tun tap failed:", err)
 		}
@@ -65,11 +84,55 @@ func (p *DataHandler) processMsg(msg *protocol.MsgDataFrame, ctx netty.InboundCo
 			app.Logger.Error("p2p failed:", err)
 		}
 		break
-	case protocol.MsgType_Msg_Ping:
-		msgFrame := &protocol.MsgDataFrame{SrcMac: config.AppConfig.TapConfig.HwMac, DstMac: msg.SrcMac, MsgType: protocol.MsgType_Msg_Pong}
+	case protocol.MsgType_Msg_Ping: // 应答带回心跳编号及路径MTU探测大小
+		msgFrame := &protocol.MsgDataFrame{SrcMac: config.AppConfig.TapConfig.HwMac, DstMac: msg.SrcMac, MsgType: protocol.MsgType_Msg_Pong,
+			Seq: msg.Seq, MtuProbe: msg.MtuProbe}
 		ctx.Write(msgFrame)
 		break
 	case protocol.MsgType_Msg_Pong:
+		if msg.MtuProbe > 0 {
+			app.P2pService.ProcessMtuPong(p.sockContext.PeerMac, msg.MtuProbe)
+		} else {
+			p.sockContext.Quality.Received(msg.Seq)
+		}
+		break
+	case protocol.MsgType_Msg_Relay:
+		if msg.Relay == nil {
+			break
+		}
+		if msg.Relay.DstMac != msg.DstMac { // 本终端为中转终端
+			if err := app.P2pService.RelayForward(msg.Relay); err != nil {
+				app.Logger.Debug("中转失败:", err)
+			}
+			break
+		}
+		switch relay := msg.Relay; relay.MsgType {
+		case protocol.MsgType_Msg_Packet:
+			p.processMsg(relay, ctx)
+		// 中转心跳不加密也不认证,中转终端可伪造应答使路径显得更快,只影响路径选择;
+		// 数据仍由源终端端到端加密,中转终端不能解密或篡改
+		case protocol.MsgType_Msg_Ping: // 经同一中转终端返回应答
+			ctx.Write(&protocol.MsgDataFrame{MsgType: protocol.MsgType_Msg_Relay, SrcMac: config.AppConfig.TapConfig.HwMac, DstMac: msg.SrcMac,
+				Relay: &protocol.MsgDataFrame{SrcMac: config.AppConfig.TapConfig.HwMac, DstMac: relay.SrcMac, MsgType: protocol.MsgType_Msg_Pong, Seq: relay.Seq}})
+		case protocol.MsgType_Msg_Pong:
+			app.P2pService.ProcessRelayPong(relay.SrcMac, msg.SrcMac, relay.Seq)
+		}
+		break
+	case protocol.MsgType_Msg_RelayInfo:
+		// 只接受服务端告知已开启中转的终端发来的中转信息
+		if peer := app.RuntimeService.FindPeer(p.sockContext.PeerMac); peer == nil || !peer.Relay {
+			break
+		}
+		links := make(map[uint64]uint32)
+		for _, l := range msg.RelayInfo.GetLinks() {
+			links[l.PeerMac] = l.Rtt
+		}
+		p.sockContext.SetRelayLinks(links)
+		break
+	case protocol.MsgType_Msg_KeyExchange:
+		if err := app.RuntimeService.ProcessKeyExchange(msg); err != nil {
+			app.Logger.Debug("会话密钥协商失败:", err)
+		}
 		break
 	}
 }
@@ -93,10 +156,9 @@ func (p *DataHandler) HandleInactive(ctx netty.InactiveContext, ex netty.Excepti
 func (p *DataHandler) HandleEvent(_ netty.EventContext, event netty.Event) {
 	switch event.(type) {
 	case netty.ReadIdleEvent:
-		msgFrame := &protocol.MsgDataFrame{SrcMac: config.AppConfig.TapConfig.HwMac, DstMac: p.sockContext.PeerMac, MsgType: protocol.MsgType_Msg_Ping}
 		if p.sockContext.Handler != nil {
 			p.sockContext.PingTryCount++
-			p.sockContext.Handler.Write(msgFrame)
+			p.sockContext.Handler.Write(peerPing(p.sockContext))
 		}
 	case netty.WriteIdleEvent:
 
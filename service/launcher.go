@@ -0,0 +1,98 @@
+package service
+
+import (
+	"vilan/app"
+	"vilan/common"
+	"vilan/config"
+	"vilan/control"
+	"vilan/model"
+	"vilan/serial"
+)
+
+// 加载APP配置并创建日志组件
+func LoadAppConfig() {
+	app.Logger = common.NewLogger(true, false, common.Error)
+	if err := config.InitAppConfig(); err != nil {
+		app.Logger.Error("配置加载失败,程序将以默认配置运行.")
+	} else {
+		app.Logger = common.NewLogger(config.AppConfig.EnableLog, config.AppConfig.SaveLog, config.AppConfig.LogLevel)
+		app.Logger.Info("程序配置加载成功")
+	}
+}
+
+// 创建并启动各服务,界面程序与守护进程共用
+func StartServices() error {
+	// 创建实例
+	app.TunTapService = NewTunTapService()
+	app.RuntimeService = NewRuntimeService()
+	app.P2pService = NewP2pService()
+	app.SerialService = serial.NewPortService()
+	// TunTapService 初始化
+	if config.AppConfig.TapConfig.IpMode == model.Static && config.AppConfig.TapConfig.HwMac != 0 {
+		if err := app.TunTapService.Start(); err != nil {
+			app.Logger.Error("虚拟网卡初始化失败:", err.Error())
+		} else {
+			app.Logger.Info("虚拟网卡初始化成功,IP ", common.Uint32toIpV4(config.AppConfig.TapConfig.IpAddr),
+				" MAC ", common.Uint64ToMacStr(config.AppConfig.TapConfig.HwMac))
+		}
+	}
+
+	// Runtime Service 初始化
+	if err := app.RuntimeService.Init(); err != nil {
+		app.Logger.Error("运行时服务配置初始化失败:", err.Error())
+		return err
+	}
+	app.Logger.Info("运行时服务配置初始化成功")
+
+	if err := app.RuntimeService.Start(); err != nil {
+		app.Logger.Error("运行时服务启动失败:", err.Error())
+		return err
+	}
+	app.Logger.Info("运行时服务启动成功")
+
+	// P2p Service 初始化
+	if err := app.P2pService.Start(); err != nil {
+		app.Logger.Error("P2P管理服务启动失败:", err.Error())
+		return err
+	}
+	app.Logger.Info("P2P管理服务启动成功")
+
+	if config.AppConfig.AllowVisitPort {
+		if err := app.SerialService.Start(); err != nil {
+			app.Logger.Error("串口服务启动失败:", err.Error())
+		} else {
+			app.Logger.Info("串口服务启动成功,监听端口:48532")
+		}
+	} else {
+		app.Logger.Info("当前设备串口已禁止远程访问")
+	}
+
+	if config.AppConfig.EnableControl {
+		app.ControlService = control.NewControlService()
+		if err := app.ControlService.Start(); err != nil {
+			app.Logger.Error("本地控制接口启动失败:", err.Error())
+		} else {
+			app.Logger.Info("本地控制接口启动成功,地址:", app.ControlService.Addr())
+		}
+	}
+	return nil
+}
+
+// 按启动的逆序关闭各服务
+func StopServices() {
+	if app.ControlService != nil {
+		app.ControlService.Stop()
+	}
+	if app.SerialService != nil {
+		app.SerialService.Stop()
+	}
+	if app.RuntimeService != nil {
+		app.RuntimeService.Stop()
+	}
+	if app.P2pService != nil {
+		app.P2pService.Stop()
+	}
+	if app.Logger != nil {
+		app.Logger.Info("服务已关闭")
+	}
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"net"
+	"sync/atomic"
+	"time"
+	"vilan/common"
+)
+
+// 服务端连接存活检查:
+// 心跳超时后先向服务端发送应用层心跳(Msg_Ping)并等待应答(Msg_Pong),不依赖ICMP;
+// 服务端没有应答时依次探测配置的目标(probe_targets),任一可达说明本地网络正常,继续等待到离线时间,
+// 没有配置探测目标时直接重连。
+// 检查在单独的协程中进行,结果经 result 交给状态监测协程处理,不阻塞状态监测。
+
+const livenessTimeout = time.Second
+
+type livenessChecker struct {
+	r        *RuntimeService
+	targets  []string
+	checking int32
+	result   chan bool
+}
+
+func newLivenessChecker(r *RuntimeService, targets []string) *livenessChecker {
+	return &livenessChecker{r: r, targets: targets, result: make(chan bool, 1)}
+}
+
+// 启动一次检查,上次检查未结束时忽略
+func (c *livenessChecker) Check() {
+	if !atomic.CompareAndSwapInt32(&c.checking, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&c.checking, 0)
+		alive := c.alive()
+		select {
+		case c.result <- alive:
+		default:
+		}
+	}()
+}
+
+func (c *livenessChecker) alive() bool {
+	if c.r.pingServer(livenessTimeout) {
+		return true
+	}
+	for _, target := range c.targets {
+		if probeTarget(target, livenessTimeout) {
+			return true
+		}
+	}
+	return false
+}
+
+// host:port 为TCP连接,仅host时为ICMP
+func probeTarget(target string, timeout time.Duration) bool {
+	if _, _, err := net.SplitHostPort(target); err != nil {
+		return common.Ping(target, int(timeout/time.Millisecond))
+	}
+	conn, err := net.DialTimeout("tcp", target, timeout)
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// 发送心跳并等待应答,应答到达时 ProcessPong 通知
+func (r *RuntimeService) pingServer(timeout time.Duration) bool {
+	select {
+	case <-r.pongNotify: // 清除之前的应答
+	default:
+	}
+	if err := r.SendPing(); err != nil {
+		return false
+	}
+	select {
+	case <-r.pongNotify:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// 记录心跳发送时间,用于计算往返时间
+func (r *RuntimeService) markPingSent() {
+	atomic.StoreInt64(&r.pingSent, time.Now().UnixNano())
+}
+
+func (r *RuntimeService) updateServerRtt() {
+	sent := atomic.SwapInt64(&r.pingSent, 0)
+	if sent == 0 || r.stats == nil {
+		return
+	}
+	r.stats.ServerRtt = uint32(time.Duration(time.Now().UnixNano()-sent) / time.Millisecond)
+}
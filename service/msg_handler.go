@@ -58,6 +58,22 @@ func (s *MessageHandler) HandleRead(ctx netty.InboundContext, msg netty.Message)
 	}
 	switch v := msg.(type) {
 	case *protocol.MsgDataFrame:
+		if v.MsgType == protocol.MsgType_Msg_KeyExchange { // 经服务端转发的密钥协商
+			if err := app.RuntimeService.ProcessKeyExchange(v); err != nil {
+				app.Logger.Debug("会话密钥协商失败:", err)
+			}
+			return
+		}
+		if !app.RuntimeService.CheckReplay(v) {
+			app.RuntimeService.SetDropStats(v.SrcMac, model.DropReplay)
+			return
+		}
+		if v.CompressCaps != 0 {
+			app.RuntimeService.ProcessCompressCaps(v.SrcMac, v.CompressCaps)
+		}
+		if len(v.Data) == 0 { // 只带可解压算法的空报文
+			return
+		}
 		if n, e := app.TunTapService.WriteData2TunTap(v.Data[:]); e != nil || n != len(v.Data[:]) {
 			//app.Logger.Error("Tap报文写入错误:", e)
 		}
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/golang/protobuf/proto"
+	"math/rand"
+	"net"
+	"time"
+	"vilan/model"
+	"vilan/netty/utils"
+	"vilan/protocol"
+)
+
+// NAT类型探测(参考RFC 3489):
+// 用同一个未连接的UDP套接字向探测服务发送请求,比较服务端看到的映射地址,
+// 并要求服务端从备用IP、端口应答,以判断NAT的映射及过滤方式。
+// 未连接的套接字才能收到来自其他地址的应答,因此不使用netty通道。
+// 探测服务默认为服务端,也可在配置中指定其他地址(nat_probe_addr)。
+// 服务端需处理Msg_NatProbe并按change_addr、change_port从备用IP、端口应答;服务端往返时间选择、回切及路径MTU探测
+// 也使用该请求。服务端不支持时探测没有应答,NAT类型按未知处理,不按往返时间选择及回切服务端,路径MTU不自动探测,
+// 自动传输方式经TCP连接时不切回UDP。
+
+const (
+	natProbeTimeout = 800 * time.Millisecond
+	natProbeRetry   = 3
+)
+
+// 探测请求没有应答,服务端可能不支持Msg_NatProbe
+var errNoProbeAck = errors.New("探测服务没有应答")
+
+type natProber struct {
+	conn    *net.UDPConn
+	mac     uint64
+	buf     []byte
+	padding []byte // 路径MTU探测时的填充
+}
+
+func DetectNatType(server string, mac uint64) (model.NatType, error) {
+	addr, err := net.ResolveUDPAddr("udp", server)
+	if err != nil {
+		return model.NatUnKnown, err
+	}
+	network := "udp4"
+	if addr.IP.To4() == nil {
+		network = "udp6"
+	}
+	conn, err := net.ListenUDP(network, nil)
+	if err != nil {
+		return model.NatUnKnown, err
+	}
+	defer conn.Close()
+	p := &natProber{conn: conn, mac: mac, buf: make([]byte, model.SizeMaxPacket)}
+
+	ack, err := p.probe(addr, false, false)
+	if err != nil {
+		return model.NatUnKnown, err
+	}
+	if ack.Mapped == nil {
+		return model.NatUnKnown, errors.New("探测应答没有映射地址")
+	}
+	open := ack.Mapped.Port == uint32(conn.LocalAddr().(*net.UDPAddr).Port) && isLocalIp(model.SockIp(ack.Mapped))
+	// 从备用IP及端口应答,能收到说明不过滤来源
+	if _, err = p.probe(addr, true, true); err == nil {
+		if open {
+			return model.NatOpen, nil
+		}
+		return model.NatFullCone, nil
+	}
+	if open { // 公网地址,防火墙按来源过滤
+		return model.NatPortCone, nil
+	}
+	if ack.Other == nil {
+		return model.NatUnKnown, errors.New("探测服务不支持备用地址")
+	}
+	other, err := net.ResolveUDPAddr("udp", model.SockAddr(ack.Other))
+	if err != nil {
+		return model.NatUnKnown, err
+	}
+	ack2, err := p.probe(other, false, false)
+	if err != nil || ack2.Mapped == nil {
+		return model.NatUnKnown, errors.New("备用地址没有应答")
+	}
+	if model.SockAddr(ack2.Mapped) != model.SockAddr(ack.Mapped) { // 不同目标使用不同映射
+		return model.NatSymmetric, nil
+	}
+	if _, err = p.probe(addr, false, true); err == nil {
+		return model.NatAddrCone, nil
+	}
+	return model.NatPortCone, nil
+}
+
+// 探测请求的往返时间,包含重发的等待时间
+func ProbeRtt(server string, mac uint64) (time.Duration, error) {
+	addr, err := net.ResolveUDPAddr("udp", server)
+	if err != nil {
+		return 0, err
+	}
+	network := "udp4"
+	if addr.IP.To4() == nil {
+		network = "udp6"
+	}
+	conn, err := net.ListenUDP(network, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	p := &natProber{conn: conn, mac: mac, buf: make([]byte, model.SizeMaxPacket)}
+	start := time.Now()
+	if _, err = p.probe(addr, false, false); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// 发送探测请求并等待应答,要求从备用地址应答时校验应答来源
+func (p *natProber) probe(addr *net.UDPAddr, changeAddr, changePort bool) (*protocol.MsgNatProbeAck, error) {
+	cookie := rand.Uint32()
+	msg := &protocol.MsgPeerFrame{PeerMac: p.mac, MsgType: protocol.MsgType_Msg_NatProbe,
+		MsgNatProbe: &protocol.MsgNatProbe{Cookie: cookie, ChangeAddr: changeAddr, ChangePort: changePort, Padding: p.padding}}
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	var head = [binary.MaxVarintLen32]byte{}
+	n := utils.PutUvarint32(head[:], uint32(len(data)))
+	packet := append(head[:n], data...)
+
+	for i := 0; i < natProbeRetry; i++ {
+		if _, err = p.conn.WriteToUDP(packet, addr); err != nil {
+			return nil, err
+		}
+		deadline := time.Now().Add(natProbeTimeout)
+		_ = p.conn.SetReadDeadline(deadline)
+		for time.Now().Before(deadline) {
+			rn, from, e := p.conn.ReadFromUDP(p.buf)
+			if e != nil {
+				break
+			}
+			ack := p.parseAck(p.buf[:rn], cookie)
+			if ack == nil {
+				continue
+			}
+			if (changeAddr && from.IP.Equal(addr.IP)) || (changePort && from.Port == addr.Port) {
+				continue // 服务端不支持时可能从原地址应答
+			}
+			return ack, nil
+		}
+	}
+	return nil, errNoProbeAck
+}
+
+func (p *natProber) parseAck(data []byte, cookie uint32) *protocol.MsgNatProbeAck {
+	_, num := utils.Uvarint32(data)
+	if num <= 0 || num >= len(data) {
+		return nil
+	}
+	frame := &protocol.MsgServerFrame{}
+	if err := proto.Unmarshal(data[num:], frame); err != nil {
+		return nil
+	}
+	if frame.MsgType != protocol.MsgType_Msg_NatProbeAck || frame.MsgNatProbeAck == nil || frame.MsgNatProbeAck.Cookie != cookie {
+		return nil
+	}
+	return frame.MsgNatProbeAck
+}
+
+func isLocalIp(ip net.IP) bool {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
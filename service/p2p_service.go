@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"net"
 	"sync"
-	"syscall"
 	"time"
 	"vilan/app"
 	"vilan/common"
@@ -26,6 +25,22 @@ import (
 // 对端收到触发报文,也上报自己的触发报文
 // 服务器收到两边的公网地址后，分别发送对端的地址
 // 两边收到对端地址，开始尝试P2P
+// 触发消息中附带本端候选地址(类似ICE的host候选):公网IPv6地址及交互Sock的内网IPv4地址
+// 双方公网出口IP相同(同一NAT后)时优先连接对端内网地址,避免NAT不支持回流,同时向公网地址打洞
+// 双方都有公网IPv6时优先以IPv6直连(通常没有NAT),失败后再以IPv4打洞
+// 一方为对称型NAT时使用端口预测:本端对称时批量打开新的本地端口,对端对称时探测对端公网端口之后的一段端口,
+// 所有套接字同时发送打洞报文,保留最先收到对端打洞报文的一个
+
+// 打洞失败时可经其他终端中转:开启中转(relay)的终端定时向P2P直连的终端发送自己直连的终端及往返时间,
+// 发送方选择与本终端及目的终端都已直连、往返时间之和最小的终端中转,数据由源终端端到端加密,中转终端只转发
+
+const (
+	p2pPredictCount   = 32 // 端口预测打洞附加的套接字数
+	p2pPredictMaxFail = 3  // 端口预测打洞失败次数上限,超过后只经服务端转发
+	p2pRttInterval    = 3  // P2P连接发送测量心跳的间隔(秒)
+	relayInfoInterval = 10 // 发送中转信息的间隔(秒)
+	relayInfoExpire   = 30 // 中转信息有效时间(秒)
+)
 
 type P2pService struct {
 	running       bool
@@ -33,10 +48,14 @@ type P2pService struct {
 	cancelFunc    context.CancelFunc
 
 	p2pFailedTime  int64
+	relayInfoTime  int64     // 上次发送中转信息的时间
 	punchSocks     *sync.Map //map[uint64]*model.PunchSockContext // 启动后 p2pFailedTime S 没有移除就是已经失败，mac移入失败名
 	p2pTrySocks    *sync.Map //map[uint64]*model.PeerSockContext  // 正在尝试建立的p2p
 	p2pSocks       *sync.Map //map[uint64]*model.PeerSockContext // 已建立的p2p 连接
 	hisP2PFailInfo *sync.Map //map[uint64]*model.PunchFailInfo // 尝试失败的p2p
+	relayPaths     *sync.Map //map[uint64]*relayPath // 经其他终端中转的路径质量
+	activePeers    *sync.Map //map[uint64]int64 // 最近有数据往来的终端及时间
+	mtuProbes      *sync.Map //map[uint64]chan uint32 // 正在探测路径MTU的终端,接收探测应答
 }
 
 func NewP2pService() *P2pService {
@@ -53,6 +72,9 @@ func (r *P2pService) Start() (err error) {
 	r.p2pTrySocks = &sync.Map{}
 	r.p2pSocks = &sync.Map{}
 	r.hisP2PFailInfo = &sync.Map{}
+	r.relayPaths = &sync.Map{}
+	r.activePeers = &sync.Map{}
+	r.mtuProbes = &sync.Map{}
 	r.p2pFailedTime = int64(config.AppConfig.Heartbeat)
 	go r.stateCheck()
 	return err
@@ -88,9 +110,8 @@ func (r *P2pService) stateCheck() {
 						v.Connected = false
 						toRemoveP2P = append(toRemoveP2P, v)
 					} else {
-						msgFrame := &protocol.MsgDataFrame{SrcMac: config.AppConfig.TapConfig.HwMac, DstMac: v.PeerMac, MsgType: protocol.MsgType_Msg_Ping}
 						v.PingTryCount++
-						v.Handler.Write(msgFrame)
+						v.Handler.Write(peerPing(v))
 					}
 				} else if uint32(now-v.LastReceive) > config.AppConfig.Offline {
 					v.Bootstrap.Stop()
@@ -105,12 +126,16 @@ func (r *P2pService) stateCheck() {
 				}
 				app.WailsApp.UpdatePeers()
 			}
+			r.measureRtt(now)
+			r.probeRelays(now)
+			r.probeMtus(now)
+			r.sendRelayInfo(now)
 
 			toRemoveP2PTry := make([]*model.PeerSockContext, 0)
 			r.p2pTrySocks.Range(func(key, value interface{}) bool {
 				v := value.(*model.PeerSockContext)
 				if v.TryConnCount >= config.AppConfig.P2pTryCount {
-					v.Bootstrap.Stop()
+					stopTrySock(v, nil)
 					v.Connected = false
 					toRemoveP2PTry = append(toRemoveP2PTry, v)
 				} else {
@@ -126,6 +151,11 @@ func (r *P2pService) stateCheck() {
 			if len(toRemoveP2PTry) > 0 {
 				for _, u := range toRemoveP2PTry {
 					r.saveFailPunchInfo(u.PeerMac)
+					if u.Ipv6 {
+						if v, ok := r.hisP2PFailInfo.Load(u.PeerMac); ok {
+							v.(*model.PunchFailInfo).Ipv6Failed = true
+						}
+					}
 					r.p2pTrySocks.Delete(u.PeerMac)
 				}
 			}
@@ -163,7 +193,7 @@ func (r *P2pService) stopP2pSockets() {
 	r.p2pTrySocks.Range(func(key, value interface{}) bool {
 		v := value.(*model.PeerSockContext)
 		if v.Bootstrap != nil {
-			v.Bootstrap.Stop()
+			stopTrySock(v, nil)
 			v.Connected = false
 		}
 		return true
@@ -179,8 +209,15 @@ func (r *P2pService) TryForwardMessage(mac uint64, msg *protocol.MsgDataFrame) b
 	if !common.IsUniCast(mac) {
 		return false
 	}
+	if msg.MsgType == protocol.MsgType_Msg_Packet {
+		r.markActive(mac)
+	}
 	if v, ok := r.p2pSocks.Load(mac); ok { // P2P
 		sock := v.(*model.PeerSockContext)
+		if relay := r.preferredRelay(mac, sock); relay != nil && msg.MsgType == protocol.MsgType_Msg_Packet {
+			relayWrite(relay, msg)
+			return true
+		}
 		if sock.Handler != nil {
 			sock.Handler.Write(msg)
 			return true
@@ -193,24 +230,143 @@ func (r *P2pService) TryForwardMessage(mac uint64, msg *protocol.MsgDataFrame) b
 			app.Logger.Debug("P2P服务启动失败:", err)
 		}
 	}
+	if msg.MsgType == protocol.MsgType_Msg_Packet {
+		if relay := r.findRelay(mac); relay != nil && relay.Handler != nil {
+			relayWrite(relay, msg)
+			return true
+		}
+	}
 	return false
 }
 
+// 可经其中转到目的终端的P2P连接:服务端告知已开启中转,且最近的中转信息中与目的终端直连
+func (r *P2pService) relaySock(viaMac uint64, dstMac uint64) *model.PeerSockContext {
+	v, ok := r.p2pSocks.Load(viaMac)
+	if !ok {
+		return nil
+	}
+	sock := v.(*model.PeerSockContext)
+	if sock.Handler == nil {
+		return nil
+	}
+	if _, ok := sock.RelayRtt(dstMac, relayInfoExpire); !ok {
+		return nil
+	}
+	if peer := app.RuntimeService.FindPeer(viaMac); peer == nil || !peer.Relay {
+		return nil
+	}
+	return sock
+}
+
+// 待测量的中转终端:在可中转的终端中按本端测得的往返时间与其声明的往返时间之和选择,
+// 声明的往返时间只用于决定先测量哪条路径,exclude为测量失败需要跳过的终端
+func (r *P2pService) relayCandidate(dstMac uint64, exclude uint64) *model.PeerSockContext {
+	var best *model.PeerSockContext
+	bestRtt := uint32(0)
+	r.p2pSocks.Range(func(key, value interface{}) bool {
+		mac := key.(uint64)
+		if mac == exclude || mac == dstMac || !value.(*model.PeerSockContext).Quality.Measured() {
+			return true
+		}
+		v := r.relaySock(mac, dstMac)
+		if v == nil {
+			return true
+		}
+		claimed, _ := v.RelayRtt(dstMac, relayInfoExpire)
+		if rtt := v.Quality.Rtt() + claimed; best == nil || rtt < bestRtt {
+			best, bestRtt = v, rtt
+		}
+		return true
+	})
+	return best
+}
+
+// 选择中转终端:只使用经中转心跳应答确认过的路径,按测得的端到端往返时间判断;
+// 已知到服务端的往返时间时,中转路径比经服务端转发(按两倍估算)更慢则不使用
+func (r *P2pService) findRelay(dstMac uint64) *model.PeerSockContext {
+	path := r.loadRelayPath(dstMac)
+	if path == nil || !path.quality.Measured() {
+		return nil
+	}
+	relay := r.relaySock(path.via, dstMac)
+	if relay == nil {
+		return nil
+	}
+	if stats := app.RuntimeService.GetStats(); stats != nil && stats.ServerRtt > 0 && path.quality.Rtt() > 2*stats.ServerRtt {
+		return nil
+	}
+	return relay
+}
+
+// 中转其他终端的报文,只转发到与本终端P2P直连的终端
+func (r *P2pService) RelayForward(msg *protocol.MsgDataFrame) error {
+	if !config.AppConfig.Relay {
+		return errors.New("本终端未开启中转")
+	}
+	if msg == nil || !common.IsUniCast(msg.DstMac) {
+		return errors.New("无效的中转报文")
+	}
+	v, ok := r.p2pSocks.Load(msg.DstMac)
+	if !ok || v.(*model.PeerSockContext).Handler == nil {
+		return errors.New("没有到目的终端的P2P连接")
+	}
+	v.(*model.PeerSockContext).Handler.Write(&protocol.MsgDataFrame{MsgType: protocol.MsgType_Msg_Relay,
+		SrcMac: config.AppConfig.TapConfig.HwMac, DstMac: msg.DstMac, Relay: msg})
+	return nil
+}
+
+// 开启中转时,定时向每个P2P连接发送本终端直连的其他终端
+func (r *P2pService) sendRelayInfo(now int64) {
+	if !config.AppConfig.Relay || now-r.relayInfoTime < relayInfoInterval {
+		return
+	}
+	r.relayInfoTime = now
+	socks := make([]*model.PeerSockContext, 0)
+	r.p2pSocks.Range(func(key, value interface{}) bool {
+		socks = append(socks, value.(*model.PeerSockContext))
+		return true
+	})
+	if len(socks) < 2 {
+		return
+	}
+	for _, to := range socks {
+		if to.Handler == nil {
+			continue
+		}
+		links := make([]*protocol.RelayLink, 0, len(socks)-1)
+		for _, s := range socks {
+			if s != to && s.Quality.Measured() {
+				links = append(links, &protocol.RelayLink{PeerMac: s.PeerMac, Rtt: s.Quality.Rtt()})
+			}
+		}
+		to.Handler.Write(&protocol.MsgDataFrame{SrcMac: config.AppConfig.TapConfig.HwMac, DstMac: to.PeerMac,
+			MsgType: protocol.MsgType_Msg_RelayInfo, RelayInfo: &protocol.MsgRelayInfo{Links: links}})
+	}
+}
+
 // 已连接的 p2pSocks 在外部判断
 func (r *P2pService) isNeedP2P(dstMac uint64) bool {
+	if app.RuntimeService.ServerTransport() != model.TransportUdp { // UDP不通时无法打洞
+		return false
+	}
 	if common.IsUniCast(dstMac) {
+		peer := app.RuntimeService.FindPeer(dstMac)
+		if peer == nil {
+			return false
+		}
+		// 端口预测成功率较低且占用较多端口,失败几次后不再尝试
+		mode := model.PunchModeOf(app.RuntimeService.NatType(), model.NatType(peer.NatType))
+		if mode == model.PunchNone {
+			return false
+		}
 		if v, ok := r.hisP2PFailInfo.Load(dstMac); ok {
 			p := v.(*model.PunchFailInfo)
-			if p.FailedCount >= 10 || // 一方为 NatSymmetric 另一方为 NatSymmetric或NatPortCone 一般无法打洞
+			if p.FailedCount >= 10 || (mode == model.PunchPredict && p.FailedCount >= p2pPredictMaxFail) ||
 				uint32(time.Now().Sub(p.FailedTime).Seconds()) < config.AppConfig.P2pRetryInterval {
 				return false
 			}
 		}
 
-		if app.RuntimeService.FindPeer(dstMac) == nil {
-			return false
-		}
-
 		if _, ok := r.p2pTrySocks.Load(dstMac); ok {
 			return false
 		}
@@ -254,22 +410,71 @@ func (r *P2pService) newPunchSock(dstMac uint64) (*protocol.Sock, error) {
 			AddLast(NewP2pHandler(ctx))
 	}
 	ctx.Bootstrap.ClientInitializer(initializer)
-	url := fmt.Sprint("//", config.AppConfig.ServerIp, ":", config.AppConfig.ServerPort)
-	lAddr := &net.UDPAddr{IP: net.IPv4zero, Port: 0}
+	url := "//" + app.RuntimeService.ServerAddr()
+	lAddr := &net.UDPAddr{Port: 0}
 
 	ch, err := ctx.Bootstrap.Transport(udp.New()).Connect(url, nil, transport.WithLocalAddr(lAddr))
 	if err != nil {
 		r.saveFailPunchInfo(dstMac)
 		return nil, errors.New(fmt.Sprintf("P2P交互Sock连接失败:%s", err.Error()))
 	}
-	if ip, port, e := common.ExtractAddrFromStr(ch.LocalAddr()); e == nil {
-		ctx.LocalSock = &protocol.Sock{Addr: ip, Port: port, Family: syscall.AF_INET}
+	if sock, e := model.NewSock(ch.LocalAddr()); e == nil {
+		ctx.LocalSock = sock
+		ctx.Candidates = r.ipv6Candidates(dstMac, sock.Port)
+		if sock.Family == model.FamilyIpv4 { // 内网地址
+			ctx.Candidates = append(ctx.Candidates, sock)
+		}
 		return ctx.LocalSock, nil
 	} else {
 		return nil, e
 	}
 }
 
+// 公网IPv6直连候选地址,端口与交互Sock相同;与该终端IPv6直连失败过时不再提供
+func (r *P2pService) ipv6Candidates(dstMac uint64, port uint32) []*protocol.Sock {
+	if v, ok := r.hisP2PFailInfo.Load(dstMac); ok && v.(*model.PunchFailInfo).Ipv6Failed {
+		return nil
+	}
+	candidates := make([]*protocol.Sock, 0)
+	for _, ip := range common.GetGlobalIpv6() {
+		if sock, err := model.IpToSock(ip, port); err == nil {
+			candidates = append(candidates, sock)
+		}
+	}
+	return candidates
+}
+
+// 双方都有IPv6候选地址时返回本端及对端地址
+func ipv6Pair(self, other []*protocol.Sock) (*protocol.Sock, *protocol.Sock) {
+	self6, other6 := firstIpv6(self), firstIpv6(other)
+	if self6 == nil || other6 == nil {
+		return nil, nil
+	}
+	return self6, other6
+}
+
+func firstIpv6(candidates []*protocol.Sock) *protocol.Sock {
+	for _, s := range candidates {
+		if s != nil && s.Family == model.FamilyIpv6 && len(s.Addr6) == net.IPv6len {
+			return s
+		}
+	}
+	return nil
+}
+
+// 双方公网出口IP相同时(同一NAT后)返回对端内网候选地址
+func lanCandidate(self, other *protocol.Sock, candidates []*protocol.Sock) *protocol.Sock {
+	if self == nil || other == nil || self.Family != model.FamilyIpv4 || other.Family != model.FamilyIpv4 || self.Addr != other.Addr {
+		return nil
+	}
+	for _, s := range candidates {
+		if s != nil && s.Family == model.FamilyIpv4 && s.Addr != other.Addr {
+			return s
+		}
+	}
+	return nil
+}
+
 func (r *P2pService) SendP2PTrigger(dstMac uint64) error {
 	if v, ok := r.punchSocks.Load(dstMac); ok {
 		p := v.(*model.PunchSockContext)
@@ -281,7 +486,7 @@ func (r *P2pService) SendP2PTrigger(dstMac uint64) error {
 		if p.Handler == nil {
 			return errors.New("无效的P2P交互Sock")
 		}
-		triggerMsg := &protocol.MsgP2PTrigger{SrcMac: config.AppConfig.TapConfig.HwMac, DstMac: dstMac}
+		triggerMsg := &protocol.MsgP2PTrigger{SrcMac: config.AppConfig.TapConfig.HwMac, DstMac: dstMac, Candidates: p.Candidates}
 		msg := &protocol.MsgPeerFrame{MsgType: protocol.MsgType_Msg_P2PTrigger, PeerMac: config.AppConfig.TapConfig.HwMac}
 		msg.MsgP2PTrigger = triggerMsg
 		p.Handler.Write(msg)
@@ -324,31 +529,107 @@ func (r *P2pService) ProcessP2PAck(msgAck *protocol.MsgP2PAck) error {
 		return errors.New("没有有效的P2P交互Sock")
 	}
 	punchSock := v.(*model.PunchSockContext)
-	// 创建Bootstrap
-	ctx := &model.PeerSockContext{PeerMac: msgAck.PeerMac, Bootstrap: netty.NewBootstrap(), Heartbeat: 5, Offline: 15}
+	ctx := &model.PeerSockContext{PeerMac: msgAck.PeerMac, Heartbeat: 5, Offline: 15, Quality: model.NewLinkQuality()}
 	r.p2pTrySocks.Store(msgAck.PeerMac, ctx)
+	local, remote := punchSock.LocalSock, msgAck.OtherExternSock
+	if lan := lanCandidate(msgAck.SelfExternSock, msgAck.OtherExternSock, msgAck.OtherCandidates); lan != nil {
+		remote = lan
+		ctx.Lan = true
+	} else if self6, other6 := ipv6Pair(punchSock.Candidates, msgAck.OtherCandidates); other6 != nil {
+		local, remote = self6, other6
+		ctx.Ipv6 = true
+	}
+	lAddr, _ := net.ResolveUDPAddr("udp", model.SockAddr(local))
+	if err := r.dialPeer(ctx, lAddr, remote); err != nil {
+		r.p2pTrySocks.Delete(msgAck.PeerMac)
+		return errors.New(fmt.Sprintf("p2p connect peer failed:%s", err.Error()))
+	}
+	if ctx.Lan {
+		// 公网出口相同也可能是不同的内网(如运营商级NAT),同时向对端公网地址打洞
+		probe := &model.PeerSockContext{PeerMac: ctx.PeerMac, Heartbeat: ctx.Heartbeat, Offline: ctx.Offline, Probe: true,
+			Quality: model.NewLinkQuality()}
+		if err := r.dialPeer(probe, lAddr, msgAck.OtherExternSock); err == nil {
+			ctx.Probes = []*model.PeerSockContext{probe}
+		}
+	} else if !ctx.Ipv6 {
+		selfNat := app.RuntimeService.NatType()
+		otherNat := model.NatUnKnown
+		if peer := app.RuntimeService.FindPeer(msgAck.PeerMac); peer != nil {
+			otherNat = model.NatType(peer.NatType)
+		}
+		if model.PunchModeOf(selfNat, otherNat) == model.PunchPredict {
+			r.predictPeer(ctx, lAddr, remote, selfNat == model.NatSymmetric, otherNat == model.NatSymmetric)
+		}
+	}
+	return nil
+}
+
+// 创建到对端的连接
+func (r *P2pService) dialPeer(ctx *model.PeerSockContext, lAddr *net.UDPAddr, remote *protocol.Sock) error {
+	ctx.Bootstrap = netty.NewBootstrap()
 	// 子连接的流水线配置
 	var initializer = func(channel netty.Channel) {
 		channel.Pipeline().
 			AddLast(netty.ReadIdleHandler(time.Duration(ctx.Heartbeat) * time.Second)).
-			AddLast(format.ProtobufCodec(1, uint32(config.AppConfig.MaxPacketSize))).
+			AddLast(format.P2PProtobufCodec(uint32(config.AppConfig.MaxPacketSize))).
 			AddLast(NewDataHandler(ctx))
 	}
 	ctx.Bootstrap.ClientInitializer(initializer)
-	url := fmt.Sprint("//", common.Uint32toIpV4(msgAck.OtherExternSock.Addr), ":", msgAck.OtherExternSock.Port)
-	lAddr, _ := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", common.Uint32toIpV4(punchSock.LocalSock.Addr), punchSock.LocalSock.Port))
+	url := "//" + model.SockAddr(remote)
 
 	ch, err := ctx.Bootstrap.Transport(udp.New()).Connect(url, nil, transport.WithLocalAddr(lAddr))
 	if err != nil {
-		r.p2pTrySocks.Delete(msgAck.PeerMac)
-		return errors.New(fmt.Sprintf("p2p connect peer failed:%s", err.Error()))
+		ctx.Bootstrap.Stop()
+		return err
 	}
-	if ip, port, e := common.ExtractAddrFromStr(ch.LocalAddr()); e == nil {
-		ctx.LocalSock = &protocol.Sock{Addr: ip, Port: port, Family: syscall.AF_INET}
+	if sock, e := model.NewSock(ch.LocalAddr()); e == nil {
+		ctx.LocalSock = sock
 	}
 	return nil
 }
 
+// 端口预测打洞,附加的套接字记录在 ctx.Probes 中
+// selfSym 本端为对称型NAT,每个套接字使用新的本地端口以获得新的映射;
+// otherSym 对端为对称型NAT,依次探测对端公网端口之后的端口(多数NAT顺序分配映射端口)
+func (r *P2pService) predictPeer(ctx *model.PeerSockContext, lAddr *net.UDPAddr, remote *protocol.Sock, selfSym, otherSym bool) {
+	probes := make([]*model.PeerSockContext, 0, p2pPredictCount)
+	for i := 1; i <= p2pPredictCount; i++ {
+		target := &protocol.Sock{Family: remote.Family, Addr: remote.Addr, Addr6: remote.Addr6, Port: remote.Port}
+		if otherSym {
+			target.Port += uint32(i)
+			if target.Port > 65535 {
+				break
+			}
+		}
+		local := lAddr
+		if selfSym {
+			local = &net.UDPAddr{IP: lAddr.IP, Port: 0}
+		}
+		probe := &model.PeerSockContext{PeerMac: ctx.PeerMac, Heartbeat: ctx.Heartbeat, Offline: ctx.Offline, Probe: true,
+			Quality: model.NewLinkQuality()}
+		if err := r.dialPeer(probe, local, target); err != nil {
+			app.Logger.Debug("端口预测套接字创建失败:", model.SockAddr(target), err)
+			continue
+		}
+		probes = append(probes, probe)
+	}
+	ctx.Probes = probes
+	app.Logger.Debug("端口预测打洞:", model.SockAddr(remote), "附加套接字", len(probes))
+}
+
+// 停止尝试中的套接字及端口预测的附加套接字,keep 为打洞成功后保留的套接字
+func stopTrySock(ctx *model.PeerSockContext, keep *model.PeerSockContext) {
+	if ctx != keep && ctx.Bootstrap != nil {
+		ctx.Bootstrap.Stop()
+	}
+	for _, p := range ctx.Probes {
+		if p != keep {
+			p.Bootstrap.Stop()
+		}
+	}
+	ctx.Probes = nil
+}
+
 // 互发打洞消息
 func (r *P2pService) SendP2PTry(dstMac uint64) error {
 	sock, _ := r.p2pTrySocks.Load(dstMac)
@@ -359,6 +640,11 @@ func (r *P2pService) SendP2PTry(dstMac uint64) error {
 		if ctx.Handler != nil {
 			msgFrame := &protocol.MsgDataFrame{SrcMac: config.AppConfig.TapConfig.HwMac, DstMac: ctx.PeerMac, MsgType: protocol.MsgType_Msg_P2PTry}
 			ctx.Handler.Write(msgFrame)
+			for _, p := range ctx.Probes {
+				if p.Handler != nil {
+					p.Handler.Write(msgFrame)
+				}
+			}
 			return nil
 		}
 	}
@@ -373,14 +659,24 @@ func (r *P2pService) P2PSuccess(dstMac uint64, sock *model.PeerSockContext) erro
 	}
 
 	if v, ok := r.p2pTrySocks.Load(dstMac); ok {
-		ctx := v.(*model.PeerSockContext)
+		tryCtx := v.(*model.PeerSockContext)
+		ctx := tryCtx
+		if sock.Probe { // 端口预测的附加套接字先收到打洞报文
+			ctx = sock
+			app.Logger.Debug("端口预测打洞成功:", model.SockAddr(ctx.LocalSock), "->", ctx.Handler.Channel().RemoteAddr())
+		}
+		stopTrySock(tryCtx, ctx)
 		r.p2pSocks.Store(dstMac, ctx)
 		r.p2pTrySocks.Delete(dstMac)
 		msgFrame := &protocol.MsgDataFrame{SrcMac: config.AppConfig.TapConfig.HwMac, DstMac: ctx.PeerMac, MsgType: protocol.MsgType_Msg_P2PTry}
 		ctx.Handler.Write(msgFrame)
 		peer := app.RuntimeService.FindPeer(dstMac)
 		if peer != nil {
-			app.Logger.Info("P2P连接成功:->", peer.PeerName)
+			if ctx.Lan {
+				app.Logger.Info("P2P连接成功(内网直连):->", peer.PeerName)
+			} else {
+				app.Logger.Info("P2P连接成功:->", peer.PeerName)
+			}
 		}
 	} else {
 		r.p2pSocks.Store(dstMac, sock)
@@ -393,7 +689,7 @@ func (r *P2pService) PeerStateChanged(mac uint64, _ bool) error {
 
 	if v, ok := r.p2pTrySocks.Load(mac); ok && v != nil {
 		p := v.(*model.PeerSockContext)
-		p.Bootstrap.Stop()
+		stopTrySock(p, nil)
 	}
 	r.p2pTrySocks.Delete(mac)
 
@@ -413,6 +709,14 @@ func (r *P2pService) IsP2P(dstMac uint64) bool {
 	return false
 }
 
+// 没有P2P连接或中转路径质量更好时,经其他终端中转
+func (r *P2pService) IsRelayed(dstMac uint64) bool {
+	if v, ok := r.p2pSocks.Load(dstMac); ok {
+		return r.preferredRelay(dstMac, v.(*model.PeerSockContext)) != nil
+	}
+	return r.findRelay(dstMac) != nil
+}
+
 func (r *P2pService) DeleteFailedP2P(mac uint64) {
 	r.hisP2PFailInfo.Delete(mac)
 }
@@ -420,10 +724,11 @@ func (r *P2pService) DeleteFailedP2P(mac uint64) {
 func (r *P2pService) ResetP2PSocks() {
 	common.ClearMap(r.punchSocks)
 	common.ClearMap(r.hisP2PFailInfo)
+	common.ClearMap(r.relayPaths)
 
 	r.p2pTrySocks.Range(func(key, value interface{}) bool {
 		v := value.(*model.PeerSockContext)
-		v.Bootstrap.Stop()
+		stopTrySock(v, nil)
 		return true
 	})
 	common.ClearMap(r.p2pTrySocks)
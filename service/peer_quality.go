@@ -0,0 +1,142 @@
+package service
+
+import (
+	"time"
+	"vilan/config"
+	"vilan/model"
+	"vilan/protocol"
+)
+
+// 终端链路质量:
+// 定时向P2P连接发送带编号(seq)的心跳,按应答计算平滑往返时间、抖动及丢包率;
+// 最近有数据往来且存在中转路径的终端,同时经中转终端发送心跳,测量中转路径的端到端质量,收到应答后才经该路径中转数据,
+// 连续 relayProbeMax 次心跳没有应答时改为测量其他中转终端;
+// P2P与中转都可用时,中转路径评分低于P2P的80%才改走中转,避免两条路径质量接近时来回切换
+
+const (
+	activeExpire  = 60 // 超过该时间(秒)没有数据往来的终端不再测量中转路径
+	relayProbeMax = 5  // 中转路径没有应答时最多发送的心跳次数
+)
+
+type relayPath struct {
+	via     uint64 // 中转终端
+	quality *model.LinkQuality
+	probes  int // 已发送的心跳次数,只由测量协程访问
+}
+
+func peerPing(ctx *model.PeerSockContext) *protocol.MsgDataFrame {
+	return &protocol.MsgDataFrame{SrcMac: config.AppConfig.TapConfig.HwMac, DstMac: ctx.PeerMac,
+		MsgType: protocol.MsgType_Msg_Ping, Seq: ctx.Quality.Sent()}
+}
+
+func relayWrite(relay *model.PeerSockContext, msg *protocol.MsgDataFrame) {
+	relay.Handler.Write(&protocol.MsgDataFrame{MsgType: protocol.MsgType_Msg_Relay, SrcMac: config.AppConfig.TapConfig.HwMac,
+		DstMac: relay.PeerMac, Relay: msg})
+}
+
+// 定时向P2P连接发送心跳,应答时计算往返时间、抖动及丢包率
+func (r *P2pService) measureRtt(now int64) {
+	r.p2pSocks.Range(func(key, value interface{}) bool {
+		v := value.(*model.PeerSockContext)
+		if v.Handler != nil && now-v.RttTime >= p2pRttInterval {
+			v.RttTime = now
+			v.Handler.Write(peerPing(v))
+		}
+		return true
+	})
+}
+
+// 记录终端最近的数据往来时间,每秒最多更新一次
+func (r *P2pService) markActive(mac uint64) {
+	now := time.Now().Unix()
+	if v, ok := r.activePeers.Load(mac); !ok || v.(int64) != now {
+		r.activePeers.Store(mac, now)
+	}
+}
+
+// 经中转终端向最近有数据往来的终端发送心跳
+func (r *P2pService) probeRelays(now int64) {
+	r.activePeers.Range(func(key, value interface{}) bool {
+		mac := key.(uint64)
+		if now-value.(int64) > activeExpire {
+			r.activePeers.Delete(mac)
+			r.relayPaths.Delete(mac)
+			return true
+		}
+		path := r.loadRelayPath(mac)
+		var relay *model.PeerSockContext
+		if path != nil {
+			relay = r.relaySock(path.via, mac)
+		}
+		if relay == nil || (path.probes >= relayProbeMax && !path.quality.Measured()) {
+			exclude := uint64(0)
+			if relay != nil {
+				exclude = path.via
+			}
+			if relay = r.relayCandidate(mac, exclude); relay == nil {
+				r.relayPaths.Delete(mac)
+				return true
+			}
+			if path == nil || path.via != relay.PeerMac { // 中转终端变化后重新统计
+				path = &relayPath{via: relay.PeerMac, quality: model.NewLinkQuality()}
+				r.relayPaths.Store(mac, path)
+			}
+		}
+		path.probes++
+		relayWrite(relay, &protocol.MsgDataFrame{SrcMac: config.AppConfig.TapConfig.HwMac, DstMac: mac,
+			MsgType: protocol.MsgType_Msg_Ping, Seq: path.quality.Sent()})
+		return true
+	})
+}
+
+func (r *P2pService) loadRelayPath(mac uint64) *relayPath {
+	if r.relayPaths == nil {
+		return nil
+	}
+	if v, ok := r.relayPaths.Load(mac); ok {
+		return v.(*relayPath)
+	}
+	return nil
+}
+
+// 经中转终端收到的心跳应答
+func (r *P2pService) ProcessRelayPong(srcMac uint64, viaMac uint64, seq uint64) {
+	if path := r.loadRelayPath(srcMac); path != nil && path.via == viaMac {
+		path.quality.Received(seq)
+	}
+}
+
+// 已有P2P连接时,中转路径质量明显更好则返回中转终端
+func (r *P2pService) preferredRelay(mac uint64, sock *model.PeerSockContext) *model.PeerSockContext {
+	path := r.loadRelayPath(mac)
+	if path == nil || !path.quality.Measured() || !sock.Quality.Measured() {
+		return nil
+	}
+	if path.quality.Score()*5 >= sock.Quality.Score()*4 {
+		return nil
+	}
+	if v, ok := r.p2pSocks.Load(path.via); ok && v.(*model.PeerSockContext).Handler != nil {
+		return v.(*model.PeerSockContext)
+	}
+	return nil
+}
+
+// 当前使用路径的往返时间、抖动及丢包率,没有测量结果时返回nil
+func (r *P2pService) PeerQuality(mac uint64) *model.QualityInfo {
+	if !r.running {
+		return nil
+	}
+	if v, ok := r.p2pSocks.Load(mac); ok {
+		sock := v.(*model.PeerSockContext)
+		if r.preferredRelay(mac, sock) == nil {
+			if !sock.Quality.Measured() {
+				return nil
+			}
+			return sock.Quality.Info()
+		}
+	}
+	if path := r.loadRelayPath(mac); path != nil && path.quality.Measured() {
+		return path.quality.Info()
+	}
+	return nil
+}
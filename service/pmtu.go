@@ -0,0 +1,342 @@
+package service
+
+import (
+	"encoding/binary"
+	"github.com/golang/protobuf/proto"
+	"math"
+	"net"
+	"sync/atomic"
+	"time"
+	"vilan/app"
+	"vilan/common"
+	"vilan/config"
+	"vilan/model"
+	"vilan/protocol"
+)
+
+// 路径MTU探测:
+// 到服务端使用独立的UDP套接字(设置不分片)发送填充到指定大小的NAT探测请求,到P2P终端经已建立的连接发送填充的心跳,
+// 探测期间该连接的套接字设置不分片,探测结束后恢复,避免内核分片使大于路径MTU的探测报文也能收到应答;
+// 按是否收到应答二分查找可通过的最大报文。服务端路径MTU减去隧道封装开销后作为虚拟网卡MTU(配置了mtu时使用配置值),
+// 到某个P2P终端的路径MTU更小时,调整与其之间TCP连接请求(SYN)的MSS,避免大报文在PPPoE、LTE等链路上被静默丢弃。
+
+const (
+	pmtuMin      = 1200 // 探测下限,该大小没有应答时认为对端不支持探测
+	pmtuStep     = 8
+	pmtuTimeout  = time.Second
+	pmtuRetry    = 2
+	pmtuInterval = 10 * time.Minute
+)
+
+// 配置的虚拟网卡MTU,未配置或超出范围时返回0
+func configuredMtu() int {
+	mtu := int(config.AppConfig.TapConfig.Mtu)
+	if mtu == 0 {
+		return 0
+	}
+	if mtu < model.MtuMin || mtu > model.MtuMax {
+		app.Logger.Warn("虚拟网卡MTU超出范围,使用自动设置:", mtu)
+		return 0
+	}
+	return mtu
+}
+
+// 二分查找可通过的最大IP报文,probe返回该大小的报文是否收到应答
+func searchPathMtu(probe func(size int) bool) (int, error) {
+	if !probe(pmtuMin) {
+		return 0, errNoProbeAck
+	}
+	lo, hi := pmtuMin, model.PathMtuDefault
+	if probe(hi) {
+		return hi, nil
+	}
+	for hi-lo > pmtuStep {
+		mid := (lo + hi) / 2
+		if probe(mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
+
+// 填充长度,使编码后的报文(含长度前缀)为payload字节,encodedSize返回给定填充时的编码长度
+func paddingFor(payload int, encodedSize func(pad int) int) int {
+	pad := 0
+	for i := 0; i < 3; i++ {
+		n := encodedSize(pad)
+		n += proto.SizeVarint(uint64(n))
+		if n == payload {
+			break
+		}
+		if pad += payload - n; pad < 0 {
+			return 0
+		}
+	}
+	return pad
+}
+
+func udpPayload(size int, ipv6 bool) int {
+	if ipv6 {
+		return size - 40 - 8
+	}
+	return size - 20 - 8
+}
+
+// 探测到服务端的路径MTU
+func ProbePathMtu(server string, mac uint64) (int, bool, error) {
+	addr, err := net.ResolveUDPAddr("udp", server)
+	if err != nil {
+		return 0, false, err
+	}
+	ipv6 := addr.IP.To4() == nil
+	network := "udp4"
+	if ipv6 {
+		network = "udp6"
+	}
+	conn, err := net.ListenUDP(network, nil)
+	if err != nil {
+		return 0, ipv6, err
+	}
+	defer conn.Close()
+	if err = common.SetDontFragment(conn, true); err != nil {
+		app.Logger.Debug("探测套接字设置不分片失败:", err)
+	}
+	p := &natProber{conn: conn, mac: mac, buf: make([]byte, model.SizeMaxPacket)}
+	mtu, err := searchPathMtu(func(size int) bool {
+		pad := paddingFor(udpPayload(size, ipv6), func(pad int) int {
+			return proto.Size(&protocol.MsgPeerFrame{PeerMac: mac, MsgType: protocol.MsgType_Msg_NatProbe,
+				MsgNatProbe: &protocol.MsgNatProbe{Cookie: math.MaxUint32, Padding: make([]byte, pad)}})
+		})
+		p.padding = make([]byte, pad)
+		_, e := p.probe(addr, false, false)
+		return e == nil
+	})
+	return mtu, ipv6, err
+}
+
+// 注册成功后及每隔 pmtuInterval 探测一次到服务端的路径MTU,并按结果设置虚拟网卡MTU
+func (r *RuntimeService) startPmtuDetect() {
+	// TCP传输时由TCP分段,不需要探测
+	if r.transport != model.TransportUdp || time.Since(r.pmtuDetectTime) < pmtuInterval || !atomic.CompareAndSwapInt32(&r.pmtuDetecting, 0, 1) {
+		return
+	}
+	r.pmtuDetectTime = time.Now()
+	go func() {
+		defer atomic.StoreInt32(&r.pmtuDetecting, 0)
+		pmtu, ipv6, err := ProbePathMtu(r.ServerAddr(), r.appConfig.TapConfig.HwMac)
+		if err != nil {
+			r.probeFailed("服务端路径MTU探测失败:", err)
+			return
+		}
+		atomic.StoreInt32(&r.serverPmtu, int32(pmtu))
+		mtu := configuredMtu()
+		if mtu == 0 {
+			mtu = model.TunnelMtu(pmtu, ipv6)
+		}
+		app.Logger.Info("服务端路径MTU:", pmtu, ",虚拟网卡MTU:", mtu)
+		if app.TunTapService != nil {
+			if err = app.TunTapService.SetMtu(mtu); err != nil {
+				app.Logger.Warn("虚拟网卡MTU设置失败:", err)
+			}
+		}
+	}()
+}
+
+// 探测到的服务端路径MTU,未探测时为0
+func (r *RuntimeService) ServerPmtu() int {
+	return int(atomic.LoadInt32(&r.serverPmtu))
+}
+
+// 定时探测P2P连接的路径MTU
+func (r *P2pService) probeMtus(now int64) {
+	r.p2pSocks.Range(func(key, value interface{}) bool {
+		v := value.(*model.PeerSockContext)
+		if v.Handler != nil && now-v.PmtuTime >= int64(pmtuInterval/time.Second) {
+			v.PmtuTime = now
+			go r.probePeerMtu(v)
+		}
+		return true
+	})
+}
+
+func (r *P2pService) probePeerMtu(ctx *model.PeerSockContext) {
+	acks := make(chan uint32, 4)
+	if _, ok := r.mtuProbes.LoadOrStore(ctx.PeerMac, acks); ok {
+		return
+	}
+	defer r.mtuProbes.Delete(ctx.PeerMac)
+	if conn := peerConn(ctx); conn != nil {
+		if err := common.SetDontFragment(conn, true); err != nil {
+			app.Logger.Debug("P2P套接字设置不分片失败:", err)
+		} else {
+			defer func() { _ = common.SetDontFragment(conn, false) }()
+		}
+	}
+	pmtu, err := searchPathMtu(func(size int) bool {
+		msg := &protocol.MsgDataFrame{SrcMac: config.AppConfig.TapConfig.HwMac, DstMac: ctx.PeerMac,
+			MsgType: protocol.MsgType_Msg_Ping, MtuProbe: uint32(size)}
+		pad := paddingFor(udpPayload(size, ctx.Ipv6), func(pad int) int {
+			msg.Data = make([]byte, pad)
+			return proto.Size(msg)
+		})
+		msg.Data = make([]byte, pad)
+		for i := 0; i < pmtuRetry; i++ {
+			handler := ctx.Handler
+			if handler == nil || !r.running {
+				return false
+			}
+			handler.Write(msg)
+			if waitMtuAck(acks, uint32(size)) {
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		app.Logger.Debug("P2P路径MTU探测失败:", ctx.PeerMac, err)
+		return
+	}
+	ctx.Pmtu = pmtu
+	app.Logger.Debug("P2P路径MTU:", ctx.PeerMac, pmtu)
+}
+
+// P2P连接的UDP套接字
+func peerConn(ctx *model.PeerSockContext) *net.UDPConn {
+	handler := ctx.Handler
+	if handler == nil {
+		return nil
+	}
+	conn, _ := handler.Channel().Transport().RawTransport().(*net.UDPConn)
+	return conn
+}
+
+func waitMtuAck(acks chan uint32, size uint32) bool {
+	timeout := time.After(pmtuTimeout)
+	for {
+		select {
+		case ack := <-acks:
+			if ack == size { // 忽略之前探测的迟到应答
+				return true
+			}
+		case <-timeout:
+			return false
+		}
+	}
+}
+
+// 收到路径MTU探测心跳的应答
+func (r *P2pService) ProcessMtuPong(mac uint64, size uint32) {
+	if v, ok := r.mtuProbes.Load(mac); ok {
+		select {
+		case v.(chan uint32) <- size:
+		default:
+		}
+	}
+}
+
+// 到该终端P2P连接的隧道MTU,没有P2P连接或未探测时为0
+func (r *P2pService) PeerMtu(mac uint64) int {
+	if !r.running {
+		return 0
+	}
+	if v, ok := r.p2pSocks.Load(mac); ok {
+		if sock := v.(*model.PeerSockContext); sock.Pmtu > 0 {
+			return model.TunnelMtu(sock.Pmtu, sock.Ipv6)
+		}
+	}
+	return 0
+}
+
+// 到该终端P2P连接的路径MTU,没有P2P连接或未探测时为0
+func (r *P2pService) PathMtu(mac uint64) int {
+	if !r.running {
+		return 0
+	}
+	if v, ok := r.p2pSocks.Load(mac); ok {
+		return v.(*model.PeerSockContext).Pmtu
+	}
+	return 0
+}
+
+// 以太网帧是否为TCP连接请求(SYN,含SYN+ACK)
+func isTcpSyn(frame []byte) bool {
+	tcp, _ := tcpSegment(frame)
+	return tcp != nil && tcp[13]&0x02 != 0
+}
+
+// 返回以太网帧中的TCP报文段及IP头长度,非TCP或分片时返回nil
+func tcpSegment(frame []byte) ([]byte, int) {
+	if len(frame) < model.SizeEthFrame+20 {
+		return nil, 0
+	}
+	ip := frame[model.SizeEthFrame:]
+	var tcp []byte
+	var ipHdr int
+	switch binary.BigEndian.Uint16(frame[12:14]) {
+	case ethTypeIpv4:
+		ipHdr = int(ip[0]&0x0F) * 4
+		if ip[0]>>4 != 4 || ipHdr < 20 || ip[9] != 6 || binary.BigEndian.Uint16(ip[6:8])&0x1FFF != 0 || len(ip) < ipHdr+20 {
+			return nil, 0
+		}
+		tcp = ip[ipHdr:]
+	case ethTypeIpv6:
+		ipHdr = 40
+		if len(ip) < ipHdr+20 || ip[0]>>4 != 6 || ip[6] != 6 { // 不处理扩展头
+			return nil, 0
+		}
+		tcp = ip[ipHdr:]
+	default:
+		return nil, 0
+	}
+	return tcp, ipHdr
+}
+
+// TCP SYN报文的MSS选项超过mtu允许的值时改小,并增量更新校验和
+func clampTcpMss(frame []byte, mtu int) bool {
+	tcp, ipHdr := tcpSegment(frame)
+	if tcp == nil || tcp[13]&0x02 == 0 {
+		return false
+	}
+	limit := mtu - ipHdr - 20
+	if limit <= 0 {
+		return false
+	}
+	optEnd := int(tcp[12]>>4) * 4
+	if optEnd > len(tcp) {
+		return false
+	}
+	for i := 20; i < optEnd; {
+		switch tcp[i] {
+		case 0: // 选项结束
+			return false
+		case 1: // NOP
+			i++
+			continue
+		}
+		if i+1 >= optEnd || tcp[i+1] < 2 || i+int(tcp[i+1]) > optEnd {
+			return false
+		}
+		if tcp[i] == 2 && tcp[i+1] == 4 {
+			mss := binary.BigEndian.Uint16(tcp[i+2:])
+			if int(mss) <= limit {
+				return false
+			}
+			binary.BigEndian.PutUint16(tcp[i+2:], uint16(limit))
+			sum := binary.BigEndian.Uint16(tcp[16:18])
+			binary.BigEndian.PutUint16(tcp[16:18], checksumUpdate(sum, mss, uint16(limit)))
+			return true
+		}
+		i += int(tcp[i+1])
+	}
+	return false
+}
+
+// 校验和增量更新(RFC 1624)
+func checksumUpdate(sum, old, new uint16) uint16 {
+	s := uint32(^sum) + uint32(^old) + uint32(new)
+	s = (s & 0xFFFF) + (s >> 16)
+	s = (s & 0xFFFF) + (s >> 16)
+	return ^uint16(s)
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"encoding/binary"
+	"testing"
+	"vilan/model"
+)
+
+// 以太网帧中的TCP SYN报文,options为TCP选项(长度为4的倍数)
+func tcpFrame(ipv6 bool, flags byte, options []byte) []byte {
+	ipHdr, etherType := 20, uint16(ethTypeIpv4)
+	if ipv6 {
+		ipHdr, etherType = 40, ethTypeIpv6
+	}
+	frame := make([]byte, model.SizeEthFrame+ipHdr+20+len(options))
+	binary.BigEndian.PutUint16(frame[12:14], etherType)
+	ip := frame[model.SizeEthFrame:]
+	if ipv6 {
+		ip[0], ip[6] = 0x60, 6
+		binary.BigEndian.PutUint16(ip[4:6], uint16(20+len(options)))
+		copy(ip[8:24], []byte{0xfd, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1})
+		copy(ip[24:40], []byte{0xfd, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2})
+	} else {
+		ip[0], ip[9] = 0x45, 6
+		binary.BigEndian.PutUint16(ip[2:4], uint16(len(ip)))
+		copy(ip[12:16], []byte{10, 0, 0, 1})
+		copy(ip[16:20], []byte{10, 0, 0, 2})
+	}
+	tcp := ip[ipHdr:]
+	binary.BigEndian.PutUint16(tcp[0:2], 40000)
+	binary.BigEndian.PutUint16(tcp[2:4], 80)
+	binary.BigEndian.PutUint32(tcp[4:8], 0x12345678)
+	tcp[12] = byte(5+len(options)/4) << 4
+	tcp[13] = flags
+	binary.BigEndian.PutUint16(tcp[14:16], 64240)
+	copy(tcp[20:], options)
+	binary.BigEndian.PutUint16(tcp[16:18], tcpChecksum(frame))
+	return frame
+}
+
+// 按伪首部完整计算TCP校验和,校验和字段按0计算
+func tcpChecksum(frame []byte) uint16 {
+	tcp, ipHdr := tcpSegment(frame)
+	ip := frame[model.SizeEthFrame:]
+	var sum uint32
+	add := func(b []byte) {
+		for i := 0; i+1 < len(b); i += 2 {
+			sum += uint32(binary.BigEndian.Uint16(b[i:]))
+		}
+		if len(b)%2 == 1 {
+			sum += uint32(b[len(b)-1]) << 8
+		}
+	}
+	if ipHdr == 40 {
+		add(ip[8:40])
+	} else {
+		add(ip[12:20])
+	}
+	sum += 6 + uint32(len(tcp))
+	old := binary.BigEndian.Uint16(tcp[16:18])
+	tcp[16], tcp[17] = 0, 0
+	add(tcp)
+	binary.BigEndian.PutUint16(tcp[16:18], old)
+	for sum>>16 != 0 {
+		sum = (sum & 0xFFFF) + (sum >> 16)
+	}
+	return ^uint16(sum)
+}
+
+func mssOption(mss uint16) []byte {
+	return []byte{2, 4, byte(mss >> 8), byte(mss)}
+}
+
+func TestClampTcpMss(t *testing.T) {
+	const syn, synAck, ack = 0x02, 0x12, 0x10
+	var cases = []struct {
+		name    string
+		ipv6    bool
+		flags   byte
+		options []byte
+		mtu     int
+		clamped bool
+		mss     uint16
+	}{
+		{name: "ipv4 clamp", flags: syn, options: mssOption(1460), mtu: 1346, clamped: true, mss: 1306},
+		{name: "ipv6 clamp", ipv6: true, flags: syn, options: mssOption(1440), mtu: 1346, clamped: true, mss: 1286},
+		{name: "syn ack", flags: synAck, options: mssOption(1460), mtu: 1400, clamped: true, mss: 1360},
+		{name: "after nop", flags: syn, options: append([]byte{1, 1, 4, 2}, mssOption(1460)...), mtu: 1400, clamped: true, mss: 1360},
+		{name: "below limit", flags: syn, options: mssOption(1200), mtu: 1400, mss: 1200},
+		{name: "equal limit", flags: syn, options: mssOption(1360), mtu: 1400, mss: 1360},
+		{name: "not syn", flags: ack, options: mssOption(1460), mtu: 1400, mss: 1460},
+		{name: "end of options", flags: syn, options: append([]byte{0, 0, 0, 0}, mssOption(1460)...), mtu: 1400, mss: 1460},
+		{name: "bad option length", flags: syn, options: []byte{3, 9, 0, 0}, mtu: 1400},
+		{name: "tiny mtu", flags: syn, options: mssOption(1460), mtu: 40, mss: 1460},
+	}
+	for _, c := range cases {
+		frame := tcpFrame(c.ipv6, c.flags, c.options)
+		if clamped := clampTcpMss(frame, c.mtu); clamped != c.clamped {
+			t.Fatalf("%s: clamped %v != %v", c.name, clamped, c.clamped)
+		}
+		tcp, _ := tcpSegment(frame)
+		if sum := binary.BigEndian.Uint16(tcp[16:18]); sum != tcpChecksum(frame) {
+			t.Fatalf("%s: checksum %04x != %04x", c.name, sum, tcpChecksum(frame))
+		}
+		if c.mss == 0 {
+			continue
+		}
+		for i := 20; i+4 <= len(tcp); i++ {
+			if tcp[i] == 2 && tcp[i+1] == 4 {
+				if mss := binary.BigEndian.Uint16(tcp[i+2:]); mss != c.mss {
+					t.Fatalf("%s: mss %d != %d", c.name, mss, c.mss)
+				}
+				break
+			}
+		}
+	}
+}
+
+func TestTcpSegment(t *testing.T) {
+	udp := tcpFrame(false, 0x02, nil)
+	udp[model.SizeEthFrame+9] = 17
+	fragment := tcpFrame(false, 0x02, nil)
+	fragment[model.SizeEthFrame+7] = 1 // 分片偏移不为0
+	var cases = []struct {
+		name  string
+		frame []byte
+		ok    bool
+	}{
+		{name: "ipv4", frame: tcpFrame(false, 0x02, nil), ok: true},
+		{name: "ipv6", frame: tcpFrame(true, 0x02, nil), ok: true},
+		{name: "udp", frame: udp},
+		{name: "fragment", frame: fragment},
+		{name: "truncated", frame: tcpFrame(false, 0x02, nil)[:model.SizeEthFrame+30]},
+		{name: "arp", frame: arpFrame(arpRequest, broadcastMac, make([]byte, 6), 1, make([]byte, 6), 2)},
+	}
+	for _, c := range cases {
+		if tcp, _ := tcpSegment(c.frame); (tcp != nil) != c.ok {
+			t.Fatalf("%s: %v", c.name, tcp != nil)
+		}
+	}
+}
+
+func TestChecksumUpdate(t *testing.T) {
+	var cases = []struct {
+		old uint16
+		new uint16
+	}{
+		{old: 1460, new: 1306},
+		{old: 0x0000, new: 0xFFFF},
+		{old: 0xFFFF, new: 0x0000},
+		{old: 0x1234, new: 0x1234},
+		{old: 0x8000, new: 0x7FFF},
+	}
+	for _, c := range cases {
+		frame := tcpFrame(false, 0x02, mssOption(c.old))
+		tcp, _ := tcpSegment(frame)
+		sum := binary.BigEndian.Uint16(tcp[16:18])
+		binary.BigEndian.PutUint16(tcp[22:24], c.new)
+		if got, want := checksumUpdate(sum, c.old, c.new), tcpChecksum(frame); got != want {
+			t.Fatalf("%04x->%04x: %04x != %04x", c.old, c.new, got, want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 防重放滑动窗口(参考RFC 6479):
+// 按源MAC记录收到的最大序号及其之前 replayWindowSize 个序号的接收位图,
+// 重复或早于窗口的序号视为重放。发送序号以启动时间为初值,终端重启后序号一般仍然递增;
+// 没有RTC的设备(如OpenWrt路由)重启后时钟可能回拨,因此对端离线或重新上线时清除其窗口。
+// 只为组内终端建立窗口,不加密或非认证加密时源MAC可伪造,避免窗口无限增长。
+
+const (
+	replayWindowWords = 32                           // 位图字数
+	replayWindowSize  = (replayWindowWords - 1) * 64 // 可容忍的乱序范围
+)
+
+type replayWindow struct {
+	last   uint64 // 已接收的最大序号
+	bitmap [replayWindowWords]uint64
+}
+
+type ReplayFilter struct {
+	sendSeq uint64 // 放在首位,保证32位平台原子操作对齐
+	mutex   sync.Mutex
+	windows map[uint64]*replayWindow
+}
+
+func NewReplayFilter() *ReplayFilter {
+	return &ReplayFilter{sendSeq: uint64(time.Now().UnixNano()), windows: make(map[uint64]*replayWindow)}
+}
+
+// 下一个发送序号
+func (f *ReplayFilter) NextSeq() uint64 {
+	return atomic.AddUint64(&f.sendSeq, 1)
+}
+
+// 序号有效时记录并返回true,重放或过旧时返回false
+func (f *ReplayFilter) Accept(srcMac uint64, seq uint64) bool {
+	if seq == 0 {
+		return false
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	w, ok := f.windows[srcMac]
+	if !ok {
+		w = &replayWindow{}
+		f.windows[srcMac] = w
+	}
+	return w.accept(seq)
+}
+
+// 清除终端的接收窗口
+func (f *ReplayFilter) Reset(srcMac uint64) {
+	f.mutex.Lock()
+	delete(f.windows, srcMac)
+	f.mutex.Unlock()
+}
+
+// 只保留keep返回true的终端的接收窗口
+func (f *ReplayFilter) Prune(keep func(srcMac uint64) bool) {
+	f.mutex.Lock()
+	for mac := range f.windows {
+		if !keep(mac) {
+			delete(f.windows, mac)
+		}
+	}
+	f.mutex.Unlock()
+}
+
+func (w *replayWindow) accept(seq uint64) bool {
+	if seq > w.last {
+		// 窗口前移,清除新进入窗口的位图
+		current, next := w.last/64, seq/64
+		diff := next - current
+		if diff > replayWindowWords {
+			diff = replayWindowWords
+		}
+		for i := uint64(1); i <= diff; i++ {
+			w.bitmap[(current+i)%replayWindowWords] = 0
+		}
+		w.last = seq
+	} else if w.last-seq > replayWindowSize {
+		return false
+	}
+	index, bit := (seq/64)%replayWindowWords, uint64(1)<<(seq%64)
+	if w.bitmap[index]&bit != 0 {
+		return false
+	}
+	w.bitmap[index] |= bit
+	return true
+}
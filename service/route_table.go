@@ -0,0 +1,340 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"vilan/app"
+	"vilan/common"
+	"vilan/model"
+	"vilan/protocol"
+	"vilan/tuntap"
+)
+
+// 到其他终端内网网段的路由表,可同时保存多个终端的路由
+// 选中的终端保存在配置中,终端上线、网段变化或重新注册后同步系统路由,终端离线时移除
+// 静态路由为配置中声明的网段,由在线且发布了包含该网段的终端作为网关,网关终端离线时切换到其他发布终端
+
+type peerRoute struct {
+	peerMac  uint64
+	peerName string
+	route    *model.RouteInfo // 已添加到系统的路由,未添加时为nil
+}
+
+type staticRoute struct {
+	peerRoute                  // 当前网关终端
+	subnet    *model.RouteInfo // 配置的网段,不含网关
+}
+
+type RouteTable struct {
+	mutex  sync.RWMutex
+	routes map[uint64]*peerRoute
+	static []*staticRoute
+}
+
+func NewRouteTable(conf []*model.RouteConfig, static []*model.RouteInfo) *RouteTable {
+	t := &RouteTable{routes: make(map[uint64]*peerRoute)}
+	for _, c := range conf {
+		if c != nil && c.PeerMac != 0 {
+			t.routes[c.PeerMac] = &peerRoute{peerMac: c.PeerMac, peerName: c.PeerName}
+		}
+	}
+	for _, subnet := range static {
+		if subnet != nil {
+			t.static = append(t.static, &staticRoute{subnet: subnet})
+		}
+	}
+	return t
+}
+
+// 终端内网网段,网关为终端虚拟IP
+func peerSubnet(p *protocol.PeerInfo) *model.RouteInfo {
+	if p == nil || p.InterAddr == 0 || p.InterNetBitLen == 0 || p.InterNetBitLen > 32 || p.NetAddr == 0 {
+		return nil
+	}
+	mask := uint32(0xFFFFFFFF) << (32 - p.InterNetBitLen)
+	return &model.RouteInfo{Destination: p.InterAddr & mask, Mask: mask, Gateway: p.NetAddr}
+}
+
+func overlap(a, b *model.RouteInfo) bool {
+	mask := a.Mask & b.Mask
+	return a.Destination&mask == b.Destination&mask
+}
+
+// 发布的网段 n 是否包含 subnet
+func covers(n *protocol.IpNet, subnet *model.RouteInfo) bool {
+	if n == nil || n.NetBitLen == 0 || n.NetBitLen > 32 {
+		return false
+	}
+	mask := uint32(0xFFFFFFFF) << (32 - n.NetBitLen)
+	return mask <= subnet.Mask && subnet.Destination&mask == n.NetAddr&mask
+}
+
+// 添加终端路由并同步到系统,网段与本地网络或其他终端路由重叠时返回错误
+// local 为本机网络(虚拟网段及本地网卡网段)
+func (t *RouteTable) Add(p *protocol.PeerInfo, local []*model.RouteInfo) error {
+	subnet := peerSubnet(p)
+	if subnet == nil {
+		return errors.New("终端没有有效的内网网段")
+	}
+	t.mutex.RLock()
+	pr := t.routes[p.PeerMac]
+	t.mutex.RUnlock()
+	if err := t.checkOverlap(pr, subnet, local); err != nil {
+		return err
+	}
+	t.mutex.Lock()
+	_, exist := t.routes[p.PeerMac]
+	if !exist {
+		t.routes[p.PeerMac] = &peerRoute{peerMac: p.PeerMac, peerName: p.PeerName}
+	}
+	t.mutex.Unlock()
+	err := t.Sync(p, local)
+	if err != nil && !exist {
+		t.mutex.Lock()
+		delete(t.routes, p.PeerMac)
+		t.mutex.Unlock()
+	}
+	return err
+}
+
+// 删除终端路由,同时移除系统路由
+func (t *RouteTable) Remove(mac uint64) error {
+	t.mutex.Lock()
+	pr, ok := t.routes[mac]
+	delete(t.routes, mac)
+	t.mutex.Unlock()
+	if !ok {
+		return errors.New("没有到该终端的路由")
+	}
+	return t.uninstall(pr)
+}
+
+// 按终端当前状态同步系统路由: 在线时添加或更新网段,离线或网段无效时移除
+func (t *RouteTable) Sync(p *protocol.PeerInfo, local []*model.RouteInfo) error {
+	if p == nil {
+		return nil
+	}
+	t.mutex.Lock()
+	pr, ok := t.routes[p.PeerMac]
+	if ok {
+		pr.peerName = p.PeerName
+	}
+	t.mutex.Unlock()
+	if !ok {
+		return nil
+	}
+	subnet := peerSubnet(p)
+	if !p.Online || subnet == nil {
+		return t.uninstall(pr)
+	}
+	t.mutex.RLock()
+	installed := pr.route != nil && *pr.route == *subnet
+	t.mutex.RUnlock()
+	if installed {
+		return nil
+	}
+	if err := t.uninstall(pr); err != nil {
+		app.Logger.Warn(err)
+	}
+	if err := t.checkOverlap(pr, subnet, local); err != nil {
+		return err
+	}
+	return t.install(pr, subnet)
+}
+
+// 按终端在线状态及发布网段同步所有静态路由,已有网关仍然有效时不切换
+func (t *RouteTable) SyncStatic(peers []*protocol.PeerInfo, local []*model.RouteInfo) {
+	t.mutex.RLock()
+	static := append([]*staticRoute(nil), t.static...)
+	t.mutex.RUnlock()
+	for _, sr := range static {
+		gw := t.staticGateway(sr, peers)
+		if gw == nil {
+			if err := t.uninstall(&sr.peerRoute); err != nil {
+				app.Logger.Warn(err)
+			}
+			continue
+		}
+		route := &model.RouteInfo{Destination: sr.subnet.Destination, Mask: sr.subnet.Mask, Gateway: gw.NetAddr}
+		t.mutex.Lock()
+		sr.peerName = gw.PeerName
+		installed := sr.route != nil && *sr.route == *route
+		t.mutex.Unlock()
+		if installed {
+			continue
+		}
+		if err := t.uninstall(&sr.peerRoute); err != nil {
+			app.Logger.Warn(err)
+		}
+		// 多个站点共用同一份静态路由配置时,本站点的网段不需要路由
+		if err := t.checkOverlap(&sr.peerRoute, route, local); err != nil {
+			app.Logger.Debug("静态路由未添加:", err)
+			continue
+		}
+		t.mutex.Lock()
+		sr.peerMac = gw.PeerMac
+		t.mutex.Unlock()
+		if err := t.install(&sr.peerRoute, route); err != nil {
+			app.Logger.Warn("静态路由添加失败:", err)
+		} else {
+			app.Logger.Info("静态路由", route.GetDst(), "/", common.MaskBitLen(route.Mask), "经终端<", gw.PeerName, ">转发")
+		}
+	}
+}
+
+// 选择静态路由网关:当前网关仍发布该网段时保留,否则取发布网段掩码最长的在线终端
+func (t *RouteTable) staticGateway(sr *staticRoute, peers []*protocol.PeerInfo) *protocol.PeerInfo {
+	t.mutex.RLock()
+	current := sr.peerMac
+	t.mutex.RUnlock()
+	var best *protocol.PeerInfo
+	bestLen := uint32(0)
+	for _, p := range peers {
+		if p == nil || !p.Online || p.NetAddr == 0 {
+			continue
+		}
+		for _, n := range p.Subnets {
+			if !covers(n, sr.subnet) {
+				continue
+			}
+			if p.PeerMac == current {
+				return p
+			}
+			if n.NetBitLen > bestLen {
+				best, bestLen = p, n.NetBitLen
+			}
+		}
+	}
+	return best
+}
+
+// 移除所有系统路由,保留路由表配置
+func (t *RouteTable) Clean() {
+	t.mutex.RLock()
+	routes := make([]*peerRoute, 0, len(t.routes))
+	for _, pr := range t.routes {
+		routes = append(routes, pr)
+	}
+	for _, sr := range t.static {
+		routes = append(routes, &sr.peerRoute)
+	}
+	t.mutex.RUnlock()
+	for _, pr := range routes {
+		if err := t.uninstall(pr); err != nil {
+			app.Logger.Warn(err)
+		}
+	}
+}
+
+// 虚拟网卡重建后系统路由已随网卡删除,只清除记录
+func (t *RouteTable) Reset() {
+	t.mutex.Lock()
+	for _, pr := range t.routes {
+		pr.route = nil
+	}
+	for _, sr := range t.static {
+		sr.route = nil
+	}
+	t.mutex.Unlock()
+}
+
+// 按目的IP查找路由网关,多个匹配时取掩码最长的
+func (t *RouteTable) Lookup(ip uint32) (uint32, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	var best *model.RouteInfo
+	match := func(r *model.RouteInfo) {
+		if r != nil && ip&r.Mask == r.Destination && (best == nil || r.Mask > best.Mask) {
+			best = r
+		}
+	}
+	for _, pr := range t.routes {
+		match(pr.route)
+	}
+	for _, sr := range t.static {
+		match(sr.route)
+	}
+	if best == nil {
+		return 0, false
+	}
+	return best.Gateway, true
+}
+
+func (t *RouteTable) Has(mac uint64) bool {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	_, ok := t.routes[mac]
+	return ok
+}
+
+func (t *RouteTable) Macs() []uint64 {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	macs := make([]uint64, 0, len(t.routes))
+	for mac := range t.routes {
+		macs = append(macs, mac)
+	}
+	return macs
+}
+
+// 用于保存到配置文件
+func (t *RouteTable) Configs() []*model.RouteConfig {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	conf := make([]*model.RouteConfig, 0, len(t.routes))
+	for _, pr := range t.routes {
+		conf = append(conf, &model.RouteConfig{PeerMac: pr.peerMac, PeerName: pr.peerName})
+	}
+	return conf
+}
+
+// self 为待添加的路由项,不与自身比较
+func (t *RouteTable) checkOverlap(self *peerRoute, subnet *model.RouteInfo, local []*model.RouteInfo) error {
+	for _, l := range local {
+		if l != nil && overlap(subnet, l) {
+			return errors.New(fmt.Sprintf("终端网段%s/%d与本地网络%s/%d重叠", subnet.GetDst(), common.MaskBitLen(subnet.Mask),
+				l.GetDst(), common.MaskBitLen(l.Mask)))
+		}
+	}
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	for _, pr := range t.routes {
+		if pr != self && pr.route != nil && overlap(subnet, pr.route) {
+			return errors.New(fmt.Sprintf("终端网段%s/%d与终端<%s>的路由重叠", subnet.GetDst(), common.MaskBitLen(subnet.Mask), pr.peerName))
+		}
+	}
+	for _, sr := range t.static {
+		if &sr.peerRoute != self && sr.route != nil && overlap(subnet, sr.route) {
+			return errors.New(fmt.Sprintf("终端网段%s/%d与静态路由%s/%d重叠", subnet.GetDst(), common.MaskBitLen(subnet.Mask),
+				sr.route.GetDst(), common.MaskBitLen(sr.route.Mask)))
+		}
+	}
+	return nil
+}
+
+func (t *RouteTable) install(pr *peerRoute, route *model.RouteInfo) error {
+	err := app.TunTapService.AddRoute(route.GetDst(), route.GetMask(), route.GetGw())
+	if err != nil && !errors.Is(err, tuntap.ErrRouteExists) {
+		return err
+	}
+	t.mutex.Lock()
+	pr.route = route
+	t.mutex.Unlock()
+	return nil
+}
+
+func (t *RouteTable) uninstall(pr *peerRoute) error {
+	t.mutex.Lock()
+	route := pr.route
+	pr.route = nil
+	t.mutex.Unlock()
+	if route == nil {
+		return nil
+	}
+	err := app.TunTapService.DelRoute(route.GetDst(), route.GetMask(), route.GetGw())
+	if err != nil && !errors.Is(err, tuntap.ErrRouteNotFound) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"testing"
+	"vilan/model"
+	"vilan/protocol"
+)
+
+func route(dst uint32, bitLen uint32, gw uint32) *model.RouteInfo {
+	mask := uint32(0xFFFFFFFF) << (32 - bitLen)
+	return &model.RouteInfo{Destination: dst & mask, Mask: mask, Gateway: gw}
+}
+
+func TestOverlap(t *testing.T) {
+	var cases = []struct {
+		a, b *model.RouteInfo
+		want bool
+	}{
+		{a: route(0xC0A80A00, 24, 0), b: route(0xC0A80A00, 24, 0), want: true},
+		{a: route(0xC0A80A00, 24, 0), b: route(0xC0A80B00, 24, 0), want: false},
+		{a: route(0xC0A80000, 16, 0), b: route(0xC0A80A00, 24, 0), want: true}, // 包含
+		{a: route(0xC0A80A80, 25, 0), b: route(0xC0A80A00, 24, 0), want: true},
+		{a: route(0xC0A80A80, 25, 0), b: route(0xC0A80A00, 25, 0), want: false},
+		{a: route(0x0A000000, 8, 0), b: route(0xC0A80A00, 24, 0), want: false},
+	}
+	for i, c := range cases {
+		if got := overlap(c.a, c.b); got != c.want {
+			t.Fatalf("%d: %v != %v", i, got, c.want)
+		}
+		if got := overlap(c.b, c.a); got != c.want {
+			t.Fatalf("%d reversed: %v != %v", i, got, c.want)
+		}
+	}
+}
+
+func TestRouteTableCheckOverlap(t *testing.T) {
+	table := NewRouteTable([]*model.RouteConfig{{PeerMac: 1, PeerName: "a"}, {PeerMac: 2, PeerName: "b"}},
+		[]*model.RouteInfo{route(0xAC100000, 16, 0)})
+	table.routes[1].route = route(0xC0A80A00, 24, 0x0A000001)
+	table.static[0].route = route(0xAC100000, 16, 0x0A000002)
+	local := []*model.RouteInfo{route(0x0A000000, 24, 0)}
+
+	var cases = []struct {
+		name   string
+		self   *peerRoute
+		subnet *model.RouteInfo
+		ok     bool
+	}{
+		{name: "free", self: table.routes[2], subnet: route(0xC0A81400, 24, 0), ok: true},
+		{name: "local", self: table.routes[2], subnet: route(0x0A000000, 16, 0)},
+		{name: "peer", self: table.routes[2], subnet: route(0xC0A80A80, 25, 0)},
+		{name: "static", self: table.routes[2], subnet: route(0xAC101400, 24, 0)},
+		{name: "self", self: table.routes[1], subnet: route(0xC0A80A00, 23, 0), ok: true},
+		{name: "self static", self: &table.static[0].peerRoute, subnet: route(0xAC100000, 16, 0), ok: true},
+	}
+	for _, c := range cases {
+		if err := table.checkOverlap(c.self, c.subnet, local); (err == nil) != c.ok {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+	}
+}
+
+func TestRouteTableLookup(t *testing.T) {
+	table := NewRouteTable([]*model.RouteConfig{{PeerMac: 1}, {PeerMac: 2}, {PeerMac: 3}},
+		[]*model.RouteInfo{route(0xC0A80000, 16, 0)})
+	table.routes[1].route = route(0xC0A80A00, 24, 0x0A000001)
+	table.routes[2].route = route(0xC0A80A80, 25, 0x0A000002)
+	table.static[0].route = route(0xC0A80000, 16, 0x0A000003)
+	// 3 未添加到系统,不参与查找
+
+	var cases = []struct {
+		ip uint32
+		gw uint32
+		ok bool
+	}{
+		{ip: 0xC0A80A01, gw: 0x0A000001, ok: true},
+		{ip: 0xC0A80AFE, gw: 0x0A000002, ok: true}, // 掩码最长优先
+		{ip: 0xC0A81401, gw: 0x0A000003, ok: true},
+		{ip: 0xC0A90001},
+		{ip: 0},
+	}
+	for _, c := range cases {
+		gw, ok := table.Lookup(c.ip)
+		if ok != c.ok || gw != c.gw {
+			t.Fatalf("%08X: %08X %v != %08X %v", c.ip, gw, ok, c.gw, c.ok)
+		}
+	}
+}
+
+func TestStaticGateway(t *testing.T) {
+	subnet := route(0xC0A81400, 24, 0)
+	peer := func(mac uint64, online bool, nets ...*protocol.IpNet) *protocol.PeerInfo {
+		return &protocol.PeerInfo{PeerMac: mac, Online: online, NetAddr: uint32(mac), Subnets: nets}
+	}
+	wide := &protocol.IpNet{NetAddr: 0xC0A80000, NetBitLen: 16}
+	exact := &protocol.IpNet{NetAddr: 0xC0A81400, NetBitLen: 24}
+	other := &protocol.IpNet{NetAddr: 0xC0A81500, NetBitLen: 24}
+
+	var cases = []struct {
+		name    string
+		current uint64
+		peers   []*protocol.PeerInfo
+		want    uint64
+	}{
+		{name: "longest", peers: []*protocol.PeerInfo{peer(1, true, wide), peer(2, true, exact)}, want: 2},
+		{name: "keep current", current: 1, peers: []*protocol.PeerInfo{peer(1, true, wide), peer(2, true, exact)}, want: 1},
+		{name: "offline", peers: []*protocol.PeerInfo{peer(1, true, wide), peer(2, false, exact)}, want: 1},
+		{name: "not covered", peers: []*protocol.PeerInfo{peer(1, true, other)}},
+	}
+	for _, c := range cases {
+		table := NewRouteTable(nil, []*model.RouteInfo{subnet})
+		table.static[0].peerMac = c.current
+		gw := table.staticGateway(table.static[0], c.peers)
+		var mac uint64
+		if gw != nil {
+			mac = gw.PeerMac
+		}
+		if mac != c.want {
+			t.Fatalf("%s: %d != %d", c.name, mac, c.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/base64"
 	"encoding/binary"
 	"errors"
@@ -12,6 +13,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"vilan/app"
 	"vilan/arp"
@@ -21,49 +23,73 @@ import (
 	"vilan/netty"
 	"vilan/netty/codec/format"
 	"vilan/netty/transport"
+	"vilan/netty/transport/tcp"
 	"vilan/netty/transport/udp"
 	"vilan/protocol"
 	"vilan/sys/wifi"
 )
 
-var gratuitousArp = []byte{
-	0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, /* dest MAC */
-	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, /* src MAC */
-	0x08, 0x06, /* ARP */
-	0x00, 0x01, /* ethernet */
-	0x08, 0x00, /* IP */
-	0x06,       /* hw Size */
-	0x04,       /* protocol Size */
-	0x00, 0x02, /* ARP reply */
-	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, /* src MAC */
-	0x00, 0x00, 0x00, 0x00, /* src IP */
-	0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, /* target MAC */
-	0x00, 0x00, 0x00, 0x00, /* target IP */
-}
+const (
+	natDetectInterval = 10 * time.Minute
+	udpMaxFails       = 2               // 自动模式下UDP连接后连续无注册应答的次数,达到后改用TCP
+	udpProbeInterval  = 5 * time.Minute // 自动模式下经TCP连接时重新探测UDP的间隔
+)
 
 type RuntimeService struct {
+	pingSent      int64 // 上次心跳发送时间(纳秒),原子操作需64位对齐
+	serverPmtu    int32 // 探测到的服务端路径MTU
 	isInit        bool
 	running       bool
 	appConfig     *model.AppConfig // 重启后 更新配置
 	cancelContext context.Context
 	cancelFunc    context.CancelFunc
 
-	crypt common.Crypt // 加密 解密
+	crypt    common.Crypt  // 加密 解密
+	sessions *SessionKeys  // 终端间会话密钥,未启用时为nil
+	replay   *ReplayFilter // 发送序号及接收防重放窗口
 
-	peerState     model.PeerState
-	serverSock    *model.ServerSockContext
-	serverChannel netty.Channel
-	linkMode      model.LinkMode // 连接方式
-	linkQuality   uint32         // 信号强度
-	p2pListener   *net.TCPListener
-	externSock    *protocol.Sock
-	LocalIp       *protocol.IpNet
-	LocalIpStr    string
-	stats         *protocol.Statistics
-	lastRoute     *model.RouteInfo // 可以考虑保存多个route
+	peerState      model.PeerState
+	serverSock     *model.ServerSockContext
+	liveness       *livenessChecker // 服务端心跳超时后的存活检查
+	serverChannel  netty.Channel
+	servers        []*serverEndpoint // 服务端地址列表,第一个为主服务端
+	serverIndex    int               // 当前服务端
+	serverIp       string            // 当前服务端解析后的IP
+	serverFails    int               // 当前服务端连续失败次数
+	serverPrefer   int               // 首选的服务端,切换到其他服务端后定时探测回切
+	failbackTime   time.Time         // 上次探测首选服务端的时间
+	failbacking    int32             // 正在探测首选服务端
+	failback       chan int          // 探测到可回切的服务端
+	transport      string            // 当前连接服务端的传输方式
+	serverTried    bool              // 上次已建立连接,等待注册应答
+	serverAcked    bool              // 本次连接已注册成功
+	udpFails       int               // UDP连接后无注册应答的次数
+	udpProbeTime   time.Time         // 上次重新探测UDP的时间
+	pongNotify     chan struct{}     // 收到心跳应答
+	linkMode       model.LinkMode    // 连接方式
+	linkQuality    uint32            // 信号强度
+	p2pListener    *net.TCPListener
+	externSock     *protocol.Sock
+	LocalIp        *protocol.IpNet
+	LocalIpStr     string
+	stats          *protocol.Statistics
+	traffic        *sync.Map         //map[uint64]*model.TrafficMeter 按终端的流量统计
+	compress       *sync.Map         //map[uint64]*peerCompress 按终端的压缩协商状态
+	compressAlg    uint32            // 配置的压缩算法
+	routes         *RouteTable       // 到其他终端内网的路由
+	subnets        []*protocol.IpNet // 本终端发布的内网网段
+	ip6            *protocol.IpNet   // IPv6虚拟地址,未配置时为nil
+	natType        uint32            // model.NatType,探测协程写入,原子操作
+	natDetecting   int32             // 正在探测NAT类型
+	natDetectTime  time.Time         // 上次探测时间
+	pmtuDetecting  int32             // 正在探测路径MTU
+	probeWarned    int32             // 已警告服务端不应答探测
+	pmtuDetectTime time.Time         // 上次探测路径MTU的时间
 
 	groupPeerCookie uint32    // 上次请求应答的cookie
 	groupPeers      *sync.Map //map[uint64]*protocol.PeerInfo
+	peerIps         *sync.Map //map[uint32]uint64 虚拟IP -> MAC,TUN模式按IP查找目标终端
+	peerIp6s        *sync.Map //map[[16]byte]uint64 IPv6虚拟地址 -> MAC
 
 	linkInfos         map[uint64][]*protocol.LinkInfo
 	linkInfoReqCookie map[uint64]uint32
@@ -75,7 +101,7 @@ type RuntimeService struct {
 func NewRuntimeService() *RuntimeService {
 	conf := &model.AppConfig{}
 	_ = config.CopyAppConfigTo(conf)
-	r := &RuntimeService{isInit: false, running: false, appConfig: conf, peerState: model.StateUnInit}
+	r := &RuntimeService{isInit: false, running: false, appConfig: conf, peerState: model.StateUnInit, replay: NewReplayFilter()}
 	return r
 }
 
@@ -87,9 +113,16 @@ func (r *RuntimeService) Init() (err error) {
 		}
 	}()
 	r.stats = &protocol.Statistics{}
+	r.traffic = &sync.Map{}
+	r.compress = &sync.Map{}
 	r.LocalIpStr = ""
-	r.serverSock = &model.ServerSockContext{Heartbeat: r.appConfig.Heartbeat, Offline: r.appConfig.Offline}
+	r.pongNotify = make(chan struct{}, 1)
+	r.liveness = newLivenessChecker(r, r.appConfig.ProbeTargets)
+	r.serverSock = &model.ServerSockContext{Heartbeat: r.appConfig.Heartbeat, Offline: r.appConfig.Offline, Checker: r.liveness}
 	r.groupPeers = &sync.Map{}
+	r.peerIps = &sync.Map{}
+	r.peerIp6s = &sync.Map{}
+	r.parseConfig()
 	r.linkInfos = make(map[uint64][]*protocol.LinkInfo)
 	r.linkInfoReqCookie = make(map[uint64]uint32)
 	r.configSend = make(map[uint64]chan error)
@@ -97,15 +130,38 @@ func (r *RuntimeService) Init() (err error) {
 	return err
 }
 
+// 解析配置中的IPv6地址、发布网段、静态路由及压缩算法,初始化及重启时调用
+func (r *RuntimeService) parseConfig() {
+	var err error
+	if r.compressAlg, err = common.ParseCompress(r.appConfig.Compress); err != nil {
+		app.Logger.Warn("数据压缩配置错误,不压缩:", err)
+	}
+	r.ip6 = parseIp6Net(r.appConfig.TapConfig.Ip6Addr)
+	r.subnets = parseSubnets(r.appConfig.AdvertiseSubnets, "发布网段")
+	static := make([]*model.RouteInfo, 0, len(r.appConfig.StaticRoutes))
+	for _, n := range parseSubnets(r.appConfig.StaticRoutes, "静态路由") {
+		mask := uint32(0xFFFFFFFF) << (32 - n.NetBitLen)
+		static = append(static, &model.RouteInfo{Destination: n.NetAddr, Mask: mask})
+	}
+	r.routes = NewRouteTable(r.appConfig.Routes, static)
+}
+
 func (r *RuntimeService) Start() (err error) {
 	if r.running {
 		return
 	}
 	r.running = true
 	r.cancelContext, r.cancelFunc = context.WithCancel(context.Background()) // 全局取消
-	err = r.initCrypt()
+	r.servers = serverEndpoints(r.appConfig)
+	r.serverIndex, r.serverFails, r.serverPrefer = 0, 0, 0
+	r.failback = make(chan int, 1)
+	if err = r.initCrypt(); err != nil { // 加密器不可用时不连接服务端,避免明文传输
+		r.running = false
+		r.cancelFunc()
+		return err
+	}
 	go r.stateCheck()
-	return err
+	return nil
 }
 
 func (r *RuntimeService) Stop() {
@@ -128,8 +184,9 @@ func (r *RuntimeService) Restart() error {
 	if err := app.TunTapService.Stop(); err != nil {
 		return err
 	}
-	// 使用新配置
+	// 使用新配置,系统路由已在Stop中移除,按新配置重建路由表
 	_ = config.CopyAppConfigTo(r.appConfig)
+	r.parseConfig()
 	time.Sleep(100 * time.Millisecond)
 	if r.appConfig.TapConfig.IpMode == model.Static && r.appConfig.TapConfig.HwMac != 0 {
 		if err := app.TunTapService.Start(); err != nil {
@@ -161,6 +218,8 @@ func (r *RuntimeService) stateCheck() {
 		}
 		r.running = false
 	}()
+	r.pickServer()
+	r.serverPrefer, r.failbackTime = r.serverIndex, time.Now()
 	if !r.serverSock.IsConnected() {
 		r.stopAllSockets()
 		err := r.initServerSocket()
@@ -173,6 +232,15 @@ func (r *RuntimeService) stateCheck() {
 		case <-r.cancelContext.Done():
 			r.stopAllSockets()
 			return
+		case alive := <-r.liveness.result:
+			// 检查期间收到服务端报文或已重连时忽略
+			if !alive && r.serverSock.HeartbeatTimeout() {
+				app.Logger.Info("服务端及探测目标均无应答,重新连接服务")
+				r.stopAllSockets()
+				if err := r.initServerSocket(); err != nil {
+					app.Logger.Error("服务连接失败:", err)
+				}
+			}
 		case <-time.After(3 * time.Second): //超时
 			app.WailsApp.UpdateStats(r.stats)
 			if !r.serverSock.IsConnected() {
@@ -184,6 +252,23 @@ func (r *RuntimeService) stateCheck() {
 				continue
 			} else if r.peerState != model.StateOk {
 				_ = r.SendAuthRequest() // 重新注册
+			} else if r.udpRecovered() {
+				app.Logger.Info("UDP已恢复,重新连接服务")
+				r.stopAllSockets()
+				if err := r.initServerSocket(); err != nil {
+					app.Logger.Error("服务连接失败:", err)
+				}
+			} else {
+				r.checkFailback()
+			}
+		case index := <-r.failback:
+			if index != r.serverIndex && r.peerState == model.StateOk {
+				app.Logger.Info("切换回服务端:", r.servers[index])
+				r.serverIndex, r.serverFails, r.udpFails = index, 0, 0
+				r.stopAllSockets()
+				if err := r.initServerSocket(); err != nil {
+					app.Logger.Error("服务连接失败:", err)
+				}
 			}
 		}
 	}
@@ -195,38 +280,140 @@ func (r *RuntimeService) initServerSocket() error {
 		_ = r.serverChannel.Close()
 	}
 	r.serverChannel = nil
-	// 先用ping测试(对于关闭PING服务的服务器,请取消这段代码)
-	if !common.Ping(r.appConfig.ServerIp, 1000) {
+	mode := r.appConfig.ServerTransport
+	if mode != model.TransportAuto && mode != model.TransportTcp && mode != model.TransportTls {
+		mode = model.TransportUdp // 未配置时与旧版本相同只使用UDP
+	}
+	if r.serverTried && !r.serverAcked { // 上次连接没有收到注册应答
+		if mode == model.TransportAuto && r.transport == model.TransportUdp {
+			r.udpFails++
+		} else {
+			r.serverFails++
+		}
+	}
+	r.serverTried, r.serverAcked = false, false
+	if r.serverFails >= serverMaxFails {
+		r.nextServer()
+	}
+	if err := r.resolveServer(); err != nil {
+		r.serverFails++
+		r.SetPeerState(model.StateUnConn)
+		return err
+	}
+
+	// UDP连接不检查可达性,没有注册应答时由心跳检查重连
+	var err error
+	switch mode {
+	case model.TransportUdp, model.TransportTcp, model.TransportTls:
+		err = r.connectServer(mode)
+	default: // UDP多次无注册应答时改用TCP,TCP端口也被封锁时使用TLS;只按注册应答判断,服务端可能不应答探测
+		if r.udpFails < udpMaxFails {
+			err = r.connectServer(model.TransportUdp)
+		} else {
+			if err = r.connectServer(model.TransportTcp); err != nil {
+				app.Logger.Debug("TCP连接服务失败:", err)
+				err = r.connectServer(model.TransportTls)
+			}
+			if err != nil {
+				r.udpFails = 0 // 服务端整体不可达,下次重新从UDP开始
+			}
+			r.udpProbeTime = time.Now()
+		}
+	}
+	if err != nil {
+		r.serverFails++
 		r.SetPeerState(model.StateUnConn)
-		return errors.New("无法PING通服务IP")
+		r.serverChannel = nil
+		return err
 	}
+	return nil
+}
 
+// 按传输方式连接服务端,TCP与UDP使用相同的帧格式
+func (r *RuntimeService) connectServer(mode string) error {
+	scheme := uint32(1)
+	if mode != model.TransportUdp {
+		scheme = 0
+	}
 	r.serverSock.Bootstrap = netty.NewBootstrap()
 	// 子连接的流水线配置
 	var initializer = func(channel netty.Channel) {
 		channel.Pipeline().
 			AddLast(netty.ReadIdleHandler(time.Duration(r.appConfig.Heartbeat) * time.Second)).
-			AddLast(format.ProtobufCodec(1, uint32(r.appConfig.MaxPacketSize))).
+			AddLast(format.ProtobufCodec(scheme, uint32(r.appConfig.MaxPacketSize))).
 			AddLast(NewServerHandler(r.serverSock))
 	}
 	r.serverSock.Bootstrap.ClientInitializer(initializer)
-	url := fmt.Sprint("//", r.appConfig.ServerIp, ":", r.appConfig.ServerPort)
-	chl, err := r.serverSock.Bootstrap.Transport(udp.New()).Connect(url, nil, transport.WithLocalAddr(&net.UDPAddr{IP: net.IPv4zero, Port: 0})) //
+
+	ep := r.servers[r.serverIndex]
+	port := ep.port
+	factory := udp.New()
+	options := make([]transport.Option, 0, 1)
+	switch mode {
+	case model.TransportTcp:
+		factory = tcp.New()
+		if r.appConfig.ServerTcpPort > 0 {
+			port = r.appConfig.ServerTcpPort
+		}
+	case model.TransportTls:
+		factory = tcp.New()
+		port = 443
+		if r.appConfig.ServerTlsPort > 0 {
+			port = r.appConfig.ServerTlsPort
+		}
+		tlsOption := *tcp.DefaultOption
+		tlsOption.TLS = &tls.Config{ServerName: ep.host, InsecureSkipVerify: r.appConfig.TlsSkipVerify}
+		options = append(options, tcp.WithOptions(&tlsOption))
+	default:
+		options = append(options, transport.WithLocalAddr(&net.UDPAddr{Port: 0})) // 按服务地址选择IPv4或IPv6
+	}
+	url := "//" + net.JoinHostPort(r.serverIp, strconv.Itoa(int(port)))
+	chl, err := r.serverSock.Bootstrap.Transport(factory).Connect(url, nil, options...)
 	if err != nil {
-		r.SetPeerState(model.StateUnConn)
-		r.serverChannel = nil
 		return err
-	} else {
-		r.serverChannel = chl
-		r.SetPeerState(model.StateUnAck)
-		r.serverSock.Connected = true
 	}
-	ipAddr := strings.Split(chl.LocalAddr(), ":")
-	r.updateLocalIp(ipAddr[0])
-	r.updateLinkInfo(ipAddr[0])
+	if r.transport != mode {
+		app.Logger.Info("服务连接方式:", mode)
+	}
+	r.transport = mode
+	r.serverTried = true
+	r.serverChannel = chl
+	r.SetPeerState(model.StateUnAck)
+	r.serverSock.Connected = true
+	localIp := localHost(chl.LocalAddr())
+	r.updateLocalIp(localIp)
+	r.updateLinkInfo(localIp)
 	return nil
 }
 
+// 自动模式下经TCP连接时,每隔 udpProbeInterval 探测UDP是否恢复
+func (r *RuntimeService) udpRecovered() bool {
+	if r.transport == model.TransportUdp || r.appConfig.ServerTransport != model.TransportAuto ||
+		time.Since(r.udpProbeTime) < udpProbeInterval {
+		return false
+	}
+	r.udpProbeTime = time.Now()
+	if _, err := ProbeRtt(r.ServerAddr(), r.appConfig.TapConfig.HwMac); err != nil {
+		r.probeFailed("UDP恢复探测失败:", err)
+		return false
+	}
+	r.udpFails = 0
+	return true
+}
+
+// 当前连接服务端的传输方式
+func (r *RuntimeService) ServerTransport() string {
+	return r.transport
+}
+
+// 连接的本地IP,IPv6地址不含方括号
+func localHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.Split(addr, ":")[0]
+}
+
 func (r *RuntimeService) updateLocalIp(ip string) {
 	r.LocalIpStr = ip
 	localIp := common.GetLocalIP(r.LocalIpStr) //
@@ -324,42 +511,70 @@ func (r *RuntimeService) stopAllSockets() {
 		r.serverChannel = nil
 	}
 }
+
+// 加密器创建失败时返回错误,不再退回无加密传输
 func (r *RuntimeService) initCrypt() error {
 	if r.appConfig == nil || r.appConfig.PeerConfig == nil {
 		return errors.New("加密器初始化失败:配置为空")
 	}
+	r.sessions = nil
+	r.crypt = nil
+	pwd, group := r.appConfig.PeerConfig.PeerPwd, r.appConfig.PeerConfig.GroupName
+	var key []byte
+	var err error
 	switch r.appConfig.PeerConfig.CryptType {
 	case model.CryptNone:
-		r.crypt = nil
 		app.Logger.Info("正在使用无加密报文传输")
 		break
 	case model.CryptAES:
-		r.crypt = common.NewAesCrypt(r.appConfig.PeerConfig.PeerPwd)
-		if r.crypt == nil {
-			return errors.New("AES加密器创建失败,将进行无加密传输")
+		if c := common.NewAesCrypt(pwd); c == nil { // 避免接口持有nil指针
+			return errors.New("AES加密器创建失败,请检查传输密码")
 		} else {
+			r.crypt = c
 			app.Logger.Info("正在使用AES进行报文加解密")
 		}
 		break
 	case model.CryptDES:
-		r.crypt = common.NewDesCrypt(r.appConfig.PeerConfig.PeerPwd)
-		if r.crypt == nil {
-			return errors.New("DES加密器创建失败,将进行无加密传输")
+		if c := common.NewDesCrypt(pwd); c == nil {
+			return errors.New("DES加密器创建失败,请检查传输密码")
 		} else {
+			r.crypt = c
 			app.Logger.Info("正在使用DES进行报文加解密")
 		}
 		break
+	case model.CryptAESGCM, model.CryptChaCha20:
+		if key, err = common.DeriveKey(pwd, group); len(pwd) == 0 || err != nil {
+			return errors.New("认证加密器创建失败,请检查传输密码")
+		}
+		if r.appConfig.PeerConfig.CryptType == model.CryptChaCha20 {
+			r.crypt = common.NewChaChaCryptKey(key)
+			app.Logger.Info("正在使用ChaCha20-Poly1305进行报文认证加解密")
+		} else {
+			r.crypt = common.NewAesGcmCryptKey(key)
+			app.Logger.Info("正在使用AES-GCM进行报文认证加解密")
+		}
+		break
 	case model.CryptRSA:
-		r.crypt = common.NewRsaCrypt()
-		if r.crypt == nil {
-			return errors.New("RSA加密器创建失败,将进行无加密传输")
+		if c := common.NewRsaCrypt(); c == nil {
+			return errors.New("RSA加密器创建失败,请检查密钥文件")
 		} else {
+			r.crypt = c
 			app.Logger.Info("正在使用RSA进行报文加解密,请确认对端密钥文件一致")
 		}
 		break
 	default:
-		r.crypt = nil
-		return errors.New("加密器创建失败,使用无加密报文传输")
+		return errors.New("加密器创建失败,不支持的加密方式")
+	}
+	if _, auth := r.crypt.(common.AuthCrypt); r.crypt != nil && !auth { // 无加密时已提示
+		app.Logger.Warn("当前加密方式不认证报文序号,序号可被篡改且接收不带序号的报文,不能防止重放;需要防重放时请使用AES-GCM或ChaCha20-Poly1305")
+	}
+	if r.appConfig.PeerConfig.SessionKey {
+		sessions, err := NewSessionKeys(r.appConfig, key, r.sendKeyExchange)
+		if err != nil {
+			return err
+		}
+		r.sessions = sessions
+		app.Logger.Info("已启用终端间会话密钥,单播报文使用协商的密钥加密")
 	}
 	return nil
 }
@@ -386,13 +601,18 @@ func (r *RuntimeService) forwardMessage(msg *model.MessageOut) {
 
 // 转发本地tun tap设备读取到的数据
 func (r *RuntimeService) PostTunTapData(dstMac uint64, data []byte) error {
+	r.sendCompressCaps(dstMac)
+	return r.postDataFrame(&protocol.MsgDataFrame{MsgType: protocol.MsgType_Msg_Packet, SrcMac: r.appConfig.TapConfig.HwMac, DstMac: dstMac, Data: data})
+}
+
+// 数据帧优先经P2P连接或中转终端发送,否则经服务端转发
+func (r *RuntimeService) postDataFrame(dataFrame *protocol.MsgDataFrame) error {
 	if r.peerState != model.StateOk {
 		return errors.New("服务未连接或未注册")
 	}
-	dataFrame := &protocol.MsgDataFrame{MsgType: protocol.MsgType_Msg_Packet, SrcMac: r.appConfig.TapConfig.HwMac, DstMac: dstMac, Data: data}
 	msgOut := &model.MessageOut{MsgContent: dataFrame}
 
-	if app.P2pService.TryForwardMessage(dstMac, dataFrame) { // P2P
+	if app.P2pService.TryForwardMessage(dataFrame.DstMac, dataFrame) { // P2P
 		return nil
 	} else if r.serverSock.Handler != nil { // 转发
 		dataFrame.Token = r.serverSock.Token
@@ -402,32 +622,151 @@ func (r *RuntimeService) PostTunTapData(dstMac uint64, data []byte) error {
 	}
 	return errors.New("没有找到有效的Sock")
 }
+
+// 广播帧复制为单播发送给有P2P连接或中转路径的在线终端,还有其他在线终端时再经服务端广播一份;
+// 各副本使用相同的发送序号,同时收到单播及广播副本的终端按防重放窗口只接收先到的一份
+func (r *RuntimeService) FanOutTunTapData(dstMac uint64, data []byte) error {
+	if r.peerState != model.StateOk {
+		return errors.New("服务未连接或未注册")
+	}
+	seq := r.NextSeq()
+	broadcast := false
+	r.groupPeers.Range(func(key, value interface{}) bool {
+		p := value.(*protocol.PeerInfo)
+		if !p.Online || p.PeerMac == r.appConfig.TapConfig.HwMac {
+			return true
+		}
+		if app.P2pService.IsP2P(p.PeerMac) || app.P2pService.IsRelayed(p.PeerMac) {
+			_ = r.postDataFrame(&protocol.MsgDataFrame{MsgType: protocol.MsgType_Msg_Packet, SrcMac: r.appConfig.TapConfig.HwMac,
+				DstMac: p.PeerMac, Data: data, Seq: seq})
+		} else {
+			broadcast = true
+		}
+		return true
+	})
+	if broadcast {
+		return r.postDataFrame(&protocol.MsgDataFrame{MsgType: protocol.MsgType_Msg_Packet, SrcMac: r.appConfig.TapConfig.HwMac,
+			DstMac: dstMac, Data: data, Seq: seq})
+	}
+	return nil
+}
+
 func (r *RuntimeService) EncryptMsg(msg *protocol.MsgDataFrame, out []byte) (int, error) {
 	if r.peerState < model.StateConnOk {
 		return 0, errors.New("客户端连接未建立,不能传输信息")
 	}
-	if len(msg.Data) == 0 {
+	if len(msg.Data) == 0 && msg.CompressCaps == 0 { // 只带可解压算法的报文加密空数据,用于认证
 		return 0, errors.New("data is nil")
 	}
-	if r.crypt != nil {
+	if r.sessions != nil && common.IsUniCast(msg.DstMac) {
+		if r.FindPeer(msg.DstMac) == nil { // 不与组外的MAC协商
+			return 0, common.ErrNoSession
+		}
+		keyId, n, err := r.sessions.Encrypt(msg.DstMac, msg.Data, out, frameAd(msg))
+		msg.KeyId = keyId
+		return n, err
+	}
+	if c, ok := r.crypt.(common.AuthCrypt); ok {
+		return c.EncodeAd(msg.Data, out, frameAd(msg))
+	} else if r.crypt != nil {
 		return r.crypt.Encode(msg.Data, out)
 	}
-	return 0, errors.New("crypt is nil")
+	return 0, common.ErrNoCrypt
 }
 
 func (r *RuntimeService) DecryptMsg(msg *protocol.MsgDataFrame, out []byte) (int, error) {
 	if r.peerState < model.StateConnOk {
 		return 0, errors.New("客户端连接未建立,不能传输信息")
 	}
-	if len(msg.Data) == 0 {
-		return 0, errors.New("data is nil")
+	if len(msg.Data) == 0 { // 不加密时才有空数据的报文
+		if r.crypt == nil {
+			return 0, common.ErrNoCrypt
+		}
+		return 0, common.ErrAuthFailed
 	}
-	if r.crypt != nil {
+	if msg.KeyId != 0 {
+		if r.sessions == nil {
+			return 0, common.ErrAuthFailed
+		}
+		return r.sessions.Decrypt(msg.SrcMac, msg.KeyId, msg.Data, out, frameAd(msg))
+	} else if r.sessions != nil && common.IsUniCast(msg.DstMac) { // 启用会话密钥后不接受以传输密码加密的单播报文
+		return 0, common.ErrAuthFailed
+	}
+	if c, ok := r.crypt.(common.AuthCrypt); ok {
+		return c.DecodeAd(msg.Data, out, frameAd(msg))
+	} else if r.crypt != nil {
 		return r.crypt.Decode(msg.Data, out)
 	}
-	return 0, errors.New("crypt is nil")
+	return 0, common.ErrNoCrypt
+}
+
+// 认证加密的附加数据: 源MAC + 发送序号,以及压缩算法、可解压的算法,防止序号被篡改后重放
+func frameAd(msg *protocol.MsgDataFrame) []byte {
+	ad := make([]byte, 16, 21)
+	binary.BigEndian.PutUint64(ad[0:], msg.SrcMac)
+	binary.BigEndian.PutUint64(ad[8:], msg.Seq)
+	if msg.Compress != common.CompressNone { // 压缩算法同样参与认证,不压缩时与旧版本相同
+		ad = append(ad, byte(msg.Compress))
+	}
+	if msg.CompressCaps != 0 { // 可解压的算法只随空数据的报文发送
+		ad = append(ad, byte(msg.CompressCaps>>24), byte(msg.CompressCaps>>16), byte(msg.CompressCaps>>8), byte(msg.CompressCaps))
+	}
+	return ad
+}
+
+func (r *RuntimeService) NextSeq() uint64 {
+	return r.replay.NextSeq()
+}
+
+// 检查数据帧序号,重放报文返回false
+// 旧版本终端不携带序号,仅在非认证加密时放行,认证加密时序号参与认证不可伪造
+func (r *RuntimeService) CheckReplay(msg *protocol.MsgDataFrame) bool {
+	if msg.Seq == 0 {
+		_, auth := r.crypt.(common.AuthCrypt)
+		return !auth && r.sessions == nil
+	}
+	if r.FindPeer(msg.SrcMac) == nil { // 只为组内终端建立接收窗口
+		return false
+	}
+	return r.replay.Accept(msg.SrcMac, msg.Seq)
+}
+
+// 与对端协商会话密钥,未启用会话密钥时忽略
+func (r *RuntimeService) StartKeyExchange(mac uint64) error {
+	if r.sessions == nil {
+		return nil
+	}
+	return r.sessions.Start(mac)
+}
+
+func (r *RuntimeService) ProcessKeyExchange(msg *protocol.MsgDataFrame) error {
+	if r.sessions == nil {
+		return errors.New("未启用会话密钥,忽略密钥协商消息")
+	}
+	if msg.DstMac != r.appConfig.TapConfig.HwMac {
+		return errors.New("密钥协商消息目标MAC无效")
+	}
+	return r.sessions.Process(msg.SrcMac, msg.KeyExchange)
+}
+
+// 密钥协商消息优先经P2P发送,否则经服务端转发
+func (r *RuntimeService) sendKeyExchange(dstMac uint64, exchange *protocol.MsgKeyExchange) error {
+	if r.peerState != model.StateOk {
+		return errors.New("服务未连接或未注册")
+	}
+	dataFrame := &protocol.MsgDataFrame{MsgType: protocol.MsgType_Msg_KeyExchange, SrcMac: r.appConfig.TapConfig.HwMac, DstMac: dstMac, KeyExchange: exchange}
+	if app.P2pService.TryForwardMessage(dstMac, dataFrame) {
+		return nil
+	} else if r.serverSock.Handler != nil {
+		dataFrame.Token = r.serverSock.Token
+		r.forwardMessage(&model.MessageOut{Handler: r.serverSock.Handler, MsgContent: dataFrame})
+		return nil
+	}
+	return errors.New("没有找到有效的Sock")
 }
-func (r *RuntimeService) sendGratuitousArp() error {
+
+// 向终端发送本终端的免费ARP,dstMac为广播地址时发给全组
+func (r *RuntimeService) sendGratuitousArp(dstMac uint64) error {
 	if r.peerState < model.StateConnOk {
 		return errors.New("客户端未连接服务,不能发送信息")
 	}
@@ -437,17 +776,9 @@ func (r *RuntimeService) sendGratuitousArp() error {
 	if r.appConfig.TapConfig.HwMac == 0 || r.appConfig.TapConfig.IpAddr == 0 {
 		return errors.New("MAC地址或IP无效,不能发送ARP报文")
 	}
-	buffer := make([]byte, len(gratuitousArp))
-	copy(buffer, gratuitousArp)
-	mac := make([]byte, 8)
-	binary.LittleEndian.PutUint64(mac, r.appConfig.TapConfig.HwMac)
-	ip := make([]byte, 4)
-	binary.BigEndian.PutUint32(ip, r.appConfig.TapConfig.IpAddr)
-	copy(buffer[6:], mac[:6])
-	copy(buffer[22:], mac[:6])
-	copy(buffer[28:], ip)
-	copy(buffer[38:], ip)
-	return r.PostTunTapData(common.MacBroadcast, buffer)
+	ip := r.appConfig.TapConfig.IpAddr
+	buffer := arpFrame(arpReply, broadcastMac, macBytes(r.appConfig.TapConfig.HwMac), ip, broadcastMac, ip)
+	return r.PostTunTapData(dstMac, buffer)
 }
 
 func (r *RuntimeService) SendAuthRequest() error {
@@ -466,9 +797,9 @@ func (r *RuntimeService) SendAuthRequest() error {
 		}
 	}
 	if r.LocalIp == nil {
-		ipStr := strings.Split(r.serverSock.Handler.Channel().LocalAddr(), ":")
-		r.updateLocalIp(ipStr[0])
-		r.updateLinkInfo(ipStr[0])
+		localIp := localHost(r.serverSock.Handler.Channel().LocalAddr())
+		r.updateLocalIp(localIp)
+		r.updateLinkInfo(localIp)
 	}
 	authMsg := &protocol.MsgAuth{
 		PeerName:    r.appConfig.PeerConfig.Name,
@@ -476,6 +807,10 @@ func (r *RuntimeService) SendAuthRequest() error {
 		PeerOs:      runtime.GOOS,
 		LinkMode:    uint32(r.linkMode),
 		LinkQuality: r.linkQuality,
+		Subnets:     r.subnets,
+		PeerAddr6:   r.ip6,
+		NatType:     atomic.LoadUint32(&r.natType),
+		Relay:       r.appConfig.Relay,
 		Group:       r.appConfig.PeerConfig.GroupName}
 	if r.appConfig.TapConfig.HwMac == 0 {
 		authMsg.AutoMac = true
@@ -537,6 +872,8 @@ func (r *RuntimeService) ProcessAuthResponse(ack *protocol.MsgAuthAck) error {
 			_ = config.SaveAppConfig()
 		}
 		r.SetPeerState(model.StateOk)
+		r.serverAcked = true
+		r.udpFails, r.serverFails = 0, 0
 		app.Logger.Info("服务注册成功")
 		app.P2pService.ResetP2PSocks()
 		if app.TunTapService.State() != model.TunTapRunning {
@@ -544,12 +881,13 @@ func (r *RuntimeService) ProcessAuthResponse(ack *protocol.MsgAuthAck) error {
 				r.SetPeerState(model.StateInitError)
 				app.Logger.Error("虚拟网卡启动失败:", err)
 			} else {
+				r.routes.Reset()
 				app.Logger.Info("虚拟网卡初始化成功,IP ", common.Uint32toIpV4(r.appConfig.TapConfig.IpAddr),
 					",MAC ", common.Uint64ToMacStr(r.appConfig.TapConfig.HwMac))
 			}
-			//_ = r.sendGratuitousArp()
 		}
 		_ = r.SendGroupPeersRequest(r.appConfig.PeerConfig.GroupName)
+		r.startNatDetect()
 		return nil
 	} else {
 		r.SetPeerState(model.StateAuthFail)
@@ -583,7 +921,10 @@ func (r *RuntimeService) ProcessPeerStateChanged(state *protocol.MsgState) error
 	// 上线
 	if state.Online {
 		if state.PeerInfo != nil {
-			r.groupPeers.Store(state.PeerMac, state.PeerInfo)
+			state.PeerInfo.Online = true
+			r.storePeer(state.PeerInfo)
+			app.TunTapService.AnnouncePeer(state.PeerInfo.PeerMac, state.PeerInfo.NetAddr)
+			_ = r.sendGratuitousArp(state.PeerInfo.PeerMac) // 新上线的终端不需查询即可得知本终端
 		}
 	} else {
 		if v, ok := r.groupPeers.Load(state.PeerMac); ok {
@@ -591,6 +932,25 @@ func (r *RuntimeService) ProcessPeerStateChanged(state *protocol.MsgState) error
 			p.Online = false
 		}
 	}
+	if r.sessions != nil { // 对端上线或离线后原会话均失效,上线后即经服务端协商,不等待首个单播报文
+		r.sessions.Remove(state.PeerMac)
+		if sessions := r.sessions; state.Online {
+			go func() {
+				if err := sessions.Start(state.PeerMac); err != nil {
+					app.Logger.Debug("会话密钥协商发起失败:", err)
+				}
+			}()
+		}
+	}
+	// 对端可能已重启,序号从新的初值开始
+	r.replay.Reset(state.PeerMac)
+	if r.compress != nil { // 对端可能更换了版本,重新协商压缩
+		r.compress.Delete(state.PeerMac)
+	}
+	if r.traffic != nil && !state.Online {
+		r.traffic.Delete(state.PeerMac)
+	}
+	r.syncRoutes()
 	app.WailsApp.UpdatePeers()
 	_ = app.P2pService.PeerStateChanged(state.PeerMac, state.Online)
 	_ = app.SerialService.PeerStateChanged(state.PeerMac, state.Online)
@@ -698,11 +1058,18 @@ func (r *RuntimeService) SendPing() error {
 		PeerName:    r.appConfig.PeerConfig.Name,
 		PeerAddr:    &protocol.IpNet{NetAddr: r.appConfig.TapConfig.IpAddr, NetBitLen: r.appConfig.TapConfig.IpMask},
 		LinkMode:    uint32(r.linkMode),
-		LinkQuality: r.linkQuality}
+		LinkQuality: r.linkQuality,
+		Subnets:     r.subnets,
+		PeerAddr6:   r.ip6,
+		NatType:     atomic.LoadUint32(&r.natType),
+		Relay:       r.appConfig.Relay}
 	if r.LocalIp != nil {
 		pingMsg.InnerAddr = &protocol.IpNet{NetAddr: r.LocalIp.NetAddr, NetBitLen: r.LocalIp.NetBitLen}
 	}
 	pingMsg.Stats = r.stats
+	r.startNatDetect()
+	r.startPmtuDetect()
+	r.markPingSent()
 	packMsg := &protocol.MsgPeerFrame{PeerMac: r.appConfig.TapConfig.HwMac,
 		MsgType: protocol.MsgType_Msg_Ping,
 		Token:   r.serverSock.Token, MsgPing: pingMsg}
@@ -711,6 +1078,43 @@ func (r *RuntimeService) SendPing() error {
 	return nil
 }
 
+// 注册成功后及每隔 natDetectInterval 探测一次NAT类型,结果随心跳上报
+func (r *RuntimeService) startNatDetect() {
+	// UDP不通时无法探测
+	if r.transport != model.TransportUdp || time.Since(r.natDetectTime) < natDetectInterval || !atomic.CompareAndSwapInt32(&r.natDetecting, 0, 1) {
+		return
+	}
+	r.natDetectTime = time.Now()
+	go func() {
+		defer atomic.StoreInt32(&r.natDetecting, 0)
+		addr := r.appConfig.NatProbeAddr
+		if len(addr) == 0 {
+			addr = r.ServerAddr()
+		}
+		natType, err := DetectNatType(addr, r.appConfig.TapConfig.HwMac)
+		if err != nil { // 保留上次探测成功的结果
+			r.probeFailed("NAT类型探测失败:", err)
+			return
+		}
+		if old := atomic.SwapUint32(&r.natType, uint32(natType)); old != uint32(natType) {
+			app.Logger.Info("NAT类型:", natType)
+		}
+	}()
+}
+
+// 探测没有应答时只警告一次,其他错误按调试信息记录
+func (r *RuntimeService) probeFailed(msg string, err error) {
+	if err != errNoProbeAck || !atomic.CompareAndSwapInt32(&r.probeWarned, 0, 1) {
+		app.Logger.Debug(msg, err)
+		return
+	}
+	app.Logger.Warn(msg, err, ",服务端需支持Msg_NatProbe;NAT类型按未知处理,不按往返时间选择及回切服务端,路径MTU不自动探测,经TCP连接时不切回UDP")
+}
+
+func (r *RuntimeService) NatType() model.NatType {
+	return model.NatType(atomic.LoadUint32(&r.natType))
+}
+
 func (r *RuntimeService) ProcessPong(pong *protocol.MsgPong) error {
 	if pong == nil {
 		return errors.New("心跳消息为空")
@@ -718,6 +1122,11 @@ func (r *RuntimeService) ProcessPong(pong *protocol.MsgPong) error {
 	if pong.Sock != nil {
 		r.externSock = pong.Sock
 	}
+	r.updateServerRtt()
+	select {
+	case r.pongNotify <- struct{}{}:
+	default:
+	}
 	return nil
 }
 
@@ -746,13 +1155,21 @@ func (r *RuntimeService) ProcessGroupPeersResponse(response *protocol.MsgGroupPe
 	}
 	if r.groupPeerCookie != response.Cookie || r.groupPeers == nil {
 		common.ClearMap(r.groupPeers)
+		common.ClearMap(r.peerIps)
+		common.ClearMap(r.peerIp6s)
 		r.groupPeerCookie = response.Cookie
 	}
 	if response.PeerInfo != nil {
 		for _, p := range response.PeerInfo {
-			r.groupPeers.Store(p.PeerMac, p)
+			r.storePeer(p)
 		}
 	}
+	r.pruneTraffic()
+	r.replay.Prune(func(mac uint64) bool {
+		p := r.FindPeer(mac)
+		return p != nil && p.Online
+	})
+	r.syncRoutes()
 	app.WailsApp.UpdatePeers()
 	return nil
 }
@@ -865,6 +1282,53 @@ func (r *RuntimeService) FindPeer(mac uint64) *protocol.PeerInfo {
 	}
 }
 
+func (r *RuntimeService) storePeer(p *protocol.PeerInfo) {
+	r.groupPeers.Store(p.PeerMac, p)
+	if p.NetAddr != 0 {
+		r.peerIps.Store(p.NetAddr, p.PeerMac)
+	}
+	if a := p.NetAddr6; a != nil && len(a.NetAddr6) == net.IPv6len {
+		var key [net.IPv6len]byte
+		copy(key[:], a.NetAddr6)
+		r.peerIp6s.Store(key, p.PeerMac)
+	}
+}
+
+// 按IPv6虚拟地址查找终端MAC,IPv6不支持到终端内网的路由
+func (r *RuntimeService) FindMacByIp6(ip []byte) (uint64, bool) {
+	if len(ip) != net.IPv6len {
+		return 0, false
+	}
+	var key [net.IPv6len]byte
+	copy(key[:], ip)
+	if mac, ok := r.peerIp6s.Load(key); ok {
+		return mac.(uint64), true
+	}
+	return 0, false
+}
+
+// 按虚拟IP查找终端MAC,不查找路由
+func (r *RuntimeService) FindPeerByIp(ip uint32) (uint64, bool) {
+	if mac, ok := r.peerIps.Load(ip); ok {
+		return mac.(uint64), true
+	}
+	return 0, false
+}
+
+// 按目的IP查找终端MAC,不在虚拟网段时按路由查找网关终端
+// 广播及组播地址没有对应终端,TUN模式下不会转发
+func (r *RuntimeService) FindMacByIp(ip uint32) (uint64, bool) {
+	if mac, ok := r.peerIps.Load(ip); ok {
+		return mac.(uint64), true
+	}
+	if gw, ok := r.routes.Lookup(ip); ok {
+		if mac, ok := r.peerIps.Load(gw); ok {
+			return mac.(uint64), true
+		}
+	}
+	return 0, false
+}
+
 func (r *RuntimeService) GetLinkInfos(mac uint64) []*protocol.LinkInfo {
 	r.linkMutex.Lock()
 	delete(r.linkInfos, mac)
@@ -890,10 +1354,12 @@ func (r *RuntimeService) GetLinkInfos(mac uint64) []*protocol.LinkInfo {
 	return nil
 }
 
-func (r *RuntimeService) SetStats(size uint64, rx bool, p2p bool) {
+// 流量计数,mac为对端终端(服务端控制报文为0),中转流量计入P2P总量
+func (r *RuntimeService) SetStats(mac uint64, size uint64, rx bool, path model.StatsPath) {
 	if r.stats == nil || r.peerState < model.StateConnOk {
 		return
 	}
+	p2p := path != model.PathServer
 	if rx {
 		if p2p {
 			r.stats.P2PReceive += size
@@ -907,39 +1373,181 @@ func (r *RuntimeService) SetStats(size uint64, rx bool, p2p bool) {
 			r.stats.TransSend += size
 		}
 	}
+	if m := r.trafficMeter(mac); m != nil {
+		m.Add(path, size, rx)
+	}
+}
+
+// 接收报文被丢弃时计数
+func (r *RuntimeService) SetDropStats(mac uint64, reason model.DropReason) {
+	if r.stats == nil {
+		return
+	}
+	switch reason {
+	case model.DropAuthFail:
+		r.stats.AuthFail++
+	case model.DropReplay:
+		r.stats.ReplayDrop++
+	}
+	if m := r.trafficMeter(mac); m != nil {
+		m.Drop(reason)
+	}
+}
+
+// 组内已知终端的流量统计,其他MAC不计数,避免伪造的源地址无限占用内存
+func (r *RuntimeService) trafficMeter(mac uint64) *model.TrafficMeter {
+	if r.traffic == nil || !common.IsUniCast(mac) || mac == r.appConfig.TapConfig.HwMac || r.FindPeer(mac) == nil {
+		return nil
+	}
+	if v, ok := r.traffic.Load(mac); ok {
+		return v.(*model.TrafficMeter)
+	}
+	v, _ := r.traffic.LoadOrStore(mac, &model.TrafficMeter{})
+	return v.(*model.TrafficMeter)
+}
+
+// 删除已离线或不在组内的终端的流量统计
+func (r *RuntimeService) pruneTraffic() {
+	if r.traffic == nil {
+		return
+	}
+	r.traffic.Range(func(key, value interface{}) bool {
+		if p := r.FindPeer(key.(uint64)); p == nil || !p.Online {
+			r.traffic.Delete(key)
+		}
+		return true
+	})
+}
+
+// 终端的流量统计,没有流量时返回nil
+func (r *RuntimeService) PeerTraffic(mac uint64) *model.PeerTraffic {
+	if r.traffic == nil {
+		return nil
+	}
+	if v, ok := r.traffic.Load(mac); ok {
+		return v.(*model.TrafficMeter).Snapshot(strconv.FormatUint(mac, 10))
+	}
+	return nil
+}
+
+// 所有终端的流量统计
+func (r *RuntimeService) TrafficList() []*model.PeerTraffic {
+	list := make([]*model.PeerTraffic, 0)
+	if r.traffic == nil {
+		return list
+	}
+	r.traffic.Range(func(key, value interface{}) bool {
+		list = append(list, value.(*model.TrafficMeter).Snapshot(strconv.FormatUint(key.(uint64), 10)))
+		return true
+	})
+	return list
 }
 
 func (r *RuntimeService) GetStats() *protocol.Statistics {
 	return r.stats
 }
 
+// 添加到终端内网的路由,可同时存在多个终端的路由,保存到配置中
 func (r *RuntimeService) AddRoute(mac uint64) bool {
 	p := r.FindPeer(mac)
 	if p == nil {
 		app.Logger.Error("路由添加失败:没有找到相应客户端")
 		return false
 	}
-	r.CleanRoutes()
-	route := &model.RouteInfo{}
-	subMask := uint32(0xFFFFFFFF)
-	segIp := p.InterAddr & (subMask << (32 - p.InterNetBitLen))
-	route.Destination = segIp
-	route.Mask = subMask << (32 - p.InterNetBitLen)
-	route.Gateway = p.NetAddr
-
-	err := app.TunTapService.AddRoute(route.GetDst(), route.GetMask(), route.GetGw())
-	if err != nil {
+	if err := r.routes.Add(p, r.localNets()); err != nil {
 		app.Logger.Error("路由添加失败:", err)
 		return false
 	}
-	r.lastRoute = route
+	r.saveRoutes()
 	return true
 }
 
+func (r *RuntimeService) RemoveRoute(mac uint64) bool {
+	if err := r.routes.Remove(mac); err != nil {
+		app.Logger.Error("路由删除失败:", err)
+		return false
+	}
+	r.saveRoutes()
+	return true
+}
+
+func (r *RuntimeService) HasRoute(mac uint64) bool {
+	return r.routes != nil && r.routes.Has(mac)
+}
+
+// 移除系统路由,路由配置保留,重新注册后恢复
 func (r *RuntimeService) CleanRoutes() {
-	if r.lastRoute != nil {
-		_ = app.TunTapService.DelRoute(r.lastRoute.GetDst(), r.lastRoute.GetMask(), r.lastRoute.GetGw())
+	if r.routes != nil {
+		r.routes.Clean()
+	}
+}
+
+// 按终端当前状态同步路由表中所有终端的系统路由及静态路由
+func (r *RuntimeService) syncRoutes() {
+	local := r.localNets()
+	for _, mac := range r.routes.Macs() {
+		if p := r.FindPeer(mac); p != nil {
+			if err := r.routes.Sync(p, local); err != nil {
+				app.Logger.Warn("路由同步失败<", p.PeerName, ">:", err)
+			}
+		}
+	}
+	peers := make([]*protocol.PeerInfo, 0)
+	r.groupPeers.Range(func(key, value interface{}) bool {
+		if p := value.(*protocol.PeerInfo); p.PeerMac != r.appConfig.TapConfig.HwMac {
+			peers = append(peers, p)
+		}
+		return true
+	})
+	r.routes.SyncStatic(peers, local)
+}
+
+// 解析配置中的IPv6虚拟地址,如 fd00::2/64
+func parseIp6Net(cidr string) *protocol.IpNet {
+	if len(cidr) == 0 {
+		return nil
+	}
+	ip, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil || ip.To4() != nil {
+		app.Logger.Warn("IPv6虚拟地址格式错误<", cidr, ">")
+		return nil
+	}
+	ones, _ := ipNet.Mask.Size()
+	return &protocol.IpNet{NetAddr6: ip.To16(), NetBitLen: uint32(ones)}
+}
+
+// 解析配置中的CIDR网段列表,格式错误的项记录日志后忽略
+func parseSubnets(list []string, name string) []*protocol.IpNet {
+	nets := make([]*protocol.IpNet, 0, len(list))
+	for _, cidr := range list {
+		addr, bitLen, err := common.ParseCidr(cidr)
+		if err != nil {
+			app.Logger.Warn(name, "格式错误<", cidr, ">:", err)
+			continue
+		}
+		nets = append(nets, &protocol.IpNet{NetAddr: addr, NetBitLen: bitLen})
+	}
+	return nets
+}
+
+// 虚拟网段及本地网卡网段,终端路由不能与之重叠
+func (r *RuntimeService) localNets() []*model.RouteInfo {
+	nets := make([]*model.RouteInfo, 0, 2)
+	if tap := r.appConfig.TapConfig; tap.IpAddr != 0 && tap.IpMask > 0 && tap.IpMask <= 32 {
+		mask := uint32(0xFFFFFFFF) << (32 - tap.IpMask)
+		nets = append(nets, &model.RouteInfo{Destination: tap.IpAddr & mask, Mask: mask})
+	}
+	if r.LocalIp != nil && r.LocalIp.NetBitLen > 0 && r.LocalIp.NetBitLen <= 32 {
+		mask := uint32(0xFFFFFFFF) << (32 - r.LocalIp.NetBitLen)
+		nets = append(nets, &model.RouteInfo{Destination: r.LocalIp.NetAddr & mask, Mask: mask})
+	}
+	return nets
+}
+
+func (r *RuntimeService) saveRoutes() {
+	r.appConfig.Routes = r.routes.Configs()
+	config.AppConfig.Routes = r.routes.Configs()
+	if err := config.SaveAppConfig(); err != nil {
+		app.Logger.Error("路由配置保存失败:", err)
 	}
-	r.lastRoute = nil
-	return
 }
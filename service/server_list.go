@@ -0,0 +1,183 @@
+package service
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"vilan/app"
+	"vilan/common"
+	"vilan/model"
+)
+
+// 服务端地址列表:主服务端(server_ip/server_port)及备用服务端(servers),
+// 当前服务端连续 serverMaxFails 次连接失败或没有注册应答时切换到下一个,
+// 地址可为域名,每次连接前重新解析;server_pick_rtt 为true时启动时选择往返时间最短的服务端。
+// 切换到其他服务端后每隔 serverFailbackInterval 探测一次排在前面的服务端(按往返时间选择时重新选择),可达时切换回去。
+// 往返时间选择及回切探测使用NAT探测请求,需服务端对其应答,不应答的服务端视为不可达;
+// 当前连接的服务端也不应答时说明服务端不支持探测,不回切
+
+const (
+	serverMaxFails         = 2
+	serverFailbackInterval = 5 * time.Minute
+)
+
+type serverEndpoint struct {
+	host string
+	port int32
+}
+
+func (e *serverEndpoint) String() string {
+	return net.JoinHostPort(e.host, strconv.Itoa(int(e.port)))
+}
+
+func serverEndpoints(conf *model.AppConfig) []*serverEndpoint {
+	list := []*serverEndpoint{{host: conf.ServerIp, port: conf.ServerPort}}
+	for _, s := range conf.Servers {
+		host, portStr, err := net.SplitHostPort(strings.TrimSpace(s))
+		if err != nil || !common.IsHost(host) {
+			app.Logger.Warn("备用服务地址格式错误:", s)
+			continue
+		}
+		port, err := strconv.ParseUint(portStr, 10, 16)
+		if err != nil || port == 0 {
+			app.Logger.Warn("备用服务地址格式错误:", s)
+			continue
+		}
+		ep := &serverEndpoint{host: host, port: int32(port)}
+		exist := false
+		for _, e := range list {
+			if e.String() == ep.String() {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			list = append(list, ep)
+		}
+	}
+	return list
+}
+
+// 解析当前服务端地址
+func (r *RuntimeService) resolveServer() error {
+	if len(r.servers) == 0 {
+		return errors.New("没有配置服务地址")
+	}
+	ep := r.servers[r.serverIndex]
+	addr, err := net.ResolveUDPAddr("udp", ep.String())
+	if err != nil {
+		return err
+	}
+	r.serverIp = addr.IP.String()
+	return nil
+}
+
+// 切换到下一个服务端
+func (r *RuntimeService) nextServer() {
+	r.serverFails = 0
+	if len(r.servers) < 2 {
+		return
+	}
+	r.serverIndex = (r.serverIndex + 1) % len(r.servers)
+	r.udpFails = 0
+	app.Logger.Warn("切换服务端:", r.servers[r.serverIndex])
+}
+
+// 并发探测各服务端的往返时间,选择最短的一个
+func (r *RuntimeService) pickServer() {
+	if !r.appConfig.ServerPickRtt || len(r.servers) < 2 {
+		return
+	}
+	best, rtt := r.probeServers(len(r.servers))
+	if best < 0 {
+		r.probeFailed("服务端往返时间探测失败,使用主服务端:", errNoProbeAck)
+		return
+	}
+	r.serverIndex = best
+	app.Logger.Info("选择服务端:", r.servers[best], ",往返时间", rtt.Milliseconds(), "ms")
+}
+
+// 并发探测前count个服务端,返回往返时间最短的一个,都不可达时返回-1
+func (r *RuntimeService) probeServers(count int) (int, time.Duration) {
+	rtts := make([]time.Duration, count)
+	var wg sync.WaitGroup
+	for i, ep := range r.servers[:count] {
+		wg.Add(1)
+		go func(i int, ep *serverEndpoint) {
+			defer wg.Done()
+			rtts[i] = -1
+			addr, err := net.ResolveUDPAddr("udp", ep.String())
+			if err != nil {
+				return
+			}
+			if rtt, err := ProbeRtt(addr.String(), r.appConfig.TapConfig.HwMac); err == nil {
+				rtts[i] = rtt
+			}
+		}(i, ep)
+	}
+	wg.Wait()
+	best := -1
+	for i, rtt := range rtts {
+		if rtt >= 0 && (best < 0 || rtt < rtts[best]) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return -1, 0
+	}
+	return best, rtts[best]
+}
+
+// 当前不是首选服务端时定时在后台探测,可回切的服务端经 failback 交给状态监测协程切换
+func (r *RuntimeService) checkFailback() {
+	if r.serverIndex == r.serverPrefer || r.transport != model.TransportUdp || time.Since(r.failbackTime) < serverFailbackInterval ||
+		!atomic.CompareAndSwapInt32(&r.failbacking, 0, 1) {
+		return
+	}
+	r.failbackTime = time.Now()
+	current := r.serverIndex
+	go func() {
+		defer atomic.StoreInt32(&r.failbacking, 0)
+		// 已连接的服务端也不应答时无法判断其他服务端是否可达,不回切
+		if _, err := ProbeRtt(r.ServerAddr(), r.appConfig.TapConfig.HwMac); err != nil {
+			r.probeFailed("服务端回切探测失败:", err)
+			return
+		}
+		best := -1
+		if r.appConfig.ServerPickRtt {
+			best, _ = r.probeServers(len(r.servers))
+		} else {
+			// 排在当前服务端之前的服务端中第一个可达的
+			for i := 0; i < current && best < 0; i++ {
+				if addr, err := net.ResolveUDPAddr("udp", r.servers[i].String()); err == nil {
+					if _, err = ProbeRtt(addr.String(), r.appConfig.TapConfig.HwMac); err == nil {
+						best = i
+					}
+				}
+			}
+		}
+		if best >= 0 && best != current {
+			select {
+			case r.failback <- best:
+			default:
+			}
+		}
+	}()
+}
+
+// 当前服务端解析后的地址 ip:port
+func (r *RuntimeService) ServerAddr() string {
+	port := r.appConfig.ServerPort
+	if r.serverIndex < len(r.servers) {
+		port = r.servers[r.serverIndex].port
+	}
+	ip := r.serverIp
+	if len(ip) == 0 {
+		ip = r.appConfig.ServerIp
+	}
+	return net.JoinHostPort(ip, strconv.Itoa(int(port)))
+}
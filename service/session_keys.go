@@ -0,0 +1,382 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+	"io"
+	"sync"
+	"time"
+	"vilan/app"
+	"vilan/common"
+	"vilan/model"
+	"vilan/protocol"
+)
+
+// 终端间会话密钥协商流程:
+// 发起方生成临时X25519密钥,发送公钥(以传输密码计算HMAC防止组外终端冒充)
+// 应答方生成临时密钥,计算共享密钥后回复公钥,新会话暂不启用
+// 发起方启用新会话并回复确认(以新会话派生的确认密钥计算HMAC),应答方收到确认或以新会话解密成功后才启用新会话;
+// 重放的旧请求得不到确认,不会替换当前会话,近期收到过的请求公钥直接拒绝
+// 双方以共享密钥经HKDF派生两个方向的报文密钥,临时私钥用完即丢弃(前向安全)
+// 会话超过 sessionRekeyTime 后重新协商,旧密钥保留 sessionKeepTime 用于解密在途报文,对端未启用新会话前一直保留
+// 注意:协商消息的HMAC只证明发送方持有传输密码,组内任一终端都能冒充其他两个终端之间的协商(中间人),
+// 会话密钥只防范组外的窃听及篡改,不能在组内终端之间相互隔离
+
+const (
+	sessionRekeyTime  = 600 // 会话密钥更新间隔(秒)
+	sessionKeepTime   = 60  // 更新后旧密钥的保留时间(秒)
+	handshakeInterval = 5   // 协商未完成时的重发间隔(秒)
+)
+
+type peerSession struct {
+	keyId       uint32
+	send        common.AuthCrypt
+	recv        common.AuthCrypt
+	created     int64
+	expire      int64  // 被新会话替换后的失效时间
+	confirm     []byte // 确认消息的HMAC
+	public      []byte // 应答方公钥,发起方重发确认时使用
+	confirmed   bool   // 对端已启用该会话
+	confirmSent int64
+}
+
+type handshakeState struct {
+	keyId   uint32
+	private []byte
+	public  []byte
+	started int64
+}
+
+type SessionKeys struct {
+	mutex     sync.Mutex
+	hwMac     uint64
+	cryptType model.CryptType
+	authKey   []byte
+	current   map[uint64]*peerSession
+	previous  map[uint64]*peerSession
+	next      map[uint64]*peerSession // 应答方等待发起方确认的会话
+	pending   map[uint64]*handshakeState
+	triggered map[uint64]int64 // 发送报文触发协商的时间,间隔内不重复触发
+	seen      map[string]int64 // 近期收到的请求公钥,拒绝重放
+	send      func(dstMac uint64, msg *protocol.MsgKeyExchange) error
+}
+
+// 仅认证加密方式支持会话密钥,groupKey为传输密码派生的密钥
+func NewSessionKeys(conf *model.AppConfig, groupKey []byte, send func(dstMac uint64, msg *protocol.MsgKeyExchange) error) (*SessionKeys, error) {
+	if !conf.PeerConfig.CryptType.Authenticated() {
+		return nil, errors.New("会话密钥仅支持AES-GCM及ChaCha20-Poly1305加密方式")
+	}
+	authKey := hmac.New(sha256.New, groupKey)
+	authKey.Write([]byte("vilan key exchange"))
+	return &SessionKeys{
+		hwMac:     conf.TapConfig.HwMac,
+		cryptType: conf.PeerConfig.CryptType,
+		authKey:   authKey.Sum(nil),
+		current:   make(map[uint64]*peerSession),
+		previous:  make(map[uint64]*peerSession),
+		next:      make(map[uint64]*peerSession),
+		pending:   make(map[uint64]*handshakeState),
+		triggered: make(map[uint64]int64),
+		seen:      make(map[string]int64),
+		send:      send,
+	}, nil
+}
+
+// 发起协商,已在协商中则不重复发送
+func (s *SessionKeys) Start(dstMac uint64) error {
+	s.mutex.Lock()
+	if h, ok := s.pending[dstMac]; ok && time.Now().Unix()-h.started < handshakeInterval {
+		s.mutex.Unlock()
+		return nil
+	}
+	h, err := newHandshake(0)
+	if err != nil {
+		s.mutex.Unlock()
+		return err
+	}
+	s.pending[dstMac] = h
+	s.mutex.Unlock()
+
+	msg := &protocol.MsgKeyExchange{KeyId: h.keyId, PublicKey: h.public}
+	msg.Auth = s.sign(s.hwMac, dstMac, msg, nil)
+	return s.send(dstMac, msg)
+}
+
+// 处理对端的协商请求、应答或确认
+func (s *SessionKeys) Process(srcMac uint64, msg *protocol.MsgKeyExchange) error {
+	if msg == nil || len(msg.PublicKey) != curve25519.PointSize {
+		return errors.New("无效的密钥协商消息")
+	}
+	if msg.Confirm {
+		return s.processConfirm(srcMac, msg)
+	}
+	if !msg.Response {
+		if !hmac.Equal(msg.Auth, s.sign(srcMac, s.hwMac, msg, nil)) {
+			return errors.New("密钥协商认证失败,请确认传输密码一致")
+		}
+		now := time.Now().Unix()
+		s.mutex.Lock()
+		if _, ok := s.seen[string(msg.PublicKey)]; ok {
+			s.mutex.Unlock()
+			return errors.New("重复的密钥协商请求")
+		}
+		for k, t := range s.seen {
+			if now-t > 2*sessionRekeyTime {
+				delete(s.seen, k)
+			}
+		}
+		s.seen[string(msg.PublicKey)] = now
+		// 双方同时发起时,MAC较小的一方作为发起方
+		if _, ok := s.pending[srcMac]; ok && s.hwMac < srcMac {
+			s.mutex.Unlock()
+			return nil
+		}
+		delete(s.pending, srcMac)
+		s.mutex.Unlock()
+
+		h, err := newHandshake(msg.KeyId)
+		if err != nil {
+			return err
+		}
+		session, err := s.derive(h, msg.PublicKey, srcMac, s.hwMac)
+		if err != nil {
+			return err
+		}
+		// 收到确认前仍使用当前会话
+		s.mutex.Lock()
+		s.next[srcMac] = session
+		s.mutex.Unlock()
+		ack := &protocol.MsgKeyExchange{KeyId: h.keyId, PublicKey: h.public, Response: true}
+		ack.Auth = s.sign(s.hwMac, srcMac, ack, msg.PublicKey)
+		return s.send(srcMac, ack)
+	}
+
+	s.mutex.Lock()
+	h, ok := s.pending[srcMac]
+	if !ok || h.keyId != msg.KeyId {
+		s.mutex.Unlock()
+		return errors.New("没有对应的密钥协商请求")
+	}
+	if !hmac.Equal(msg.Auth, s.sign(srcMac, s.hwMac, msg, h.public)) {
+		s.mutex.Unlock()
+		return errors.New("密钥协商应答认证失败,请确认传输密码一致")
+	}
+	delete(s.pending, srcMac)
+	s.mutex.Unlock()
+
+	session, err := s.derive(h, msg.PublicKey, s.hwMac, srcMac)
+	if err != nil {
+		return err
+	}
+	session.public = msg.PublicKey
+	session.confirmSent = time.Now().Unix()
+	s.install(srcMac, session)
+	return s.send(srcMac, confirmMessage(session))
+}
+
+// 应答方收到确认后启用新会话
+func (s *SessionKeys) processConfirm(srcMac uint64, msg *protocol.MsgKeyExchange) error {
+	s.mutex.Lock()
+	session, ok := s.next[srcMac]
+	if !ok || session.keyId != msg.KeyId {
+		s.mutex.Unlock()
+		return nil // 已以新会话解密成功或为重发的确认
+	}
+	if !hmac.Equal(msg.Auth, session.confirm) {
+		s.mutex.Unlock()
+		return errors.New("密钥协商确认认证失败")
+	}
+	delete(s.next, srcMac)
+	session.confirmed = true
+	s.mutex.Unlock()
+	s.install(srcMac, session)
+	return nil
+}
+
+func confirmMessage(session *peerSession) *protocol.MsgKeyExchange {
+	return &protocol.MsgKeyExchange{KeyId: session.keyId, PublicKey: session.public, Confirm: true, Auth: session.confirm}
+}
+
+// 使用会话密钥加密,没有会话时发起协商并返回ErrNoSession;会话到期时发起重新协商,期间仍使用当前会话
+func (s *SessionKeys) Encrypt(dstMac uint64, src []byte, dst []byte, ad []byte) (uint32, int, error) {
+	s.mutex.Lock()
+	session, ok := s.current[dstMac]
+	start := s.trigger(dstMac, session, time.Now().Unix())
+	s.mutex.Unlock()
+	if start {
+		go func() {
+			if err := s.Start(dstMac); err != nil {
+				app.Logger.Debug("会话密钥协商发起失败:", err)
+			}
+		}()
+	}
+	if !ok {
+		return 0, 0, common.ErrNoSession
+	}
+	n, err := session.send.EncodeAd(src, dst, ad)
+	return session.keyId, n, err
+}
+
+// 是否需要由发送的报文发起协商,调用时持有s.mutex;每个对端在 handshakeInterval 内只触发一次
+func (s *SessionKeys) trigger(dstMac uint64, session *peerSession, now int64) bool {
+	if session != nil && now-session.created <= sessionRekeyTime {
+		return false
+	}
+	if next, ok := s.next[dstMac]; session == nil && ok && now-next.created < handshakeInterval { // 等待发起方确认
+		return false
+	}
+	if now-s.triggered[dstMac] < handshakeInterval {
+		return false
+	}
+	s.triggered[dstMac] = now
+	return true
+}
+
+// 以当前会话、旧会话或待确认的新会话解密;待确认的新会话解密成功视为发起方已确认
+func (s *SessionKeys) Decrypt(srcMac uint64, keyId uint32, src []byte, dst []byte, ad []byte) (int, error) {
+	now := time.Now().Unix()
+	s.mutex.Lock()
+	current := s.current[srcMac]
+	session, next := current, false
+	if session == nil || session.keyId != keyId {
+		session = s.previous[srcMac]
+		// 对端尚未启用新会话时旧会话一直有效
+		if session != nil && (session.keyId != keyId || now > session.expire && (current == nil || current.confirmed)) {
+			session = nil
+		}
+	}
+	if session == nil {
+		if session = s.next[srcMac]; session != nil && session.keyId == keyId {
+			next = true
+		} else {
+			session = nil
+		}
+	}
+	s.mutex.Unlock()
+	if session == nil {
+		return 0, common.ErrAuthFailed
+	}
+	n, err := session.recv.DecodeAd(src, dst, ad)
+	if err != nil {
+		return 0, err
+	}
+	promote, resend := false, false
+	s.mutex.Lock()
+	switch {
+	case next:
+		if promote = s.next[srcMac] == session; promote {
+			delete(s.next, srcMac)
+			session.confirmed = true
+		}
+	case session == current:
+		current.confirmed = true
+	case current != nil && !current.confirmed && now-current.confirmSent >= handshakeInterval:
+		// 对端仍使用旧会话,确认可能丢失,重发
+		current.confirmSent, resend = now, true
+	}
+	s.mutex.Unlock()
+	if promote {
+		s.install(srcMac, session)
+	}
+	if resend {
+		go func() {
+			_ = s.send(srcMac, confirmMessage(current))
+		}()
+	}
+	return n, nil
+}
+
+// 终端离线时移除会话
+func (s *SessionKeys) Remove(mac uint64) {
+	s.mutex.Lock()
+	delete(s.current, mac)
+	delete(s.previous, mac)
+	delete(s.next, mac)
+	delete(s.pending, mac)
+	delete(s.triggered, mac)
+	s.mutex.Unlock()
+}
+
+func (s *SessionKeys) install(mac uint64, session *peerSession) {
+	s.mutex.Lock()
+	if old, ok := s.current[mac]; ok {
+		old.expire = time.Now().Unix() + sessionKeepTime
+		s.previous[mac] = old
+	}
+	s.current[mac] = session
+	s.mutex.Unlock()
+	if peer := app.RuntimeService.FindPeer(mac); peer != nil {
+		app.Logger.Debug("会话密钥协商完成:->", peer.PeerName)
+	}
+}
+
+// initiator 发起方MAC, responder 应答方MAC
+func (s *SessionKeys) derive(h *handshakeState, peerPublic []byte, initiator uint64, responder uint64) (*peerSession, error) {
+	shared, err := curve25519.X25519(h.private, peerPublic)
+	if err != nil {
+		return nil, err
+	}
+	info := make([]byte, 20)
+	binary.BigEndian.PutUint64(info[0:], initiator)
+	binary.BigEndian.PutUint64(info[8:], responder)
+	binary.BigEndian.PutUint32(info[16:], h.keyId)
+	keys := make([]byte, 96)
+	if _, err = io.ReadFull(hkdf.New(sha256.New, shared, s.authKey, append([]byte("vilan session"), info...)), keys); err != nil {
+		return nil, err
+	}
+	out, in := keys[:32], keys[32:64]
+	if s.hwMac == responder {
+		out, in = in, out
+	}
+	confirm := hmac.New(sha256.New, keys[64:])
+	confirm.Write([]byte("vilan session confirm"))
+	session := &peerSession{keyId: h.keyId, created: time.Now().Unix(), confirm: confirm.Sum(nil)}
+	if s.cryptType == model.CryptChaCha20 {
+		session.send, session.recv = common.NewChaChaCryptKey(out), common.NewChaChaCryptKey(in)
+	} else {
+		session.send, session.recv = common.NewAesGcmCryptKey(out), common.NewAesGcmCryptKey(in)
+	}
+	return session, nil
+}
+
+// 应答消息同时签入发起方公钥,防止应答被用于其他请求
+func (s *SessionKeys) sign(srcMac uint64, dstMac uint64, msg *protocol.MsgKeyExchange, requestPublic []byte) []byte {
+	mac := hmac.New(sha256.New, s.authKey)
+	head := make([]byte, 21)
+	binary.BigEndian.PutUint64(head[0:], srcMac)
+	binary.BigEndian.PutUint64(head[8:], dstMac)
+	binary.BigEndian.PutUint32(head[16:], msg.KeyId)
+	if msg.Response {
+		head[20] = 1
+	}
+	mac.Write(head)
+	mac.Write(msg.PublicKey)
+	mac.Write(requestPublic)
+	return mac.Sum(nil)
+}
+
+// keyId 为0时随机生成
+func newHandshake(keyId uint32) (*handshakeState, error) {
+	h := &handshakeState{keyId: keyId, private: make([]byte, curve25519.ScalarSize), started: time.Now().Unix()}
+	if _, err := rand.Read(h.private); err != nil {
+		return nil, err
+	}
+	for h.keyId == 0 {
+		buf := make([]byte, 4)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		h.keyId = binary.BigEndian.Uint32(buf)
+	}
+	public, err := curve25519.X25519(h.private, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	h.public = public
+	return h, nil
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"testing"
+	"vilan/model"
+)
+
+func TestSessionTrigger(t *testing.T) {
+	const now = 100000
+	var cases = []struct {
+		name      string
+		session   *peerSession
+		next      *peerSession
+		triggered int64
+		want      bool
+	}{
+		{name: "no session", want: true},
+		{name: "triggered", triggered: now - handshakeInterval + 1},
+		{name: "retry", triggered: now - handshakeInterval, want: true},
+		{name: "current", session: &peerSession{created: now - 1}},
+		{name: "rekey", session: &peerSession{created: now - sessionRekeyTime - 1}, want: true},
+		{name: "rekey pending", session: &peerSession{created: now - sessionRekeyTime - 1}, triggered: now - 1},
+		{name: "wait confirm", next: &peerSession{created: now - 1}},
+		{name: "confirm timeout", next: &peerSession{created: now - handshakeInterval}, want: true},
+	}
+	conf := &model.AppConfig{PeerConfig: &model.PeerConfig{CryptType: model.CryptAESGCM}, TapConfig: &model.TapConfig{HwMac: 0x0000010203EE}}
+	for _, c := range cases {
+		s, err := NewSessionKeys(conf, make([]byte, 32), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.next != nil {
+			s.next[testPeerMac] = c.next
+		}
+		if c.triggered != 0 {
+			s.triggered[testPeerMac] = c.triggered
+		}
+		if got := s.trigger(testPeerMac, c.session, now); got != c.want {
+			t.Fatalf("%s: %v != %v", c.name, got, c.want)
+		}
+		// 触发后间隔内不再触发
+		if c.want && s.trigger(testPeerMac, c.session, now+1) {
+			t.Fatalf("%s: triggered twice", c.name)
+		}
+	}
+}
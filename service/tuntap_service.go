@@ -4,6 +4,8 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"net"
+	"sync/atomic"
 	"time"
 	"vilan/app"
 	"vilan/common"
@@ -12,11 +14,20 @@ import (
 	"vilan/tuntap"
 )
 
+const (
+	ethTypeIpv4 = 0x0800
+	ethTypeArp  = 0x0806
+	ethTypeIpv6 = 0x86DD
+)
+
 type TunTapService struct {
 	tapper  tuntap.TapDevice
 	state   model.TunTapState
+	tun     bool // TUN模式,网卡读写IP报文,网络上仍以以太网帧传输以兼容TAP终端
 	readBuf []byte
 	macBuf  []byte
+	mtu     int32 // 当前MTU,探测到路径MTU后调整
+	cast    *broadcastPolicy
 }
 
 func NewTunTapService() *TunTapService {
@@ -32,7 +43,12 @@ func (t *TunTapService) createTap() (err error) {
 		t.state = model.TunTapUnInit
 		return errors.New("虚拟网卡目前没有有效的IP或MAC地址")
 	}
-	t.tapper, err = tuntap.CreateTapDevice(config.AppConfig.TapConfig.Name)
+	t.tun = config.AppConfig.TapConfig.DevType == model.TUN
+	if t.tun {
+		t.tapper, err = tuntap.CreateTunDevice(config.AppConfig.TapConfig.Name)
+	} else {
+		t.tapper, err = tuntap.CreateTapDevice(config.AppConfig.TapConfig.Name)
+	}
 	if err != nil {
 		t.state = model.TunTapInitFailed
 		return err
@@ -56,7 +72,8 @@ func (t *TunTapService) Start() error {
 	if err := t.createTap(); err != nil {
 		return err
 	}
-	if curMac, err := t.tapper.GetMac(); err != nil {
+	if t.tun { // TUN设备没有MAC地址
+	} else if curMac, err := t.tapper.GetMac(); err != nil {
 		return errors.New(fmt.Sprintf("虚拟网卡MAC地址获取失败:%s", err.Error()))
 	} else {
 		if curMac != config.AppConfig.TapConfig.HwMac {
@@ -69,9 +86,30 @@ func (t *TunTapService) Start() error {
 	if err := t.tapper.SetIpAddr(common.Uint32toIpV4(config.AppConfig.TapConfig.IpAddr), common.Uint32toIpV4(mask)); err != nil {
 		return errors.New(fmt.Sprintf("虚拟网卡IP地址设置失败:%s", err.Error()))
 	}
-	_ = t.tapper.SetMtu(1350)
+	if ip6 := config.AppConfig.TapConfig.Ip6Addr; len(ip6) > 0 { // IPv6地址可选,设置失败不影响IPv4
+		ip, ipNet, err := net.ParseCIDR(ip6)
+		if err != nil || ip.To4() != nil {
+			app.Logger.Warn("IPv6虚拟地址格式错误:", ip6)
+		} else {
+			ones, _ := ipNet.Mask.Size()
+			if err = t.tapper.SetIp6Addr(ip.String(), ones); err != nil {
+				app.Logger.Warn("虚拟网卡IPv6地址设置失败:", err)
+			}
+		}
+	}
+	if atomic.LoadInt32(&t.mtu) == 0 {
+		mtu := configuredMtu()
+		if mtu == 0 {
+			mtu = model.TunnelMtu(model.PathMtuDefault, false)
+		}
+		atomic.StoreInt32(&t.mtu, int32(mtu))
+	}
+	if err := t.tapper.SetMtu(uint(t.Mtu())); err != nil {
+		app.Logger.Warn("虚拟网卡MTU设置失败:", err)
+	}
 	_ = t.tapper.Up()
-	t.readBuf = make([]byte, model.SizeMaxPacket)
+	t.cast = newBroadcastPolicy(config.AppConfig.Broadcast)
+	t.readBuf = make([]byte, model.SizeMaxFrame)
 	// 开启协程
 	go t.readTap()
 	return nil
@@ -102,8 +140,12 @@ func (t *TunTapService) readTap() {
 		t.state = model.TunTapStop
 	}()
 	t.state = model.TunTapRunning
+	offset := 0
+	if t.tun { // 预留以太网头,转发时原地补齐
+		offset = model.SizeEthFrame
+	}
 	for t.state == model.TunTapRunning {
-		n, err := t.tapper.Read(t.readBuf[:])
+		n, err := t.tapper.Read(t.readBuf[offset:])
 		if err != nil || n == 0 {
 			if t.state != model.TunTapRunning {
 				return
@@ -120,7 +162,11 @@ func (t *TunTapService) readTap() {
 			continue
 		}
 		// 处理tun tap读取的数据
-		t.tapData2Net(t.readBuf[:n])
+		if t.tun {
+			t.tunData2Net(t.readBuf[:offset+n])
+		} else {
+			t.tapData2Net(t.readBuf[:n])
+		}
 	}
 }
 
@@ -132,12 +178,50 @@ func (t *TunTapService) tapData2Net(data []byte) {
 	if dataLen < model.SizeEthFrame { //model.SizeMac *2 + 2
 		return
 	}
+	if t.proxyArp(data) {
+		return
+	}
 	// 取出数据 封装为MsgPacket  找到出口sock(server或者已建立的p2p)  发送数据
 	copy(t.macBuf[:], data[:6])
 	dstMac := binary.LittleEndian.Uint64(t.macBuf)
+	if !common.IsUniCast(dstMac) {
+		if !t.cast.allow(data) {
+			return
+		}
+		if t.cast.fanout {
+			_ = app.RuntimeService.FanOutTunTapData(dstMac, data[:])
+			return
+		}
+	}
+	t.clampMss(dstMac, data)
 	_ = app.RuntimeService.PostTunTapData(dstMac, data[:]) // 转发tap数据到相关socket
 }
 
+// frame 前 model.SizeEthFrame 字节为预留的以太网头,其后为IP报文
+func (t *TunTapService) tunData2Net(frame []byte) {
+	packet := frame[model.SizeEthFrame:]
+	var dstMac uint64
+	var ok bool
+	ethType := uint16(ethTypeIpv4)
+	switch {
+	case len(packet) >= 20 && packet[0]>>4 == 4:
+		dstMac, ok = app.RuntimeService.FindMacByIp(binary.BigEndian.Uint32(packet[16:20]))
+	case len(packet) >= 40 && packet[0]>>4 == 6:
+		dstMac, ok = app.RuntimeService.FindMacByIp6(packet[24:40])
+		ethType = ethTypeIpv6
+	}
+	if !ok {
+		return
+	}
+	binary.LittleEndian.PutUint64(t.macBuf, dstMac)
+	copy(frame[0:6], t.macBuf[:6])
+	binary.LittleEndian.PutUint64(t.macBuf, config.AppConfig.TapConfig.HwMac)
+	copy(frame[6:12], t.macBuf[:6])
+	binary.BigEndian.PutUint16(frame[12:14], ethType)
+	t.clampMss(dstMac, frame)
+	_ = app.RuntimeService.PostTunTapData(dstMac, frame)
+}
+
 func (t *TunTapService) WriteData2TunTap(data []byte) (int, error) {
 	if t.state != model.TunTapRunning {
 		return 0, errors.New("虚拟网卡服务未运行")
@@ -145,8 +229,71 @@ func (t *TunTapService) WriteData2TunTap(data []byte) (int, error) {
 	if t.tapper == nil {
 		return 0, errors.New("虚拟网络不能正常启动")
 	}
+	if isTcpSyn(data) {
+		src := make([]byte, 8)
+		copy(src, data[6:12])
+		t.clampMss(binary.LittleEndian.Uint64(src), data)
+	}
+	if t.tun {
+		return t.writeTun(data)
+	}
 	return t.tapper.Write(data[:])
 }
+
+// 去掉以太网头写入IP报文,其他终端的ARP请求由本端应答,其余二层报文丢弃
+func (t *TunTapService) writeTun(data []byte) (int, error) {
+	if len(data) < model.SizeEthFrame {
+		return 0, errors.New("以太网帧长度错误")
+	}
+	switch binary.BigEndian.Uint16(data[12:14]) {
+	case ethTypeIpv4, ethTypeIpv6:
+		n, err := t.tapper.Write(data[model.SizeEthFrame:])
+		return n + model.SizeEthFrame, err
+	case ethTypeArp:
+		t.replyArp(data)
+	}
+	return len(data), nil
+}
+
+func (t *TunTapService) replyArp(frame []byte) {
+	senderMac, senderIp, targetIp, ok := parseArpRequest(frame)
+	if !ok || targetIp != config.AppConfig.TapConfig.IpAddr {
+		return
+	}
+	reply := arpFrame(arpReply, senderMac, macBytes(config.AppConfig.TapConfig.HwMac), targetIp, senderMac, senderIp)
+	srcMac := make([]byte, 8)
+	copy(srcMac, senderMac)
+	_ = app.RuntimeService.PostTunTapData(binary.LittleEndian.Uint64(srcMac), reply)
+}
+
+// 到该终端的隧道MTU小于网卡MTU时,调整双方TCP连接请求的MSS
+// 配置了虚拟网卡MTU时超过路径MTU的报文分片传输,不调整MSS
+func (t *TunTapService) clampMss(peer uint64, frame []byte) {
+	if config.AppConfig.TapConfig.Mtu > 0 || !isTcpSyn(frame) {
+		return
+	}
+	if mtu := app.P2pService.PeerMtu(peer); mtu > 0 && mtu < t.Mtu() {
+		clampTcpMss(frame, mtu)
+	}
+}
+
+// 设置虚拟网卡MTU,网卡未启动时在启动时设置
+func (t *TunTapService) SetMtu(mtu int) error {
+	if mtu < model.MtuMin {
+		mtu = model.MtuMin
+	} else if mtu > model.MtuMax {
+		mtu = model.MtuMax
+	}
+	if int32(mtu) == atomic.SwapInt32(&t.mtu, int32(mtu)) || t.tapper == nil || t.state != model.TunTapRunning {
+		return nil
+	}
+	return t.tapper.SetMtu(uint(mtu))
+}
+
+func (t *TunTapService) Mtu() int {
+	return int(atomic.LoadInt32(&t.mtu))
+}
+
 func (t *TunTapService) TapName() string {
 	if t.tapper != nil {
 		return t.tapper.Name()
@@ -154,11 +301,17 @@ func (t *TunTapService) TapName() string {
 	return ""
 }
 func (t *TunTapService) AddRoute(dst, mask, gw string) error {
-	_, err := tuntap.AddRoute(dst, mask, gw)
-	return err
+	return tuntap.AddRoute(t.TapName(), dst, mask, gw)
 }
 
 func (t *TunTapService) DelRoute(dst, mask, gw string) error {
-	_, err := tuntap.DelRoute(dst, mask, gw)
-	return err
+	return tuntap.DelRoute(t.TapName(), dst, mask, gw)
+}
+
+// 虚拟网卡上由本程序添加的路由
+func (t *TunTapService) ListRoutes() ([]*model.TapRoute, error) {
+	if t.tapper == nil {
+		return nil, errors.New("虚拟网卡未启动")
+	}
+	return tuntap.ListRoutes(t.TapName())
 }
@@ -0,0 +1,41 @@
+package tuntap
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrRouteExists   = errors.New("路由已存在")
+	ErrRouteNotFound = errors.New("路由不存在")
+)
+
+// 路由操作失败时返回,Err 为底层错误,可用 errors.Is 判断 ErrRouteExists 等
+type RouteError struct {
+	Op  string // add del list
+	Dst string
+	Gw  string
+	Err error
+}
+
+func (e *RouteError) Error() string {
+	if len(e.Dst) == 0 {
+		return fmt.Sprintf("路由%s失败:%v", routeOpName(e.Op), e.Err)
+	}
+	return fmt.Sprintf("路由%s失败<%s via %s>:%v", routeOpName(e.Op), e.Dst, e.Gw, e.Err)
+}
+
+func (e *RouteError) Unwrap() error {
+	return e.Err
+}
+
+func routeOpName(op string) string {
+	switch op {
+	case "add":
+		return "添加"
+	case "del":
+		return "删除"
+	default:
+		return "查询"
+	}
+}
@@ -0,0 +1,131 @@
+package tuntap
+
+import (
+	"errors"
+	"github.com/mdlayher/netlink"
+	"golang.org/x/sys/unix"
+	"net"
+	"vilan/model"
+)
+
+// 通过rtnetlink管理路由,不依赖route命令(Alpine、OpenWrt等精简系统没有net-tools)
+// 添加的路由使用自定义协议号标记,便于列出和清理本程序添加的路由
+
+const routeProtocol = 0x56 // 路由协议号,ip route 显示为 proto 86
+
+// ip route add dst/mask via gw dev 网卡 proto 86
+func AddRoute(dev, dst, mask, gw string) error {
+	return routeRequest("add", unix.RTM_NEWROUTE, netlink.Create|netlink.Excl, dev, dst, mask, gw)
+}
+
+// ip route del dst/mask via gw dev 网卡
+func DelRoute(dev, dst, mask, gw string) error {
+	return routeRequest("del", unix.RTM_DELROUTE, 0, dev, dst, mask, gw)
+}
+
+// 列出网卡上本程序添加的IPv4路由,dev 为空时列出所有网卡
+func ListRoutes(dev string) ([]*model.TapRoute, error) {
+	fail := func(err error) ([]*model.TapRoute, error) {
+		return nil, &RouteError{Op: "list", Err: err}
+	}
+	conn, err := netlink.Dial(unix.NETLINK_ROUTE, nil)
+	if err != nil {
+		return fail(err)
+	}
+	defer conn.Close()
+	msgs, err := conn.Execute(netlink.Message{
+		Header: netlink.Header{Type: unix.RTM_GETROUTE, Flags: netlink.Request | netlink.Dump},
+		Data:   routeHeader(0, unix.RT_TABLE_MAIN),
+	})
+	if err != nil {
+		return fail(err)
+	}
+	routes := make([]*model.TapRoute, 0)
+	for _, msg := range msgs {
+		if len(msg.Data) < unix.SizeofRtMsg || msg.Data[0] != unix.AF_INET || msg.Data[5] != routeProtocol {
+			continue
+		}
+		ad, err := netlink.NewAttributeDecoder(msg.Data[unix.SizeofRtMsg:])
+		if err != nil {
+			return fail(err)
+		}
+		route := &model.TapRoute{Mask: net.IP(net.CIDRMask(int(msg.Data[1]), 32)).String(), Dst: "0.0.0.0"}
+		for ad.Next() {
+			switch ad.Type() {
+			case unix.RTA_DST:
+				route.Dst = net.IP(ad.Bytes()).String()
+			case unix.RTA_GATEWAY:
+				route.Gw = net.IP(ad.Bytes()).String()
+			case unix.RTA_OIF:
+				if ifi, e := net.InterfaceByIndex(int(ad.Uint32())); e == nil {
+					route.Dev = ifi.Name
+				}
+			}
+		}
+		if err = ad.Err(); err != nil {
+			return fail(err)
+		}
+		if len(dev) == 0 || route.Dev == dev {
+			routes = append(routes, route)
+		}
+	}
+	return routes, nil
+}
+
+func routeRequest(op string, msgType netlink.HeaderType, flags netlink.HeaderFlags, dev, dst, mask, gw string) error {
+	fail := func(err error) error {
+		return &RouteError{Op: op, Dst: dst + "/" + mask, Gw: gw, Err: err}
+	}
+	dstIp, gwIp := net.ParseIP(dst).To4(), net.ParseIP(gw).To4()
+	maskIp := net.ParseIP(mask).To4()
+	if dstIp == nil || gwIp == nil || maskIp == nil {
+		return fail(errors.New("无效的IPv4地址"))
+	}
+	ones, bits := net.IPMask(maskIp).Size()
+	if bits == 0 {
+		return fail(errors.New("无效的子网掩码"))
+	}
+	ifi, err := net.InterfaceByName(dev)
+	if err != nil {
+		return fail(err)
+	}
+	ae := netlink.NewAttributeEncoder()
+	ae.Bytes(unix.RTA_DST, dstIp.Mask(net.IPMask(maskIp)))
+	ae.Bytes(unix.RTA_GATEWAY, gwIp)
+	ae.Uint32(unix.RTA_OIF, uint32(ifi.Index))
+	attrs, err := ae.Encode()
+	if err != nil {
+		return fail(err)
+	}
+
+	conn, err := netlink.Dial(unix.NETLINK_ROUTE, nil)
+	if err != nil {
+		return fail(err)
+	}
+	defer conn.Close()
+	_, err = conn.Execute(netlink.Message{
+		Header: netlink.Header{Type: msgType, Flags: netlink.Request | netlink.Acknowledge | flags},
+		Data:   append(routeHeader(uint8(ones), unix.RT_TABLE_MAIN), attrs...),
+	})
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, unix.EEXIST):
+		return fail(ErrRouteExists)
+	case errors.Is(err, unix.ESRCH):
+		return fail(ErrRouteNotFound)
+	}
+	return fail(err)
+}
+
+// struct rtmsg
+func routeHeader(dstLen uint8, table uint8) []byte {
+	b := make([]byte, unix.SizeofRtMsg)
+	b[0] = unix.AF_INET
+	b[1] = dstLen
+	b[4] = table
+	b[5] = routeProtocol
+	b[6] = unix.RT_SCOPE_UNIVERSE
+	b[7] = unix.RTN_UNICAST
+	return b
+}
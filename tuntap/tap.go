@@ -10,6 +10,7 @@ type TapDevice interface {
 	SetMac(mac uint64) error
 	GetMac() (uint64, error)
 	SetIpAddr(addr, mask string) error
+	SetIp6Addr(addr string, prefixLen int) error
 	GetIpAddr() (string, string, error)
 	SetMtu(mtu uint) error
 	Name() string
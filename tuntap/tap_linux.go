@@ -101,7 +101,17 @@ func ioctl(fd uintptr, request uintptr, argp uintptr) error {
 	return nil
 }
 
+// 二层设备,读写以太网帧
 func CreateTapDevice(name string) (TapDevice, error) {
+	return createDevice(name, cIFFTAP)
+}
+
+// 三层设备,读写IP报文,没有MAC地址
+func CreateTunDevice(name string) (TapDevice, error) {
+	return createDevice(name, cIFFTUN)
+}
+
+func createDevice(name string, devFlag uint16) (TapDevice, error) {
 	if len(name) == 0 {
 		return nil, errors.New("没有有效的名称")
 	}
@@ -116,7 +126,7 @@ func CreateTapDevice(name string) (TapDevice, error) {
 	}
 
 	var req ifReq
-	req.Flags = cIFFNOPI | cIFFTAP | cIFFMULTIQUEUE
+	req.Flags = cIFFNOPI | devFlag | cIFFMULTIQUEUE
 	copy(req.Name[:0x0f], name)
 
 	err = ioctl(uintptr(tapLinux.fd), syscall.TUNSETIFF, uintptr(unsafe.Pointer(&req)))
@@ -171,6 +181,18 @@ func (t *TapLinux) SetIpAddr(ip, mask string) error {
 	return err
 }
 
+// ip -6 addr replace addr/prefixLen dev 网卡
+func (t *TapLinux) SetIp6Addr(addr string, prefixLen int) error {
+	args := []string{
+		"-6", "addr", "replace", fmt.Sprintf("%s/%d", addr, prefixLen), "dev", t.devName,
+	}
+	output, err := exec.Command("ip", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
 func (t *TapLinux) GetIpAddr() (string, string, error) {
 	command := "ip addr show dev " + t.devName + " | grep 'inet ' | awk '{print $2}'"
 	cmd := exec.Command("sh", "-c", command)
@@ -221,19 +243,3 @@ func (t *TapLinux) Read(buf []byte) (int, error) {
 func (t *TapLinux) Write(buf []byte) (int, error) {
 	return t.ReadWriteCloser.Write(buf)
 }
-
-// route add -net 172.28.96.0/19 gw 192.168.1.151
-func AddRoute(dst, mask, gw string) ([]byte, error) {
-	args := []string{
-		"add", "-net", dst, "netmask", mask, "gw", gw,
-	}
-	return exec.Command("route", args...).CombinedOutput()
-}
-
-// route del -net 172.28.96.0/19 gw 192.168.1.151
-func DelRoute(dst, mask, gw string) ([]byte, error) {
-	args := []string{
-		"del", "-net", dst, "netmask", mask, "gw", gw,
-	}
-	return exec.Command("route", args...).CombinedOutput()
-}
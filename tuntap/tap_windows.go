@@ -6,10 +6,13 @@ import (
 	"fmt"
 	w "golang.org/x/sys/windows"
 	r "golang.org/x/sys/windows/registry"
+	"net"
 	"os/exec"
 	"regexp"
+	"strings"
 	"syscall"
 	"unsafe"
+	"vilan/model"
 )
 
 func CtlCode(DeviceType uint32, Function uint32, Method uint32, Access uint32) uint32 {
@@ -35,6 +38,7 @@ var TapIoctlSetMediaStatus = TapControlCode(6, MethodBuffered)
 //var TAP_IOCTL_CONFIG_DHCP_MASQ = TAP_CONTROL_CODE(7, METHOD_BUFFERED)
 //var TAP_IOCTL_GET_LOG_LINE = TAP_CONTROL_CODE(8, METHOD_BUFFERED)
 //var TAP_IOCTL_CONFIG_DHCP_SET_OPT = TAP_CONTROL_CODE(9, METHOD_BUFFERED)
+var TapIoctlConfigTun = TapControlCode(10, MethodBuffered)
 
 const (
 	// tapDriverKey is the location of the TAP driver key.
@@ -55,6 +59,7 @@ type TapWindows struct {
 	wo             *syscall.Overlapped
 	devName        string
 	deviceRegistry string
+	tun            bool // TUN模式,驱动去除以太网头并自行应答ARP
 }
 
 func init() {
@@ -102,7 +107,17 @@ func lookupAdapterRegPath(deviceRegistry string) string {
 	return ""
 }
 
+// 二层设备,读写以太网帧
 func CreateTapDevice(name string) (TapDevice, error) {
+	return createDevice(name, false)
+}
+
+// 三层设备,读写IP报文,设置IP地址时配置驱动的TUN模式
+func CreateTunDevice(name string) (TapDevice, error) {
+	return createDevice(name, true)
+}
+
+func createDevice(name string, tun bool) (TapDevice, error) {
 	name = ""
 	key, ok := r.OpenKey(r.LOCAL_MACHINE, netConfigKey, r.READ)
 	if ok != nil {
@@ -110,7 +125,7 @@ func CreateTapDevice(name string) (TapDevice, error) {
 	}
 	keys, ok := key.ReadSubKeyNames(0)
 	_ = key.Close()
-	tapWindows := &TapWindows{}
+	tapWindows := &TapWindows{tun: tun}
 	for _, subKey := range keys {
 		tapWindows.deviceRegistry = subKey
 		keyPath := netConfigKey + "\\" + subKey + "\\Connection"
@@ -236,6 +251,11 @@ func (t *TapWindows) GetMac() (uint64, error) {
 }
 
 func (t *TapWindows) SetIpAddr(addr, mask string) error {
+	if t.tun {
+		if err := t.configTun(addr, mask); err != nil {
+			return err
+		}
+	}
 	args := []string{
 		"interface", "ipv4", "set", "address",
 		t.devName, "static", addr, mask,
@@ -246,6 +266,36 @@ func (t *TapWindows) SetIpAddr(addr, mask string) error {
 	return err
 }
 
+// 参数依次为本地地址、网段、掩码,均为网络字节序
+func (t *TapWindows) configTun(addr, mask string) error {
+	ip, ipMask := net.ParseIP(addr).To4(), net.ParseIP(mask).To4()
+	if ip == nil || ipMask == nil {
+		return errors.New("无效的TUN地址或掩码")
+	}
+	param := make([]byte, 12)
+	copy(param[0:], ip)
+	for i := 0; i < 4; i++ {
+		param[4+i] = ip[i] & ipMask[i]
+	}
+	copy(param[8:], ipMask)
+	var ret uint32
+	return w.DeviceIoControl(t.handler, TapIoctlConfigTun, &param[0], uint32(len(param)), &param[0], uint32(len(param)), &ret, nil)
+}
+
+func (t *TapWindows) SetIp6Addr(addr string, prefixLen int) error {
+	args := []string{
+		"interface", "ipv6", "set", "address",
+		"interface=" + t.devName, fmt.Sprintf("address=%s/%d", addr, prefixLen), "store=active",
+	}
+	cmd := exec.Command("netsh", args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: 0x08000000}
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%v %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
 func (t *TapWindows) GetIpAddr() (string, string, error) {
 	args := []string{
 		"interface", "ipv4", "show", "address",
@@ -342,21 +392,31 @@ func (t *TapWindows) Write(buf []byte) (int, error) {
 }
 
 // route add 172.28.96.0 mask 255.255.224.0 192.168.1.151
-func AddRoute(dst, mask, gw string) ([]byte, error) {
+func AddRoute(dev, dst, mask, gw string) error {
 	args := []string{
 		"add", dst, "mask", mask, gw,
 	}
-	cmd := exec.Command("route", args...)
-	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: 0x08000000}
-	return cmd.CombinedOutput()
+	return routeCommand("add", dst+"/"+mask, gw, args)
 }
 
 // route delete 172.28.96.0 mask 255.255.224.0 192.168.1.151
-func DelRoute(dst, mask, gw string) ([]byte, error) {
+func DelRoute(dev, dst, mask, gw string) error {
 	args := []string{
 		"delete", dst, "mask", mask, gw,
 	}
+	return routeCommand("del", dst+"/"+mask, gw, args)
+}
+
+func ListRoutes(dev string) ([]*model.TapRoute, error) {
+	return nil, &RouteError{Op: "list", Err: errors.New("Windows暂不支持列出路由")}
+}
+
+func routeCommand(op, dst, gw string, args []string) error {
 	cmd := exec.Command("route", args...)
 	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: 0x08000000}
-	return cmd.CombinedOutput()
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return &RouteError{Op: op, Dst: dst, Gw: gw, Err: fmt.Errorf("%v %s", err, strings.TrimSpace(string(output)))}
+	}
+	return nil
 }
@@ -6,28 +6,24 @@ import (
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 	"os"
 	"runtime"
-	"strconv"
-	"strings"
 	"vilan/app"
 	"vilan/common"
-	"vilan/config"
+	"vilan/control"
 	"vilan/model"
 	"vilan/protocol"
-	"vilan/serial"
 	"vilan/service"
 )
 
 // WailsApp struct
 type WailsApp struct {
-	ctx       context.Context
-	ti        *TrayIcon
-	logEvents []map[string]interface{}
-	isInit    bool
+	ctx    context.Context
+	ti     *TrayIcon
+	isInit bool
 }
 
 // NewWailsApp creates a new WailsApp application struct
 func NewWailsApp() *WailsApp {
-	return &WailsApp{logEvents: make([]map[string]interface{}, 0), isInit: false}
+	return &WailsApp{isInit: false}
 }
 
 // startup is called at application startup
@@ -66,59 +62,12 @@ func (a *WailsApp) domReady(context.Context) {
 	}
 	a.isInit = true
 	// APP配置
-	app.Logger = common.NewLogger(true, false, common.Error)
-	if err := config.InitAppConfig(); err != nil {
-		app.Logger.Error("配置加载失败,程序将以默认配置运行.")
-	} else {
-		app.Logger = common.NewLogger(config.AppConfig.EnableLog, config.AppConfig.SaveLog, config.AppConfig.LogLevel)
-		app.Logger.Info("程序配置加载成功")
-	}
+	service.LoadAppConfig()
 	app.Logger.SetFunc(a.onLog)
 
-	// 创建实例
-	app.TunTapService = service.NewTunTapService()
-	app.RuntimeService = service.NewRuntimeService()
-	app.P2pService = service.NewP2pService()
-	app.SerialService = serial.NewPortService()
-	// TunTapService 初始化
-	if config.AppConfig.TapConfig.IpMode == model.Static && config.AppConfig.TapConfig.HwMac != 0 {
-		if err := app.TunTapService.Start(); err != nil {
-			app.Logger.Error("虚拟网卡初始化失败:", err.Error())
-		} else {
-			app.Logger.Info("虚拟网卡初始化成功,IP ", common.Uint32toIpV4(config.AppConfig.TapConfig.IpAddr),
-				" MAC ", common.Uint64ToMacStr(config.AppConfig.TapConfig.HwMac))
-		}
-	}
-
-	// Runtime Service 初始化
-	if err := app.RuntimeService.Init(); err != nil {
-		app.Logger.Error("运行时服务配置初始化失败:", err.Error())
-		return
-	}
-	app.Logger.Info("运行时服务配置初始化成功")
-
-	if err := app.RuntimeService.Start(); err != nil {
-		app.Logger.Error("运行时服务启动失败:", err.Error())
-		return
-	}
-	app.Logger.Info("运行时服务启动成功")
-
-	// P2p Service 初始化
-	if err := app.P2pService.Start(); err != nil {
-		app.Logger.Error("P2P管理服务启动失败:", err.Error())
+	if err := service.StartServices(); err != nil {
 		return
 	}
-	app.Logger.Info("P2P管理服务启动成功")
-
-	if config.AppConfig.AllowVisitPort {
-		if err := app.SerialService.Start(); err != nil {
-			app.Logger.Error("串口服务启动失败:", err.Error())
-		} else {
-			app.Logger.Info("串口服务启动成功,监听端口:48532")
-		}
-	} else {
-		app.Logger.Info("当前设备串口已禁止远程访问")
-	}
 
 	wailsRuntime.EventsEmit(a.ctx, "peerState", app.RuntimeService.PeerState())
 
@@ -134,18 +83,7 @@ func (a *WailsApp) beforeClose(context.Context) (prevent bool) {
 		return false
 	}
 	if dialog == "Yes" {
-		if app.SerialService != nil {
-			app.SerialService.Stop()
-		}
-		if app.RuntimeService != nil {
-			app.RuntimeService.Stop()
-		}
-		if app.P2pService != nil {
-			app.P2pService.Stop()
-		}
-		if app.Logger != nil {
-			app.Logger.Info("服务已关闭")
-		}
+		service.StopServices()
 		return false
 	}
 
@@ -173,219 +111,27 @@ func (a *WailsApp) GetPeerState() uint {
 	return uint(app.RuntimeService.PeerState())
 }
 func (a *WailsApp) RequestGroupPeers() []*model.PeerInfo {
-	if app.RuntimeService == nil {
-		return nil
-	}
-	infos := app.RuntimeService.GetGroupPeers(config.AppConfig.PeerConfig.GroupName, true)
-	if infos != nil {
-		peers := make([]*model.PeerInfo, len(infos))
-		for i := range infos {
-			peers[i] = model.ProtoToModel(infos[i])
-			if app.P2pService.IsP2P(infos[i].PeerMac) {
-				peers[i].ConnectType = 1
-			}
-		}
-		return sortPeers(peers)
-	}
-	return nil
-}
-
-// 排序 先在线 按IP 小到大
-func sortPeers(peers []*model.PeerInfo) []*model.PeerInfo {
-	if peers == nil || len(peers) == 0 {
-		return peers
-	}
-	onlinePeers := make([]*model.PeerInfo, 0)
-	offlinePeers := make([]*model.PeerInfo, 0)
-	for i := range peers {
-		if peers[i].Online {
-			onlinePeers = append(onlinePeers, peers[i])
-		} else {
-			offlinePeers = append(offlinePeers, peers[i])
-		}
-	}
-
-	num := len(onlinePeers)
-	if num > 0 {
-		for i := 0; i < num-1; i++ {
-			for j := 0; j < num-i-1; j++ {
-				if compareIP(onlinePeers[j].NetAddr, onlinePeers[j+1].NetAddr) {
-					temp := onlinePeers[j]
-					onlinePeers[j] = onlinePeers[j+1]
-					onlinePeers[j+1] = temp
-				}
-			}
-		}
-	}
-	num = len(offlinePeers)
-	if num > 0 {
-		for i := 0; i < num-1; i++ {
-			for j := 0; j < num-i-1; j++ {
-				if compareIP(offlinePeers[j].NetAddr, offlinePeers[j+1].NetAddr) {
-					temp := offlinePeers[j]
-					offlinePeers[j] = offlinePeers[j+1]
-					offlinePeers[j+1] = temp
-				}
-			}
-		}
-	}
-	return append(onlinePeers, offlinePeers...)
-}
-
-func compareIP(ip0, ip1 string) bool {
-	ipStr0 := strings.ReplaceAll(ip0, "/", "")
-	ipStr0 = strings.ReplaceAll(ipStr0, ".", "")
-	ipVal0, err := strconv.ParseUint(ipStr0, 10, 64)
-	if err != nil {
-		return false
-	}
-	ipStr1 := strings.ReplaceAll(ip1, "/", "")
-	ipStr1 = strings.ReplaceAll(ipStr1, ".", "")
-	ipVal1, err := strconv.ParseUint(ipStr1, 10, 64)
-	if err != nil {
-		return false
-	}
-	return ipVal0 > ipVal1
+	return control.GroupPeers(true)
 }
 
 func (a *WailsApp) GetLinkInfos(macStr string) []*protocol.LinkInfo {
-	mac, err := strconv.ParseUint(macStr, 10, 64)
-	if err != nil {
-		app.Logger.Debug("地址转换错误:", macStr, err)
-		return nil
-	}
-	return app.RuntimeService.GetLinkInfos(mac)
+	return control.GetLinkInfos(macStr)
 }
 
 func (a *WailsApp) SelectPeer(macStr string) bool {
-	mac, err := strconv.ParseUint(macStr, 10, 64)
-	if err != nil {
-		return false
-	}
-	return app.RuntimeService.AddRoute(mac)
+	return control.SelectPeer(macStr)
+}
+
+func (a *WailsApp) UnselectPeer(macStr string) bool {
+	return control.UnselectPeer(macStr)
 }
 
 func (a *WailsApp) GetConfig() *model.Config {
-	defer func() {
-		if err := recover(); err != nil {
-			app.Logger.Error("配置请求出错:", err)
-		}
-	}()
-	if app.RuntimeService == nil {
-		return nil
-	}
-	mask := uint32(0xFFFFFFFF) << (32 - config.AppConfig.TapConfig.IpMask)
-	conf := &model.Config{
-		ServerIp:   config.AppConfig.ServerIp,
-		ServerPort: config.AppConfig.ServerPort,
-		PeerName:   config.AppConfig.PeerConfig.Name,
-		CryptType:  config.AppConfig.PeerConfig.CryptType,
-		PeerPwd:    config.AppConfig.PeerConfig.PeerPwd,
-		GroupName:  config.AppConfig.PeerConfig.GroupName,
-		GroupPwd:   config.AppConfig.PeerConfig.GroupPwd,
-		HwMac:      common.Uint64ToMacStr(config.AppConfig.TapConfig.HwMac),
-		IpMode:     uint(config.AppConfig.TapConfig.IpMode),
-		IpAddr:     common.Uint32toIpV4(config.AppConfig.TapConfig.IpAddr),
-		IpMask:     common.Uint32toIpV4(mask),
-		EnableLog:  config.AppConfig.EnableLog,
-		LogLevel:   byte(config.AppConfig.LogLevel),
-	}
-	if app.RuntimeService.PeerState() >= model.StateInitOk {
-		conf.TabName = app.TunTapService.TapName()
-	}
-	return conf
+	return control.GetConfig()
 }
 
 func (a *WailsApp) SaveConfig(conf *model.Config) string {
-	if conf == nil {
-		return "配置信息为空"
-	}
-	if len(conf.PeerName) == 0 {
-		return "终端名称不能为空"
-	}
-	if len(conf.GroupName) == 0 {
-		return "网络组名称不能为空"
-	}
-	//if len(conf.GroupPwd) == 0 {
-	//	return "网络组密码不能为空"
-	//}
-	if conf.CryptType != model.CryptNone && len(conf.PeerPwd) == 0 {
-		return "传输密码不能为空"
-	}
-	_, e := common.IpV4toUint32(conf.ServerIp)
-	if e != nil {
-		return "服务地址格式错误"
-	}
-	ipMode := model.AutoAssign
-	if conf.IpMode == 0x02 {
-		ipMode = model.Static
-	}
-	peerIp, e := common.IpV4toUint32(conf.IpAddr)
-	if e != nil && ipMode != model.AutoAssign {
-		return "分配地址格式错误"
-	}
-	peerMask, e := common.IpV4toUint32(conf.IpMask)
-	if e != nil && ipMode != model.AutoAssign {
-		return "子网掩码格式错误"
-	}
-
-	maskLen := common.MaskBitLen(peerMask)
-	mac, e := common.MacStrToUint64(conf.HwMac)
-	if e != nil {
-		return "MAC地址格式错误"
-	}
-	c := config.NewConfig()
-	if e := config.CopyAppConfigTo(c); e != nil {
-		return "配置载入出错"
-	}
-	c.ServerIp = conf.ServerIp
-	c.ServerPort = conf.ServerPort
-	c.EnableLog = conf.EnableLog
-	c.LogLevel = common.LogType(conf.LogLevel)
-	c.PeerConfig.Name = conf.PeerName
-	c.PeerConfig.CryptType = conf.CryptType
-	c.PeerConfig.PeerPwd = conf.PeerPwd
-	c.PeerConfig.GroupName = conf.GroupName
-	c.PeerConfig.GroupPwd = conf.GroupPwd
-	c.TapConfig.HwMac = mac
-	c.TapConfig.IpMode = ipMode
-	c.TapConfig.IpAddr = peerIp
-	c.TapConfig.IpMask = maskLen
-	if err := config.SaveConfig(c); err != nil {
-		return "配置保存失败"
-	} else {
-		needRestart := false
-		if config.AppConfig.TapConfig.HwMac != c.TapConfig.HwMac ||
-			config.AppConfig.PeerConfig.CryptType != c.PeerConfig.CryptType ||
-			(config.AppConfig.PeerConfig.CryptType != model.CryptNone && config.AppConfig.PeerConfig.PeerPwd != c.PeerConfig.PeerPwd) ||
-			config.AppConfig.TapConfig.IpMode != c.TapConfig.IpMode {
-			needRestart = true
-		} else if (config.AppConfig.TapConfig.IpAddr != c.TapConfig.IpAddr ||
-			config.AppConfig.TapConfig.IpMask != c.TapConfig.IpMask) && c.TapConfig.IpMode != model.AutoAssign {
-			needRestart = true
-		}
-		if config.AppConfig.ServerIp != c.ServerIp ||
-			config.AppConfig.ServerPort != c.ServerPort ||
-			config.AppConfig.PeerConfig.GroupName != c.PeerConfig.GroupName ||
-			config.AppConfig.PeerConfig.GroupPwd != c.PeerConfig.GroupPwd {
-			needRestart = true
-		}
-		config.AppConfig = c
-		if c.EnableLog {
-			app.Logger.Enable()
-		} else {
-			app.Logger.Disable()
-		}
-		app.Logger.SetLogLevel(c.LogLevel)
-		if needRestart {
-			if ex := app.RuntimeService.Restart(); ex != nil {
-				app.Logger.Error("本地服务重启失败:", ex)
-			} else {
-				app.Logger.Info("本地服务重启成功!")
-			}
-		}
-		return "配置保存成功"
-	}
+	return control.SaveConfig(conf)
 }
 
 func (a *WailsApp) UpdateState(state model.PeerState) {
@@ -397,41 +143,24 @@ func (a *WailsApp) UpdateStats(stats *protocol.Statistics) {
 }
 
 func (a *WailsApp) UpdatePeers() {
-	infos := app.RuntimeService.GetGroupPeers(config.AppConfig.PeerConfig.GroupName, false)
-	if infos != nil {
-		peers := make([]*model.PeerInfo, len(infos))
-		count := 0
-		for i := range infos {
-			//if infos[i].DevType == "windows" {
-			//	continue
-			//}
-			peers[count] = model.ProtoToModel(infos[i])
-			if app.P2pService.IsP2P(infos[i].PeerMac) {
-				peers[count].ConnectType = 1
-			}
-			count++
-		}
-		wailsRuntime.EventsEmit(a.ctx, "peerInfos", sortPeers(peers[:count]))
+	if peers := control.GroupPeers(false); peers != nil {
+		wailsRuntime.EventsEmit(a.ctx, "peerInfos", peers)
 	}
 }
 
 func (a *WailsApp) UpdateConfig(peer *model.PeerInfo) map[string]interface{} {
-	return app.RuntimeService.UpdateConfig(peer)
+	return control.UpdateConfig(peer)
 }
 
 // 日志处理
 func (a *WailsApp) GetHisLog() []map[string]interface{} {
-	return a.logEvents
+	return control.Logs.List(0)
 }
 func (a *WailsApp) ClearHisLog() {
-	a.logEvents = make([]map[string]interface{}, 0)
+	control.Logs.Clear()
 }
 
 func (a *WailsApp) onLog(logType common.LogType, time string, content string) {
-	evt := map[string]interface{}{"logType": logType, "time": time, "content": content}
-	if len(a.logEvents) > 100 {
-		a.logEvents = a.logEvents[:90]
-	}
-	a.logEvents = append([]map[string]interface{}{evt}, a.logEvents...)
+	evt := control.Logs.Add(logType, time, content)
 	wailsRuntime.EventsEmit(a.ctx, "log", evt)
 }